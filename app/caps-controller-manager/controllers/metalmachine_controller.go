@@ -8,6 +8,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/go-logr/logr"
 	"github.com/siderolabs/go-pointer"
@@ -402,6 +403,13 @@ func (r *MetalMachineReconciler) patchProviderID(ctx context.Context, cluster *c
 		return err
 	}
 
+	if len(nodes.Items) == 0 {
+		nodes.Items, err = r.findPromotedNodes(ctx, workloadClient, metalMachine.Spec.ServerRef.Name)
+		if err != nil {
+			return err
+		}
+	}
+
 	if len(nodes.Items) == 0 {
 		return fmt.Errorf("no matching nodes found")
 	}
@@ -430,6 +438,37 @@ func (r *MetalMachineReconciler) patchProviderID(ctx context.Context, cluster *c
 	return patchHelper.Patch(ctx, &node)
 }
 
+// findPromotedNodes matches nodes of the servers promoted from adopted servers.
+//
+// Such nodes were not provisioned by Sidero, so they don't have the server UUID label, and they are matched by the system UUID instead.
+func (r *MetalMachineReconciler) findPromotedNodes(ctx context.Context, workloadClient client.Client, serverName string) ([]corev1.Node, error) {
+	var server metalv1.Server
+
+	if err := r.Get(ctx, types.NamespacedName{Name: serverName}, &server); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+
+	if _, promoted := server.Annotations[metalv1.PromotedFromAnnotation]; !promoted {
+		return nil, nil
+	}
+
+	var nodes corev1.NodeList
+
+	if err := workloadClient.List(ctx, &nodes); err != nil {
+		return nil, err
+	}
+
+	var matched []corev1.Node
+
+	for _, node := range nodes.Items {
+		if strings.EqualFold(node.Status.NodeInfo.SystemUUID, serverName) {
+			matched = append(matched, node)
+		}
+	}
+
+	return matched, nil
+}
+
 // createServerBinding updates a server to mark it as "in use" via ServerBinding resource.
 func (r *MetalMachineReconciler) createServerBinding(ctx context.Context, serverClassRef *corev1.ObjectReference, serverObj *metalv1.Server, metalMachine *infrav1.MetalMachine) error {
 	var serverBinding infrav1.ServerBinding
@@ -25,6 +25,17 @@ func (src *Environment) ConvertTo(dstRaw conversion.Hub) error {
 		return err
 	}
 
+	for i := range dst.Status.Conditions {
+		if i < len(restored.Status.Conditions) && restored.Status.Conditions[i].Asset == dst.Status.Conditions[i].Asset {
+			dst.Status.Conditions[i].Reason = restored.Status.Conditions[i].Reason
+			dst.Status.Conditions[i].Message = restored.Status.Conditions[i].Message
+			dst.Status.Conditions[i].MirrorURL = restored.Status.Conditions[i].MirrorURL
+		}
+	}
+
+	dst.Status.BootPath = restored.Status.BootPath
+	dst.Status.Schematic = restored.Status.Schematic
+
 	return nil
 }
 
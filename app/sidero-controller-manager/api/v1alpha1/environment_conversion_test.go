@@ -0,0 +1,56 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha1_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metalv1alpha1 "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha1"
+	metalv1alpha2 "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
+)
+
+func TestEnvironmentConvertRoundTrip(t *testing.T) {
+	src := &metalv1alpha2.Environment{
+		Status: metalv1alpha2.EnvironmentStatus{
+			Conditions: []metalv1alpha2.AssetCondition{
+				{
+					Asset:     metalv1alpha2.Asset{URL: "https://example.com/vmlinuz", SHA512: "abcd"},
+					Status:    "True",
+					Type:      "Ready",
+					MirrorURL: "https://artifacts.local/talos/vmlinuz",
+				},
+				{
+					Asset:   metalv1alpha2.Asset{URL: "https://example.com/initramfs.xz", SHA512: "ef01"},
+					Status:  "False",
+					Type:    "Ready",
+					Reason:  metalv1alpha2.AssetChecksumMismatchReason,
+					Message: "checksum mismatch",
+				},
+			},
+			BootPath: metalv1alpha2.BootPathUKI,
+			Schematic: &metalv1alpha2.SchematicStatus{
+				ID:           "376567988ad370138ad8b2698212367b8edcb69b5fd68c80be1f2ec7d603b4ba",
+				Factory:      "https://factory.talos.dev",
+				TalosVersion: "v1.11.5",
+			},
+		},
+	}
+
+	spoke := &metalv1alpha1.Environment{}
+	require.NoError(t, spoke.ConvertFrom(src))
+
+	assert.Equal(t, []metalv1alpha1.AssetCondition{
+		{Asset: metalv1alpha1.Asset{URL: "https://example.com/vmlinuz", SHA512: "abcd"}, Status: "True", Type: "Ready"},
+		{Asset: metalv1alpha1.Asset{URL: "https://example.com/initramfs.xz", SHA512: "ef01"}, Status: "False", Type: "Ready"},
+	}, spoke.Status.Conditions)
+
+	dst := &metalv1alpha2.Environment{}
+	require.NoError(t, spoke.ConvertTo(dst))
+
+	assert.Equal(t, src.Status, dst.Status)
+}
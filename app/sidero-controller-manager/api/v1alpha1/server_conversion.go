@@ -26,6 +26,8 @@ func (src *Server) ConvertTo(dstRaw conversion.Hub) error {
 		return err
 	}
 
+	dst.Status.ManagementAPIStatus = restored.Status.ManagementAPIStatus
+
 	return nil
 }
 
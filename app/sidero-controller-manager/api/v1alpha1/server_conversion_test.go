@@ -97,3 +97,25 @@ func TestServerConvertV1alpha2V1Alpha1(t *testing.T) {
 		dst.Spec.CPU,
 	)
 }
+
+func TestServerConvertRoundTrip(t *testing.T) {
+	src := &metalv1alpha2.Server{
+		Status: metalv1alpha2.ServerStatus{
+			InUse: true,
+			ManagementAPIStatus: &metalv1alpha2.ManagementAPIStatus{
+				ClusterName: "management",
+				ClusterID:   "c-1",
+				NodeID:      "n-1",
+			},
+		},
+	}
+
+	spoke := &metalv1alpha1.Server{}
+	require.NoError(t, spoke.ConvertFrom(src))
+
+	dst := &metalv1alpha2.Server{}
+	require.NoError(t, spoke.ConvertTo(dst))
+
+	assert.True(t, dst.Status.InUse)
+	assert.Equal(t, src.Status.ManagementAPIStatus, dst.Status.ManagementAPIStatus)
+}
@@ -26,6 +26,8 @@ func (src *ServerClass) ConvertTo(dstRaw conversion.Hub) error {
 		return err
 	}
 
+	dst.Spec.ManagementAPI = restored.Spec.ManagementAPI
+
 	return nil
 }
 
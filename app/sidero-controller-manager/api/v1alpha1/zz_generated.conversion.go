@@ -303,6 +303,9 @@ func autoConvert_v1alpha2_AssetCondition_To_v1alpha1_AssetCondition(in *v1alpha2
 	}
 	out.Status = in.Status
 	out.Type = in.Type
+	// INFO: in.Reason opted out of conversion generation
+	// INFO: in.Message opted out of conversion generation
+	// INFO: in.MirrorURL opted out of conversion generation
 	return nil
 }
 
@@ -421,7 +424,17 @@ func Convert_v1alpha2_Environment_To_v1alpha1_Environment(in *v1alpha2.Environme
 
 func autoConvert_v1alpha1_EnvironmentList_To_v1alpha2_EnvironmentList(in *EnvironmentList, out *v1alpha2.EnvironmentList, s conversion.Scope) error {
 	out.ListMeta = in.ListMeta
-	out.Items = *(*[]v1alpha2.Environment)(unsafe.Pointer(&in.Items))
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]v1alpha2.Environment, len(*in))
+		for i := range *in {
+			if err := Convert_v1alpha1_Environment_To_v1alpha2_Environment(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.Items = nil
+	}
 	return nil
 }
 
@@ -432,7 +445,17 @@ func Convert_v1alpha1_EnvironmentList_To_v1alpha2_EnvironmentList(in *Environmen
 
 func autoConvert_v1alpha2_EnvironmentList_To_v1alpha1_EnvironmentList(in *v1alpha2.EnvironmentList, out *EnvironmentList, s conversion.Scope) error {
 	out.ListMeta = in.ListMeta
-	out.Items = *(*[]Environment)(unsafe.Pointer(&in.Items))
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Environment, len(*in))
+		for i := range *in {
+			if err := Convert_v1alpha2_Environment_To_v1alpha1_Environment(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.Items = nil
+	}
 	return nil
 }
 
@@ -472,7 +495,17 @@ func Convert_v1alpha2_EnvironmentSpec_To_v1alpha1_EnvironmentSpec(in *v1alpha2.E
 }
 
 func autoConvert_v1alpha1_EnvironmentStatus_To_v1alpha2_EnvironmentStatus(in *EnvironmentStatus, out *v1alpha2.EnvironmentStatus, s conversion.Scope) error {
-	out.Conditions = *(*[]v1alpha2.AssetCondition)(unsafe.Pointer(&in.Conditions))
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1alpha2.AssetCondition, len(*in))
+		for i := range *in {
+			if err := Convert_v1alpha1_AssetCondition_To_v1alpha2_AssetCondition(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.Conditions = nil
+	}
 	return nil
 }
 
@@ -482,7 +515,19 @@ func Convert_v1alpha1_EnvironmentStatus_To_v1alpha2_EnvironmentStatus(in *Enviro
 }
 
 func autoConvert_v1alpha2_EnvironmentStatus_To_v1alpha1_EnvironmentStatus(in *v1alpha2.EnvironmentStatus, out *EnvironmentStatus, s conversion.Scope) error {
-	out.Conditions = *(*[]AssetCondition)(unsafe.Pointer(&in.Conditions))
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]AssetCondition, len(*in))
+		for i := range *in {
+			if err := Convert_v1alpha2_AssetCondition_To_v1alpha1_AssetCondition(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.Conditions = nil
+	}
+	// INFO: in.BootPath opted out of conversion generation
+	// INFO: in.Schematic opted out of conversion generation
 	return nil
 }
 
@@ -729,6 +774,7 @@ func autoConvert_v1alpha2_ServerClassSpec_To_v1alpha1_ServerClassSpec(in *v1alph
 	out.ConfigPatches = *(*[]ConfigPatches)(unsafe.Pointer(&in.ConfigPatches))
 	// INFO: in.StrategicPatches opted out of conversion generation
 	out.BootFromDiskMethod = types.BootFromDisk(in.BootFromDiskMethod)
+	// INFO: in.ManagementAPI opted out of conversion generation
 	return nil
 }
 
@@ -855,6 +901,7 @@ func autoConvert_v1alpha2_ServerStatus_To_v1alpha1_ServerStatus(in *v1alpha2.Ser
 	out.Conditions = *(*[]v1beta1.Condition)(unsafe.Pointer(&in.Conditions))
 	out.Addresses = *(*[]v1.NodeAddress)(unsafe.Pointer(&in.Addresses))
 	out.Power = in.Power
+	// INFO: in.ManagementAPIStatus opted out of conversion generation
 	return nil
 }
 
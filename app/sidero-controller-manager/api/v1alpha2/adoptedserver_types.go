@@ -10,6 +10,13 @@ import (
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 )
 
+// Machine configuration apply modes of an adopted server.
+const (
+	ConfigApplyModeAuto     = "auto"
+	ConfigApplyModeNoReboot = "no-reboot"
+	ConfigApplyModeDryRun   = "dry-run"
+)
+
 // ManagementAPIConfig defines the configuration for Talos Management API integration.
 type ManagementAPIConfig struct {
 	// Enabled indicates if Management API integration is enabled.
@@ -25,11 +32,15 @@ type ManagementAPIConfig struct {
 	// +optional
 	ClusterName string `json:"clusterName,omitempty"`
 
-	// ClusterID is the UUID of the cluster in the Management API.
+	// ClusterID is the UUID of an existing cluster in the Management API to join.
+	// If empty, the cluster is registered on the first sync, and its ID is recorded in status.managementAPIStatus.clusterID.
 	// +optional
 	ClusterID string `json:"clusterID,omitempty"`
 
 	// SecretRef references a secret containing Management API credentials.
+	// The secret should contain an `api-key`, a bearer `token`, or a `tls.crt` and `tls.key` client certificate for mTLS,
+	// and optionally a `ca.crt` bundle to verify the Management API certificate.
+	// Changes to the secret are picked up on the next sync.
 	// +optional
 	SecretRef *corev1.SecretReference `json:"secretRef,omitempty"`
 }
@@ -68,6 +79,18 @@ type TalosConfig struct {
 	// +optional
 	KubernetesVersion string `json:"kubernetesVersion,omitempty"`
 
+	// DesiredTalosVersion is the Talos version the node should be upgraded to.
+	// When it differs from the running version, the node is upgraded via the Talos API preserving
+	// the ephemeral partition, and TalosVersion is updated once the upgraded node is healthy.
+	// Example: v1.11.5
+	// +optional
+	DesiredTalosVersion string `json:"desiredTalosVersion,omitempty"`
+
+	// InstallerImage is the installer image used to upgrade to DesiredTalosVersion.
+	// Defaults to ghcr.io/siderolabs/installer:<desiredTalosVersion>.
+	// +optional
+	InstallerImage string `json:"installerImage,omitempty"`
+
 	// NodeType indicates if this is a control plane or worker node.
 	// Valid values: controlplane, worker
 	// +kubebuilder:validation:Enum=controlplane;worker
@@ -77,6 +100,30 @@ type TalosConfig struct {
 	// Hostname is the node hostname.
 	// +optional
 	Hostname string `json:"hostname,omitempty"`
+
+	// SecretRef references a secret containing Talos API client credentials.
+	// The secret should contain either a `talosconfig` key, or `ca.crt`, `tls.crt` and `tls.key` keys.
+	// +optional
+	SecretRef *corev1.SecretReference `json:"secretRef,omitempty"`
+}
+
+// PromotionConfig requests promotion of the adopted server into a Server managed by Sidero.
+//
+// Promotion creates a Server, ServerBinding, MetalMachine and Machine which import the running node
+// into an existing CAPI Cluster without reprovisioning it.
+type PromotionConfig struct {
+	// ClusterRef references the CAPI Cluster the node is imported into.
+	// +kubebuilder:validation:Required
+	ClusterRef corev1.ObjectReference `json:"clusterRef"`
+
+	// ServerClassRef optionally records the ServerClass the Server belongs to.
+	// +optional
+	ServerClassRef *corev1.ObjectReference `json:"serverClassRef,omitempty"`
+
+	// MachineName is the name of the created Machine and MetalMachine.
+	// Defaults to the name of the AdoptedServer.
+	// +optional
+	MachineName string `json:"machineName,omitempty"`
 }
 
 // AdoptedServerSpec defines the desired state of AdoptedServer.
@@ -93,6 +140,27 @@ type AdoptedServerSpec struct {
 	// +optional
 	SideroLink *SideroLinkConfig `json:"sideroLink,omitempty"`
 
+	// Promotion requests promotion of the server into the CAPI lifecycle.
+	// +optional
+	Promotion *PromotionConfig `json:"promotion,omitempty"`
+
+	// ConfigPatches are RFC6902 patches applied to the running machine configuration.
+	//
+	// Patches are applied once, and re-applied only when they change; removing patches doesn't revert them.
+	// +optional
+	ConfigPatches []ConfigPatches `json:"configPatches,omitempty"`
+
+	// StrategicPatches are strategic merge patches applied to the running machine configuration after ConfigPatches.
+	// +optional
+	StrategicPatches []string `json:"strategicPatches,omitempty"`
+
+	// ConfigApplyMode controls how the patched machine configuration is applied.
+	// Valid values: auto (reboot if required), no-reboot (fail if a reboot is required), dry-run (validate only).
+	// Defaults to auto.
+	// +kubebuilder:validation:Enum=auto;no-reboot;dry-run
+	// +optional
+	ConfigApplyMode string `json:"configApplyMode,omitempty"`
+
 	// Accepted indicates if the server has been accepted for management.
 	// Similar to Server.Spec.Accepted, this controls whether Sidero will manage this node.
 	// +optional
@@ -116,6 +184,98 @@ const (
 	ConditionSideroLinkReady clusterv1.ConditionType = "SideroLinkReady"
 	// ConditionManagementAPISync indicates whether the server is synced with Management API.
 	ConditionManagementAPISync clusterv1.ConditionType = "ManagementAPISync"
+	// ConditionPromoted indicates whether the server was promoted into a Server bound to a CAPI cluster.
+	ConditionPromoted clusterv1.ConditionType = "Promoted"
+	// ConditionVersionDrift indicates whether the versions running on the node differ from the versions in the spec.
+	ConditionVersionDrift clusterv1.ConditionType = "VersionDrift"
+	// ConditionUpgraded indicates whether the node runs the desired Talos version.
+	ConditionUpgraded clusterv1.ConditionType = "Upgraded"
+	// ConditionConfigPatched indicates whether the machine configuration patches were applied to the node.
+	ConditionConfigPatched clusterv1.ConditionType = "ConfigPatched"
+	// ConditionRemovedUpstream is set on the AdoptedServers created from a ManagementAPIInventory
+	// once the server is no longer listed by the Management API.
+	ConditionRemovedUpstream clusterv1.ConditionType = "RemovedUpstream"
+)
+
+// PromotedFromAnnotation is set on the Server created by promotion and holds the name of the AdoptedServer.
+//
+// Servers with this annotation are treated as already installed.
+const PromotedFromAnnotation = "metal.sidero.dev/promoted-from"
+
+// AdoptedServerSecretRefField is used to index AdoptedServers on the secrets they reference, as "namespace/name".
+const AdoptedServerSecretRefField = "spec.secretRefs"
+
+// Condition reasons for the Connected condition.
+const (
+	// TalosCredentialsUnavailableReason (Severity=Error) documents that Talos API credentials could not be loaded.
+	TalosCredentialsUnavailableReason = "CredentialsUnavailable"
+	// TalosAuthenticationFailedReason (Severity=Error) documents that the Talos API rejected the client credentials.
+	TalosAuthenticationFailedReason = "AuthenticationFailed"
+	// TalosUnreachableReason (Severity=Warning) documents that the Talos API endpoint is not reachable.
+	TalosUnreachableReason = "Unreachable"
+	// TalosTimeoutReason (Severity=Warning) documents that the Talos API did not respond in time.
+	TalosTimeoutReason = "Timeout"
+	// TalosConnectivityCheckFailedReason (Severity=Warning) documents any other Talos API failure.
+	TalosConnectivityCheckFailedReason = "ConnectivityCheckFailed"
+)
+
+// Condition reasons for the SideroLinkReady condition.
+const (
+	// SideroLinkSetupFailedReason (Severity=Warning) documents that SideroLink configuration could not be pushed to the node.
+	SideroLinkSetupFailedReason = "SetupFailed"
+	// SideroLinkWaitingForNodeReason (Severity=Info) documents that the node hasn't established the SideroLink tunnel yet.
+	SideroLinkWaitingForNodeReason = "WaitingForNode"
+)
+
+// Condition reasons for the ManagementAPISync condition.
+const (
+	// ManagementAPISyncFailedReason (Severity=Warning) documents that the sync with the Management API failed.
+	ManagementAPISyncFailedReason = "SyncFailed"
+	// ManagementAPICredentialsUnavailableReason (Severity=Error) documents that the Management API credentials could not be loaded.
+	ManagementAPICredentialsUnavailableReason = "CredentialsUnavailable"
+	// ManagementAPIAuthenticationFailedReason (Severity=Error) documents that the Management API rejected the credentials.
+	ManagementAPIAuthenticationFailedReason = "AuthenticationFailed"
+	// ManagementAPIUnavailableReason (Severity=Warning) documents that the requests are suspended after repeated Management API failures.
+	ManagementAPIUnavailableReason = "Unavailable"
+	// ManagementAPIRemovedUpstreamReason (Severity=Info) documents that the sync is paused as the server was removed from the Management API.
+	ManagementAPIRemovedUpstreamReason = "RemovedUpstream"
+)
+
+// UpgradeGroupLabel groups adopted control plane nodes which should be upgraded one at a time.
+//
+// The label is used when the Management API cluster name is not set.
+const UpgradeGroupLabel = "metal.sidero.dev/upgrade-group"
+
+// Condition reasons for the Promoted condition.
+const (
+	// PromotionFailedReason (Severity=Warning) documents that promotion objects could not be created.
+	PromotionFailedReason = "PromotionFailed"
+)
+
+// Condition reasons for the VersionDrift condition.
+const (
+	// VersionMismatchReason documents that the running versions differ from the spec.
+	VersionMismatchReason = "VersionMismatch"
+	// VersionsMatchReason (Severity=Info) documents that the running versions match the spec.
+	VersionsMatchReason = "VersionsMatch"
+)
+
+// Condition reasons for the Upgraded condition.
+const (
+	// UpgradeWaitingForLockReason (Severity=Info) documents that another control plane node of the cluster is being upgraded.
+	UpgradeWaitingForLockReason = "WaitingForLock"
+	// UpgradeInProgressReason (Severity=Info) documents that the upgrade was started, and the node hasn't come back healthy yet.
+	UpgradeInProgressReason = "UpgradeInProgress"
+	// UpgradeFailedReason (Severity=Warning) documents that the upgrade couldn't be started or didn't complete in time.
+	UpgradeFailedReason = "UpgradeFailed"
+)
+
+// Condition reasons for the ConfigPatched condition.
+const (
+	// ConfigPatchDryRunReason (Severity=Info) documents that the patches were validated, but not applied.
+	ConfigPatchDryRunReason = "DryRun"
+	// ConfigPatchFailedReason (Severity=Warning) documents that the patches couldn't be applied.
+	ConfigPatchFailedReason = "ConfigPatchFailed"
 )
 
 // AdoptedServerStatus defines the observed state of AdoptedServer.
@@ -154,8 +314,28 @@ type AdoptedServerStatus struct {
 	// NodeInfo contains additional information about the node.
 	// +optional
 	NodeInfo *NodeInfo `json:"nodeInfo,omitempty"`
+
+	// Promotion contains references to the objects created by promotion.
+	// +optional
+	Promotion *PromotionStatus `json:"promotion,omitempty"`
+
+	// Upgrade describes the last Talos upgrade of the node.
+	// +optional
+	Upgrade *UpgradeStatus `json:"upgrade,omitempty"`
+
+	// ConfigPatches describes the machine configuration patches applied to the node.
+	// +optional
+	ConfigPatches *ConfigPatchStatus `json:"configPatches,omitempty"`
 }
 
+// Overall health statuses of an adopted server.
+const (
+	HealthStatusHealthy   = "healthy"
+	HealthStatusDegraded  = "degraded"
+	HealthStatusUnhealthy = "unhealthy"
+	HealthStatusUnknown   = "unknown"
+)
+
 // HealthStatus contains health check information.
 type HealthStatus struct {
 	// Status is the overall health status: "healthy", "degraded", "unhealthy", "unknown"
@@ -169,6 +349,32 @@ type HealthStatus struct {
 	// Message contains additional health information or error details.
 	// +optional
 	Message string `json:"message,omitempty"`
+
+	// Checks lists the results of individual health checks.
+	// +optional
+	Checks []HealthCheckResult `json:"checks,omitempty"`
+}
+
+// Results of an individual health check.
+const (
+	HealthCheckPassed  = "Passed"
+	HealthCheckWarning = "Warning"
+	HealthCheckFailed  = "Failed"
+	HealthCheckUnknown = "Unknown"
+)
+
+// HealthCheckResult is the result of a single health check.
+type HealthCheckResult struct {
+	// Name of the check, e.g. "service/etcd" or "memory".
+	Name string `json:"name"`
+
+	// Result of the check: "Passed", "Warning", "Failed" or "Unknown".
+	// +kubebuilder:validation:Enum=Passed;Warning;Failed;Unknown
+	Result string `json:"result"`
+
+	// Message describes the observed state.
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 // ManagementAPIStatus contains sync status with the Management API.
@@ -184,6 +390,87 @@ type ManagementAPIStatus struct {
 	// Error contains any error from the last sync attempt.
 	// +optional
 	Error string `json:"error,omitempty"`
+
+	// ClusterID is the UUID of the cluster in the Management API, as registered or taken from the spec.
+	// +optional
+	ClusterID string `json:"clusterID,omitempty"`
+
+	// NodeID is the UUID of the node in the Management API, the node status is updated once it's registered.
+	// +optional
+	NodeID string `json:"nodeID,omitempty"`
+
+	// ClusterName is the name of the Management API cluster the Server is registered in,
+	// which follows the allocation of the Server. It's not set for AdoptedServers.
+	// +optional
+	ClusterName string `json:"clusterName,omitempty"`
+}
+
+// PromotionStatus contains references to the objects created by promotion.
+type PromotionStatus struct {
+	// ServerRef references the Server created for the node.
+	// +optional
+	ServerRef *corev1.ObjectReference `json:"serverRef,omitempty"`
+
+	// MachineRef references the CAPI Machine created for the node.
+	// +optional
+	MachineRef *corev1.ObjectReference `json:"machineRef,omitempty"`
+}
+
+// Phases of a Talos upgrade.
+const (
+	UpgradePhaseInProgress = "InProgress"
+	UpgradePhaseCompleted  = "Completed"
+	UpgradePhaseFailed     = "Failed"
+)
+
+// UpgradeStatus describes a Talos upgrade of the node.
+type UpgradeStatus struct {
+	// Phase of the upgrade: "InProgress", "Completed" or "Failed".
+	// +optional
+	Phase string `json:"phase,omitempty"`
+
+	// TargetVersion is the Talos version the node is upgraded to.
+	// +optional
+	TargetVersion string `json:"targetVersion,omitempty"`
+
+	// Image is the installer image used for the upgrade.
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// StartTime is when the upgrade was started.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is when the upgraded node was found healthy.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Error describes why the upgrade failed.
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+// ConfigPatchStatus describes the machine configuration patches applied to the node.
+type ConfigPatchStatus struct {
+	// AppliedHash is the hash of the patches last applied to the node.
+	// +optional
+	AppliedHash string `json:"appliedHash,omitempty"`
+
+	// AppliedMode is the apply mode used for the last applied patches.
+	// +optional
+	AppliedMode string `json:"appliedMode,omitempty"`
+
+	// LastAppliedTime is when the patches were last applied.
+	// +optional
+	LastAppliedTime *metav1.Time `json:"lastAppliedTime,omitempty"`
+
+	// DryRunHash is the hash of the patches last validated in dry-run mode.
+	// +optional
+	DryRunHash string `json:"dryRunHash,omitempty"`
+
+	// Details describes the changes reported by Talos for the last apply or dry run.
+	// +optional
+	Details string `json:"details,omitempty"`
 }
 
 // SideroLinkStatus contains the status of the SideroLink connection.
@@ -207,6 +494,10 @@ type SideroLinkStatus struct {
 
 // NodeInfo contains additional information about the node.
 type NodeInfo struct {
+	// Hostname is the fully qualified hostname reported by the node.
+	// +optional
+	Hostname string `json:"hostname,omitempty"`
+
 	// Architecture of the node (e.g., amd64, arm64).
 	// +optional
 	Architecture string `json:"architecture,omitempty"`
@@ -219,10 +510,22 @@ type NodeInfo struct {
 	// +optional
 	MachineID string `json:"machineID,omitempty"`
 
+	// UUID is the SMBIOS system UUID of the node.
+	// +optional
+	UUID string `json:"uuid,omitempty"`
+
 	// KernelVersion is the kernel version running on the node.
 	// +optional
 	KernelVersion string `json:"kernelVersion,omitempty"`
 
+	// TalosVersion is the Talos version running on the node.
+	// +optional
+	TalosVersion string `json:"talosVersion,omitempty"`
+
+	// KubernetesVersion is the kubelet version running on the node.
+	// +optional
+	KubernetesVersion string `json:"kubernetesVersion,omitempty"`
+
 	// ClusterName is the Kubernetes cluster name from the node's perspective.
 	// +optional
 	ClusterName string `json:"clusterName,omitempty"`
@@ -235,7 +538,10 @@ type NodeInfo struct {
 // +kubebuilder:printcolumn:name="Type",type="string",JSONPath=".spec.talos.nodeType",description="Node type (controlplane/worker)"
 // +kubebuilder:printcolumn:name="Accepted",type="boolean",JSONPath=".spec.accepted",description="Indicates if accepted for management"
 // +kubebuilder:printcolumn:name="Connected",type="boolean",JSONPath=".status.connected",description="Indicates if Talos API is reachable"
+// +kubebuilder:printcolumn:name="Hostname",type="string",JSONPath=".status.nodeInfo.hostname",description="Hostname reported by the node",priority=1
+// +kubebuilder:printcolumn:name="Talos",type="string",JSONPath=".status.nodeInfo.talosVersion",description="Talos version reported by the node",priority=1
 // +kubebuilder:printcolumn:name="Health",type="string",JSONPath=".status.health.status",description="Health status"
+// +kubebuilder:printcolumn:name="Server",type="string",JSONPath=".status.promotion.serverRef.name",description="Server created by promotion",priority=1
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time since creation"
 // +kubebuilder:storageversion
 
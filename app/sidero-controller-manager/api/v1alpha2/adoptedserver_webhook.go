@@ -0,0 +1,308 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha2
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// DefaultTalosAPIPort is the default port of the Talos API.
+const DefaultTalosAPIPort = 50000
+
+// AllowEndpointChangeAnnotation allows changing the Talos API endpoint of an accepted and connected AdoptedServer.
+const AllowEndpointChangeAnnotation = "metal.sidero.dev/allow-endpoint-change"
+
+// NormalizeTalosEndpoint validates the Talos API endpoint (host[:port]), and adds the default port if it's missing.
+func NormalizeTalosEndpoint(endpoint string) (string, error) {
+	if addr, err := netip.ParseAddr(endpoint); err == nil {
+		return net.JoinHostPort(addr.String(), strconv.Itoa(DefaultTalosAPIPort)), nil
+	}
+
+	host, port, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		var addrErr *net.AddrError
+
+		if !errors.As(err, &addrErr) || addrErr.Err != "missing port in address" {
+			return "", fmt.Errorf("invalid endpoint %q: %w", endpoint, err)
+		}
+
+		host, port = endpoint, strconv.Itoa(DefaultTalosAPIPort)
+	}
+
+	if host == "" {
+		return "", fmt.Errorf("invalid endpoint %q: host is empty", endpoint)
+	}
+
+	if p, err := strconv.ParseUint(port, 10, 16); err != nil || p == 0 {
+		return "", fmt.Errorf("invalid endpoint %q: invalid port %q", endpoint, port)
+	}
+
+	return net.JoinHostPort(host, port), nil
+}
+
+func (as *AdoptedServer) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	hook := &AdoptedServerWebhook{
+		Reader: mgr.GetAPIReader(),
+	}
+
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(as).
+		WithDefaulter(hook).
+		WithValidator(hook).
+		Complete()
+}
+
+//+kubebuilder:webhook:verbs=create;update,path=/mutate-metal-sidero-dev-v1alpha2-adoptedserver,mutating=true,failurePolicy=fail,groups=metal.sidero.dev,resources=adoptedservers,versions=v1alpha2,name=madoptedservers.metal.sidero.dev,sideEffects=None,admissionReviewVersions=v1
+//+kubebuilder:webhook:verbs=create;update,path=/validate-metal-sidero-dev-v1alpha2-adoptedserver,mutating=false,failurePolicy=fail,groups=metal.sidero.dev,resources=adoptedservers,versions=v1alpha2,name=vadoptedservers.metal.sidero.dev,sideEffects=None,admissionReviewVersions=v1
+
+// AdoptedServerWebhook defaults and validates AdoptedServers.
+//
+// Validation needs other AdoptedServers to reject duplicate endpoints, so it's implemented
+// as a custom validator with access to the API server.
+//
+// +kubebuilder:object:generate=false
+type AdoptedServerWebhook struct {
+	Reader client.Reader
+}
+
+var (
+	_ admission.CustomDefaulter = &AdoptedServerWebhook{}
+	_ admission.CustomValidator = &AdoptedServerWebhook{}
+)
+
+// Default implements admission.CustomDefaulter.
+//
+// The default Talos API port is added to the endpoint, so that endpoints can be compared.
+func (w *AdoptedServerWebhook) Default(_ context.Context, obj runtime.Object) error {
+	as, ok := obj.(*AdoptedServer)
+	if !ok {
+		return fmt.Errorf("expected an AdoptedServer, got %T", obj)
+	}
+
+	if endpoint, err := NormalizeTalosEndpoint(as.Spec.Talos.Endpoint); err == nil {
+		as.Spec.Talos.Endpoint = endpoint
+	}
+
+	return nil
+}
+
+// ValidateCreate implements admission.CustomValidator.
+func (w *AdoptedServerWebhook) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	as, ok := obj.(*AdoptedServer)
+	if !ok {
+		return nil, fmt.Errorf("expected an AdoptedServer, got %T", obj)
+	}
+
+	return nil, w.validate(ctx, nil, as)
+}
+
+// ValidateUpdate implements admission.CustomValidator.
+func (w *AdoptedServerWebhook) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	old, ok := oldObj.(*AdoptedServer)
+	if !ok {
+		return nil, fmt.Errorf("expected an AdoptedServer, got %T", oldObj)
+	}
+
+	as, ok := newObj.(*AdoptedServer)
+	if !ok {
+		return nil, fmt.Errorf("expected an AdoptedServer, got %T", newObj)
+	}
+
+	return nil, w.validate(ctx, old, as)
+}
+
+// ValidateDelete implements admission.CustomValidator.
+func (w *AdoptedServerWebhook) ValidateDelete(context.Context, runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (w *AdoptedServerWebhook) validate(ctx context.Context, old, as *AdoptedServer) error {
+	var allErrs field.ErrorList
+
+	allErrs = append(allErrs, as.validateTalos(old)...)
+	allErrs = append(allErrs, as.validateManagementAPI()...)
+	allErrs = append(allErrs, as.validateSideroLink()...)
+	allErrs = append(allErrs, as.validateConfigPatches()...)
+
+	if len(allErrs) == 0 {
+		// the endpoint is valid at this point
+		endpoint, _ := NormalizeTalosEndpoint(as.Spec.Talos.Endpoint) //nolint:errcheck
+
+		duplicates, err := w.validateUniqueEndpoint(ctx, as.Name, endpoint)
+		if err != nil {
+			return apierrors.NewInternalError(err)
+		}
+
+		allErrs = append(allErrs, duplicates...)
+	}
+
+	if len(allErrs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(
+		schema.GroupKind{Group: GroupVersion.Group, Kind: "AdoptedServer"},
+		as.Name, allErrs)
+}
+
+func (as *AdoptedServer) validateTalos(old *AdoptedServer) (allErrs field.ErrorList) {
+	path := field.NewPath("spec").Child("talos")
+
+	endpoint, err := NormalizeTalosEndpoint(as.Spec.Talos.Endpoint)
+	if err != nil {
+		allErrs = append(allErrs, field.Invalid(path.Child("endpoint"), as.Spec.Talos.Endpoint, err.Error()))
+	}
+
+	switch as.Spec.Talos.NodeType {
+	case "", "controlplane", "worker":
+	default:
+		allErrs = append(allErrs, field.NotSupported(path.Child("nodeType"), as.Spec.Talos.NodeType, []string{"controlplane", "worker"}))
+	}
+
+	allErrs = append(allErrs, validateSecretReference(path.Child("secretRef"), as.Spec.Talos.SecretRef)...)
+
+	// changing the endpoint of a managed node would silently point Sidero to another node
+	if old != nil && err == nil && old.Spec.Accepted && old.Status.Connected && as.Annotations[AllowEndpointChangeAnnotation] != "true" {
+		if oldEndpoint, oldErr := NormalizeTalosEndpoint(old.Spec.Talos.Endpoint); oldErr == nil && oldEndpoint != endpoint {
+			allErrs = append(allErrs, field.Forbidden(path.Child("endpoint"),
+				fmt.Sprintf("endpoint of an accepted and connected server can't be changed, set the %q annotation to \"true\" to override", AllowEndpointChangeAnnotation),
+			))
+		}
+	}
+
+	return allErrs
+}
+
+func (as *AdoptedServer) validateManagementAPI() (allErrs field.ErrorList) {
+	if as.Spec.ManagementAPI == nil {
+		return nil
+	}
+
+	path := field.NewPath("spec").Child("managementAPI")
+
+	switch endpoint := as.Spec.ManagementAPI.Endpoint; {
+	case endpoint == "" && as.Spec.ManagementAPI.Enabled:
+		allErrs = append(allErrs, field.Required(path.Child("endpoint"), "endpoint is required when the Management API is enabled"))
+	case endpoint != "":
+		u, err := url.Parse(endpoint)
+
+		switch {
+		case err != nil:
+			allErrs = append(allErrs, field.Invalid(path.Child("endpoint"), endpoint, err.Error()))
+		case u.Scheme != "http" && u.Scheme != "https":
+			allErrs = append(allErrs, field.Invalid(path.Child("endpoint"), endpoint, "scheme should be either http or https"))
+		case u.Host == "":
+			allErrs = append(allErrs, field.Invalid(path.Child("endpoint"), endpoint, "host is empty"))
+		}
+	}
+
+	allErrs = append(allErrs, validateSecretReference(path.Child("secretRef"), as.Spec.ManagementAPI.SecretRef)...)
+
+	return allErrs
+}
+
+func (as *AdoptedServer) validateSideroLink() (allErrs field.ErrorList) {
+	if as.Spec.SideroLink == nil {
+		return nil
+	}
+
+	path := field.NewPath("spec").Child("sideroLink")
+
+	if address := as.Spec.SideroLink.Address; address != "" {
+		prefix, err := netip.ParsePrefix(address)
+
+		switch {
+		case err != nil:
+			allErrs = append(allErrs, field.Invalid(path.Child("address"), address, err.Error()))
+		case !prefix.Addr().Is6():
+			allErrs = append(allErrs, field.Invalid(path.Child("address"), address, "address should be an IPv6 prefix"))
+		}
+	}
+
+	if publicKey := as.Spec.SideroLink.PublicKey; publicKey != "" {
+		// Wireguard keys are base64 encoded 32 bytes
+		if key, err := base64.StdEncoding.DecodeString(publicKey); err != nil || len(key) != 32 {
+			allErrs = append(allErrs, field.Invalid(path.Child("publicKey"), publicKey, "public key should be a base64 encoded Wireguard key"))
+		}
+	}
+
+	return allErrs
+}
+
+func (as *AdoptedServer) validateConfigPatches() (allErrs field.ErrorList) {
+	for index, patch := range as.Spec.ConfigPatches {
+		if _, ok := operations[patch.Op]; !ok {
+			allErrs = append(allErrs,
+				field.Invalid(field.NewPath("spec").Child("configPatches").Index(index).Child("op"), patch.Op,
+					fmt.Sprintf("valid values are: %q", operationKinds),
+				),
+			)
+		}
+	}
+
+	return allErrs
+}
+
+// validateUniqueEndpoint rejects endpoints already used by other AdoptedServers.
+func (w *AdoptedServerWebhook) validateUniqueEndpoint(ctx context.Context, name, endpoint string) (field.ErrorList, error) {
+	var adoptedServers AdoptedServerList
+
+	if err := w.Reader.List(ctx, &adoptedServers); err != nil {
+		return nil, fmt.Errorf("failed to list adopted servers: %w", err)
+	}
+
+	for _, other := range adoptedServers.Items {
+		if other.Name == name {
+			continue
+		}
+
+		if otherEndpoint, err := NormalizeTalosEndpoint(other.Spec.Talos.Endpoint); err == nil && otherEndpoint == endpoint {
+			return field.ErrorList{
+				field.Duplicate(field.NewPath("spec").Child("talos").Child("endpoint"), fmt.Sprintf("%s is used by AdoptedServer %q", endpoint, other.Name)),
+			}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func validateSecretReference(path *field.Path, ref *corev1.SecretReference) (allErrs field.ErrorList) {
+	if ref == nil {
+		return nil
+	}
+
+	if ref.Name == "" {
+		allErrs = append(allErrs, field.Required(path.Child("name"), "secret name is required"))
+	} else {
+		for _, msg := range validation.IsDNS1123Subdomain(ref.Name) {
+			allErrs = append(allErrs, field.Invalid(path.Child("name"), ref.Name, msg))
+		}
+	}
+
+	if ref.Namespace != "" {
+		for _, msg := range validation.IsDNS1123Label(ref.Namespace) {
+			allErrs = append(allErrs, field.Invalid(path.Child("namespace"), ref.Namespace, msg))
+		}
+	}
+
+	return allErrs
+}
@@ -0,0 +1,228 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha2_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	metal "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
+)
+
+func TestNormalizeTalosEndpoint(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		endpoint string
+		expected string
+		err      bool
+	}{
+		{endpoint: "10.5.0.2", expected: "10.5.0.2:50000"},
+		{endpoint: "10.5.0.2:50001", expected: "10.5.0.2:50001"},
+		{endpoint: "node-1.example.org", expected: "node-1.example.org:50000"},
+		{endpoint: "fd00::2", expected: "[fd00::2]:50000"},
+		{endpoint: "[fd00::2]:50001", expected: "[fd00::2]:50001"},
+		{endpoint: "10.5.0.2:0", err: true},
+		{endpoint: "10.5.0.2:70000", err: true},
+		{endpoint: ":50000", err: true},
+		{endpoint: "", err: true},
+	} {
+		t.Run(tt.endpoint, func(t *testing.T) {
+			t.Parallel()
+
+			endpoint, err := metal.NormalizeTalosEndpoint(tt.endpoint)
+			if tt.err {
+				require.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, endpoint)
+		})
+	}
+}
+
+func adoptedServer(name, endpoint string) *metal.AdoptedServer {
+	return &metal.AdoptedServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: metal.AdoptedServerSpec{
+			Talos: metal.TalosConfig{
+				Endpoint: endpoint,
+				NodeType: "controlplane",
+				SecretRef: &corev1.SecretReference{
+					Name:      "talosconfig",
+					Namespace: "default",
+				},
+			},
+		},
+	}
+}
+
+func TestAdoptedServerWebhookDefault(t *testing.T) {
+	t.Parallel()
+
+	as := adoptedServer("node-1", "10.5.0.2")
+
+	require.NoError(t, (&metal.AdoptedServerWebhook{}).Default(context.Background(), as))
+	assert.Equal(t, "10.5.0.2:50000", as.Spec.Talos.Endpoint)
+}
+
+func TestAdoptedServerWebhookValidateCreate(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, metal.AddToScheme(scheme))
+
+	hook := &metal.AdoptedServerWebhook{
+		Reader: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(adoptedServer("existing", "10.5.0.3:50000")).
+			Build(),
+	}
+
+	for _, tt := range []struct {
+		name   string
+		modify func(*metal.AdoptedServer)
+		errs   []string
+	}{
+		{
+			name: "valid",
+			modify: func(as *metal.AdoptedServer) {
+				as.Spec.ManagementAPI = &metal.ManagementAPIConfig{Enabled: true, Endpoint: "https://management.example.org:8090"}
+				as.Spec.SideroLink = &metal.SideroLinkConfig{
+					Enabled:   true,
+					Address:   "fdae:41e4:649b:9303:b6db:d99c:215e:dfc4/64",
+					PublicKey: "Tqmzr9zEeJ3ZPPVoGjXEUGp2HFDoOpmJ7eR4nQbJb3c=",
+				}
+			},
+		},
+		{
+			name:   "invalid endpoint",
+			modify: func(as *metal.AdoptedServer) { as.Spec.Talos.Endpoint = "10.5.0.2:abc" },
+			errs:   []string{"spec.talos.endpoint"},
+		},
+		{
+			name:   "duplicate endpoint",
+			modify: func(as *metal.AdoptedServer) { as.Spec.Talos.Endpoint = "10.5.0.3" },
+			errs:   []string{"spec.talos.endpoint"},
+		},
+		{
+			name:   "invalid node type",
+			modify: func(as *metal.AdoptedServer) { as.Spec.Talos.NodeType = "init" },
+			errs:   []string{"spec.talos.nodeType"},
+		},
+		{
+			name:   "invalid secret reference",
+			modify: func(as *metal.AdoptedServer) { as.Spec.Talos.SecretRef = &corev1.SecretReference{Namespace: "Default"} },
+			errs:   []string{"spec.talos.secretRef.name", "spec.talos.secretRef.namespace"},
+		},
+		{
+			name: "invalid management API",
+			modify: func(as *metal.AdoptedServer) {
+				as.Spec.ManagementAPI = &metal.ManagementAPIConfig{Enabled: true, Endpoint: "grpc://management:8090"}
+			},
+			errs: []string{"spec.managementAPI.endpoint"},
+		},
+		{
+			name: "missing management API endpoint",
+			modify: func(as *metal.AdoptedServer) {
+				as.Spec.ManagementAPI = &metal.ManagementAPIConfig{Enabled: true}
+			},
+			errs: []string{"spec.managementAPI.endpoint"},
+		},
+		{
+			name: "invalid SideroLink",
+			modify: func(as *metal.AdoptedServer) {
+				as.Spec.SideroLink = &metal.SideroLinkConfig{Enabled: true, Address: "10.0.0.1/24", PublicKey: "not-a-key"}
+			},
+			errs: []string{"spec.sideroLink.address", "spec.sideroLink.publicKey"},
+		},
+		{
+			name: "invalid config patch",
+			modify: func(as *metal.AdoptedServer) {
+				as.Spec.ConfigPatches = []metal.ConfigPatches{{Op: "merge", Path: "/machine"}}
+			},
+			errs: []string{"spec.configPatches[0].op"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			as := adoptedServer("node-1", "10.5.0.2:50000")
+			tt.modify(as)
+
+			_, err := hook.ValidateCreate(context.Background(), as)
+			assertFieldErrors(t, err, tt.errs)
+		})
+	}
+}
+
+func TestAdoptedServerWebhookValidateUpdate(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, metal.AddToScheme(scheme))
+
+	hook := &metal.AdoptedServerWebhook{
+		Reader: fake.NewClientBuilder().WithScheme(scheme).Build(),
+	}
+
+	old := adoptedServer("node-1", "10.5.0.2:50000")
+	old.Spec.Accepted = true
+	old.Status.Connected = true
+
+	updated := old.DeepCopy()
+	updated.Spec.Talos.Endpoint = "10.5.0.4:50000"
+
+	_, err := hook.ValidateUpdate(context.Background(), old, updated)
+	assertFieldErrors(t, err, []string{"spec.talos.endpoint"})
+
+	updated.Annotations = map[string]string{metal.AllowEndpointChangeAnnotation: "true"}
+
+	_, err = hook.ValidateUpdate(context.Background(), old, updated)
+	require.NoError(t, err)
+
+	// the endpoint can be changed while the server is not connected
+	old.Status.Connected = false
+	updated.Annotations = nil
+
+	_, err = hook.ValidateUpdate(context.Background(), old, updated)
+	require.NoError(t, err)
+}
+
+func assertFieldErrors(t *testing.T, err error, fields []string) {
+	t.Helper()
+
+	if len(fields) == 0 {
+		require.NoError(t, err)
+
+		return
+	}
+
+	require.Error(t, err)
+
+	var statusErr *apierrors.StatusError
+
+	require.ErrorAs(t, err, &statusErr)
+
+	actual := make([]string, 0, len(statusErr.ErrStatus.Details.Causes))
+
+	for _, cause := range statusErr.ErrStatus.Details.Causes {
+		actual = append(actual, cause.Field)
+	}
+
+	assert.Equal(t, fields, actual)
+}
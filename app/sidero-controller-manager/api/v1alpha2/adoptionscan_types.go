@@ -0,0 +1,160 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha2
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+// AdoptionScanSpec defines the desired state of AdoptionScan.
+//
+// Either CIDRs or SeedEndpoint should be set.
+type AdoptionScanSpec struct {
+	// TalosSecretRef references a secret containing Talos API client credentials used to probe the nodes.
+	// The secret should contain either a `talosconfig` key, or `ca.crt`, `tls.crt` and `tls.key` keys.
+	// The reference is copied to the discovered AdoptedServers.
+	// +kubebuilder:validation:Required
+	TalosSecretRef corev1.SecretReference `json:"talosSecretRef"`
+
+	// CIDRs lists the networks to scan for Talos nodes, e.g. 192.168.1.0/24.
+	// +optional
+	CIDRs []string `json:"cidrs,omitempty"`
+
+	// SeedEndpoint is the Talos API endpoint of a cluster member (host[:port]).
+	// Cluster members known to the seed node are discovered.
+	// +optional
+	SeedEndpoint string `json:"seedEndpoint,omitempty"`
+
+	// Port is the Talos API port probed on the discovered addresses.
+	// Defaults to 50000.
+	// +optional
+	Port int32 `json:"port,omitempty"`
+
+	// Interval is the interval between scans.
+	// Defaults to 1h.
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+
+	// Labels are set as custom labels on the created AdoptedServers.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+const (
+	// ConditionScanSucceeded indicates whether the last scan was completed.
+	ConditionScanSucceeded clusterv1.ConditionType = "ScanSucceeded"
+)
+
+// Condition reasons for the ScanSucceeded condition.
+const (
+	// ScanInvalidSpecReason (Severity=Error) documents that the scan spec is invalid.
+	ScanInvalidSpecReason = "InvalidSpec"
+	// ScanFailedReason (Severity=Warning) documents that the scan failed.
+	ScanFailedReason = "ScanFailed"
+)
+
+// AdoptionScanLabel is set on the AdoptedServers created by the scan, and holds the name of the scan.
+const AdoptionScanLabel = "metal.sidero.dev/adoption-scan"
+
+// AdoptionScanStatus defines the observed state of AdoptionScan.
+type AdoptionScanStatus struct {
+	// LastScanTime is when the last scan was completed.
+	// +optional
+	LastScanTime *metav1.Time `json:"lastScanTime,omitempty"`
+
+	// ObservedGeneration is the generation of the spec used by the last scan.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Nodes lists the Talos nodes found by the last scan.
+	// +optional
+	Nodes []DiscoveredNode `json:"nodes,omitempty"`
+
+	// Unreachable lists the endpoints which didn't respond to the Talos API during the last scan.
+	// +optional
+	Unreachable []UnreachableEndpoint `json:"unreachable,omitempty"`
+
+	// Conditions defines current service state of the AdoptionScan.
+	// +optional
+	Conditions []clusterv1.Condition `json:"conditions,omitempty"`
+}
+
+// DiscoveredNode describes a Talos node found by the scan.
+type DiscoveredNode struct {
+	// Endpoint is the Talos API endpoint the node responded on.
+	Endpoint string `json:"endpoint"`
+
+	// MachineID is the unique machine identifier.
+	MachineID string `json:"machineID"`
+
+	// Hostname is the fully qualified hostname reported by the node.
+	// +optional
+	Hostname string `json:"hostname,omitempty"`
+
+	// NodeType is the machine type: controlplane or worker.
+	// +optional
+	NodeType string `json:"nodeType,omitempty"`
+
+	// TalosVersion is the Talos version running on the node.
+	// +optional
+	TalosVersion string `json:"talosVersion,omitempty"`
+
+	// AdoptedServer is the name of the AdoptedServer representing the node.
+	// +optional
+	AdoptedServer string `json:"adoptedServer,omitempty"`
+}
+
+// UnreachableEndpoint describes an endpoint which didn't respond to the Talos API.
+type UnreachableEndpoint struct {
+	// Endpoint is the Talos API endpoint.
+	Endpoint string `json:"endpoint"`
+
+	// Error is the error returned by the probe.
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Seed",type="string",JSONPath=".spec.seedEndpoint",description="Seed endpoint"
+// +kubebuilder:printcolumn:name="Last Scan",type="date",JSONPath=".status.lastScanTime",description="Time since the last scan"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time since creation"
+// +kubebuilder:storageversion
+
+// AdoptionScan is the Schema for the adoptionscans API.
+// AdoptionScan discovers existing Talos nodes and creates AdoptedServers for them.
+type AdoptionScan struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   AdoptionScanSpec   `json:"spec,omitempty"`
+	Status AdoptionScanStatus `json:"status,omitempty"`
+}
+
+// GetConditions returns the conditions from the status.
+func (scan *AdoptionScan) GetConditions() clusterv1.Conditions {
+	return scan.Status.Conditions
+}
+
+// SetConditions sets the conditions in the status.
+func (scan *AdoptionScan) SetConditions(conditions clusterv1.Conditions) {
+	scan.Status.Conditions = conditions
+}
+
+// +kubebuilder:object:root=true
+
+// AdoptionScanList contains a list of AdoptionScan.
+type AdoptionScanList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AdoptionScan `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&AdoptionScan{}, &AdoptionScanList{})
+}
@@ -75,12 +75,29 @@ type RegistryMirror struct {
 	// OverridePath replaces the image path when pulling from mirror.
 	// +optional
 	OverridePath bool `json:"overridePath,omitempty"`
+
+	// Source for the username of the mirror endpoints, used with PasswordFrom.
+	// +optional
+	UsernameFrom *CredentialSource `json:"usernameFrom,omitempty"`
+
+	// Source for the password of the mirror endpoints, used with UsernameFrom.
+	// +optional
+	PasswordFrom *CredentialSource `json:"passwordFrom,omitempty"`
+
+	// Source for the identity token of the mirror endpoints. Cannot be used with UsernameFrom and PasswordFrom.
+	// +optional
+	TokenFrom *CredentialSource `json:"tokenFrom,omitempty"`
+
+	// Source for the PEM-encoded CA bundle of the mirror endpoints.
+	// +optional
+	CAFrom *CredentialSource `json:"caFrom,omitempty"`
 }
 
 // LocalImageFactory defines configuration for a local/on-premise Image Factory instance.
 // Used in air-gapped environments where factory.talos.dev is not accessible.
 type LocalImageFactory struct {
 	// Endpoint is the local Image Factory API endpoint.
+	// The boot assets derived from the Environment schematic are downloaded from it.
 	// Example: https://factory.local
 	// +required
 	Endpoint string `json:"endpoint"`
@@ -104,7 +121,9 @@ type AirGapConfig struct {
 	Enabled bool `json:"enabled,omitempty"`
 
 	// AssetMirror is a local HTTP(S) server hosting Talos release assets.
-	// Replaces downloads from github.com/siderolabs/talos/releases.
+	// Replaces downloads from github.com/siderolabs/talos/releases (<mirror>/<version>/<file>)
+	// and factory.talos.dev (<mirror>/image/<schematic>/<version>/<file>).
+	// Defaults to the controller --asset-mirror flag.
 	// Example: https://artifacts.local/talos
 	// +optional
 	AssetMirror string `json:"assetMirror,omitempty"`
@@ -116,19 +135,63 @@ type AirGapConfig struct {
 	RegistryMirrors map[string]RegistryMirror `json:"registryMirrors,omitempty"`
 
 	// ImageCacheURL points to a pre-built OCI image cache file.
-	// Talos 1.9+ supports embedding container images in boot assets.
+	// The file is mirrored and served with the other assets (/env/<name>/image-cache.oci) to build boot media and
+	// installer images with the image cache (Talos 1.9+), it isn't used by the PXE-booted nodes.
 	// Example: https://artifacts.local/talos/v1.11.5/image-cache.oci
 	// +optional
 	ImageCacheURL string `json:"imageCacheURL,omitempty"`
 
+	// ImageCacheSHA512 is the SHA512 checksum of the image cache.
+	// +optional
+	ImageCacheSHA512 string `json:"imageCacheSHA512,omitempty"`
+
 	// LocalImageFactory configures a local Image Factory instance.
 	// Used for generating custom boot assets with extensions in air-gap.
 	// +optional
 	LocalImageFactory *LocalImageFactory `json:"localImageFactory,omitempty"`
 }
 
+// MetaValue is a Talos META partition value.
+type MetaValue struct {
+	// Key is the META key, e.g. 0xa for the network configuration.
+	Key uint8 `json:"key"`
+
+	// Value is the META value.
+	Value string `json:"value"`
+}
+
+// Schematic is an Image Factory schematic the Environment boot assets are generated from.
+type Schematic struct {
+	// TalosVersion of the boot assets, defaults to the Talos release of the controller.
+	// +optional
+	TalosVersion string `json:"talosVersion,omitempty"`
+
+	// Arch of the boot assets, defaults to amd64.
+	// +optional
+	Arch string `json:"arch,omitempty"`
+
+	// Extensions are the official system extensions baked into the boot assets.
+	// Example: ["siderolabs/iscsi-tools"]
+	// +optional
+	Extensions []string `json:"extensions,omitempty"`
+
+	// KernelArgs are the extra kernel arguments baked into the UKI, they are passed to the kernel/initrd boot as well.
+	// +optional
+	KernelArgs []string `json:"kernelArgs,omitempty"`
+
+	// Meta are the META partition values baked into the boot assets.
+	// +optional
+	Meta []MetaValue `json:"meta,omitempty"`
+}
+
 // EnvironmentSpec defines the desired state of Environment.
 type EnvironmentSpec struct {
+	// Schematic is created on the Image Factory (AirGap.LocalImageFactory, or factory.talos.dev unless air-gapped),
+	// the boot asset, kernel and initrd URLs are derived from it and recorded in the status.
+	// The derived URLs replace the BootAsset, Kernel and Initrd URLs, their SHA512 checksums still apply.
+	// +optional
+	Schematic *Schematic `json:"schematic,omitempty"`
+
 	// BootAsset is the preferred method for Talos 1.10+ with systemd-boot.
 	// When specified, Kernel and Initrd fields are ignored.
 	// +optional
@@ -150,15 +213,97 @@ type EnvironmentSpec struct {
 	AirGap *AirGapConfig `json:"airGap,omitempty"`
 }
 
+// AssetCondition reasons.
+const (
+	// AssetDownloadFailedReason is used when the asset can't be downloaded.
+	AssetDownloadFailedReason = "DownloadFailed"
+	// AssetChecksumMismatchReason is used when the downloaded asset doesn't match its SHA512 checksum.
+	AssetChecksumMismatchReason = "ChecksumMismatch"
+	// AssetExtractionFailedReason is used when the UKI can't be extracted from the boot asset.
+	AssetExtractionFailedReason = "ExtractionFailed"
+	// AssetHostNotAllowedReason is used when an air-gapped asset is not on the asset mirror or an allowed host.
+	AssetHostNotAllowedReason = "HostNotAllowed"
+)
+
+// Boot paths.
+const (
+	// BootPathUKI boots UEFI clients from the UKI extracted from the BootAsset, other clients use Kernel and Initrd.
+	BootPathUKI = "UKI"
+	// BootPathKernelInitrd boots all clients from Kernel and Initrd.
+	BootPathKernelInitrd = "KernelInitrd"
+)
+
 type AssetCondition struct {
 	Asset  `json:",inline"`
 	Status string `json:"status"`
 	Type   string `json:"type"`
+
+	// Reason is set when the asset is not ready.
+	// +optional
+	// +k8s:conversion-gen=false
+	Reason string `json:"reason,omitempty"`
+
+	// Message describes the failure when the asset is not ready.
+	// +optional
+	// +k8s:conversion-gen=false
+	Message string `json:"message,omitempty"`
+
+	// MirrorURL is the asset mirror URL the asset was downloaded from in air-gapped environments.
+	// +optional
+	// +k8s:conversion-gen=false
+	MirrorURL string `json:"mirrorURL,omitempty"`
+}
+
+// SchematicStatus is the Image Factory schematic of the Environment.
+type SchematicStatus struct {
+	// ID is the schematic ID returned by the Image Factory.
+	ID string `json:"id"`
+
+	// Factory is the Image Factory endpoint the schematic was created on.
+	Factory string `json:"factory"`
+
+	// TalosVersion of the boot assets.
+	TalosVersion string `json:"talosVersion"`
+
+	// UKIURL is the Unified Kernel Image of the schematic.
+	// +optional
+	UKIURL string `json:"ukiURL,omitempty"`
+
+	// InstallerImage is the Talos installer image of the schematic.
+	// +optional
+	InstallerImage string `json:"installerImage,omitempty"`
+
+	// BootAssetURL is the boot asset of the schematic.
+	// +optional
+	BootAssetURL string `json:"bootAssetURL,omitempty"`
+
+	// KernelURL is the kernel of the schematic.
+	// +optional
+	KernelURL string `json:"kernelURL,omitempty"`
+
+	// InitrdURL is the initrd of the schematic.
+	// +optional
+	InitrdURL string `json:"initrdURL,omitempty"`
+
+	// InputsHash identifies the schematic and the Image Factory it was created on, the schematic is only created
+	// again when they change.
+	// +optional
+	InputsHash string `json:"inputsHash,omitempty"`
 }
 
 // EnvironmentStatus defines the observed state of Environment.
 type EnvironmentStatus struct {
 	Conditions []AssetCondition `json:"conditions,omitempty"`
+
+	// BootPath is the boot path served to the servers, either UKI or KernelInitrd.
+	// +optional
+	// +k8s:conversion-gen=false
+	BootPath string `json:"bootPath,omitempty"`
+
+	// Schematic is the Image Factory schematic the boot assets are derived from.
+	// +optional
+	// +k8s:conversion-gen=false
+	Schematic *SchematicStatus `json:"schematic,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -167,6 +312,7 @@ type EnvironmentStatus struct {
 // +kubebuilder:printcolumn:name="BootAsset",type="string",priority=0,JSONPath=".spec.bootAsset.url",description="the boot asset URL (Talos 1.10+)"
 // +kubebuilder:printcolumn:name="Kernel",type="string",priority=1,JSONPath=".spec.kernel.url",description="the kernel for the environment (legacy)"
 // +kubebuilder:printcolumn:name="Initrd",type="string",priority=1,JSONPath=".spec.initrd.url",description="the initrd for the environment (legacy)"
+// +kubebuilder:printcolumn:name="BootPath",type="string",priority=1,JSONPath=".status.bootPath",description="the boot path served to the servers"
 // +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description="indicates the readiness of the environment"
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="The age of this resource"
 // +kubebuilder:storageversion
@@ -231,31 +377,70 @@ func EnvironmentDefaultSpec(talosRelease, apiEndpoint string, apiPort uint16) *E
 
 // IsReady returns aggregated Environment readiness.
 // Checks both BootAsset (preferred for Talos 1.10+) and legacy Kernel/Initrd.
+//
+// An asset is ready once it was downloaded from its URL and verified against its SHA512 checksum.
 func (env *Environment) IsReady() bool {
-	assetURLs := map[string]struct{}{}
+	// the boot assets aren't known until the schematic is resolved
+	if env.Spec.Schematic != nil && env.Status.Schematic == nil {
+		return false
+	}
+
+	assets := map[Asset]struct{}{}
+
+	bootAsset, kernel, initrd := env.BootAssets()
 
 	// Check BootAsset (preferred for Talos 1.10+)
-	if env.Spec.BootAsset != nil && env.Spec.BootAsset.URL != "" {
-		assetURLs[env.Spec.BootAsset.URL] = struct{}{}
+	if bootAsset.URL != "" {
+		assets[bootAsset] = struct{}{}
 	}
 
 	// Check legacy Kernel/Initrd (fallback or explicit preference)
-	if env.Spec.Kernel.URL != "" {
-		assetURLs[env.Spec.Kernel.URL] = struct{}{}
+	if kernel.URL != "" {
+		assets[kernel] = struct{}{}
 	}
 
-	if env.Spec.Initrd.URL != "" {
-		assetURLs[env.Spec.Initrd.URL] = struct{}{}
+	if initrd.URL != "" {
+		assets[initrd] = struct{}{}
 	}
 
 	// Mark assets as ready based on conditions
 	for _, cond := range env.Status.Conditions {
 		if cond.Status == "True" && cond.Type == "Ready" {
-			delete(assetURLs, cond.URL)
+			delete(assets, cond.Asset)
 		}
 	}
 
-	return len(assetURLs) == 0
+	return len(assets) == 0
+}
+
+// BootAssets returns the boot asset, kernel and initrd of the Environment, the URL is empty if an asset isn't set.
+//
+// The URLs derived from the schematic replace the spec ones, the SHA512 checksums are always taken from the spec.
+func (env *Environment) BootAssets() (bootAsset, kernel, initrd Asset) {
+	if env.Spec.BootAsset != nil {
+		bootAsset = Asset{URL: env.Spec.BootAsset.URL, SHA512: env.Spec.BootAsset.SHA512}
+	}
+
+	kernel, initrd = env.Spec.Kernel.Asset, env.Spec.Initrd.Asset
+
+	if env.Spec.Schematic != nil && env.Status.Schematic != nil {
+		bootAsset.URL = env.Status.Schematic.BootAssetURL
+		kernel.URL = env.Status.Schematic.KernelURL
+		initrd.URL = env.Status.Schematic.InitrdURL
+	}
+
+	return bootAsset, kernel, initrd
+}
+
+// ImageCacheAsset returns the image cache asset of an air-gapped Environment, if any.
+//
+// The image cache isn't required to boot the nodes, so it doesn't affect the Environment readiness.
+func (env *Environment) ImageCacheAsset() (Asset, bool) {
+	if env.Spec.AirGap == nil || !env.Spec.AirGap.Enabled || env.Spec.AirGap.ImageCacheURL == "" {
+		return Asset{}, false
+	}
+
+	return Asset{URL: env.Spec.AirGap.ImageCacheURL, SHA512: env.Spec.AirGap.ImageCacheSHA512}, true
 }
 
 func init() {
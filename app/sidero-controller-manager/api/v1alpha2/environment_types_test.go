@@ -0,0 +1,72 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha2_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	metal "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
+)
+
+func TestEnvironmentImageCacheAsset(t *testing.T) {
+	t.Parallel()
+
+	const imageCacheURL = "https://artifacts.local/talos/v1.11.5/image-cache.oci"
+
+	for _, test := range []struct {
+		name     string
+		airGap   *metal.AirGapConfig
+		expected bool
+	}{
+		{
+			name: "no air-gap",
+		},
+		{
+			name:   "air-gap disabled",
+			airGap: &metal.AirGapConfig{ImageCacheURL: imageCacheURL},
+		},
+		{
+			name:   "no image cache",
+			airGap: &metal.AirGapConfig{Enabled: true},
+		},
+		{
+			name:     "air-gap enabled",
+			airGap:   &metal.AirGapConfig{Enabled: true, ImageCacheURL: imageCacheURL, ImageCacheSHA512: "abcd"},
+			expected: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			env := &metal.Environment{
+				Spec: metal.EnvironmentSpec{
+					Kernel: metal.Kernel{Asset: metal.Asset{URL: "https://artifacts.local/talos/v1.11.5/vmlinuz-amd64"}},
+					AirGap: test.airGap,
+				},
+				Status: metal.EnvironmentStatus{
+					Conditions: []metal.AssetCondition{
+						{
+							Asset:  metal.Asset{URL: "https://artifacts.local/talos/v1.11.5/vmlinuz-amd64"},
+							Type:   "Ready",
+							Status: "True",
+						},
+					},
+				},
+			}
+
+			// the image cache isn't required for the Environment to be ready
+			assert.True(t, env.IsReady())
+
+			imageCache, ok := env.ImageCacheAsset()
+			assert.Equal(t, test.expected, ok)
+
+			if ok {
+				assert.Equal(t, metal.Asset{URL: imageCacheURL, SHA512: "abcd"}, imageCache)
+			}
+		})
+	}
+}
@@ -0,0 +1,288 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha2
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+// EtcdBackupPolicySpec defines the desired state of EtcdBackupPolicy.
+//
+// At least one of AdoptedServerSelector and ServerBindingSelector should be set.
+type EtcdBackupPolicySpec struct {
+	// Schedule is the cron schedule of the snapshots in the standard 5-field format, e.g. "0 */6 * * *".
+	// Descriptors like "@daily" are supported as well.
+	// +kubebuilder:validation:Required
+	Schedule string `json:"schedule"`
+
+	// Suspend stops taking scheduled snapshots; a restore still runs.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+
+	// AdoptedServerSelector selects the AdoptedServers to back up.
+	// Only accepted control plane nodes are used.
+	// +optional
+	AdoptedServerSelector *metav1.LabelSelector `json:"adoptedServerSelector,omitempty"`
+
+	// ServerBindingSelector selects the ServerBindings of provisioned nodes to back up.
+	// Only the control plane machines are used, and the Talos API credentials are read from
+	// the `<cluster>-talosconfig` secret created by the Talos bootstrap provider.
+	// +optional
+	ServerBindingSelector *metav1.LabelSelector `json:"serverBindingSelector,omitempty"`
+
+	// Sink defines where the snapshots are stored.
+	// +kubebuilder:validation:Required
+	Sink EtcdBackupSink `json:"sink"`
+
+	// Retention is the number of snapshots kept for each cluster.
+	// Defaults to 7.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Retention int32 `json:"retention,omitempty"`
+
+	// Restore requests restoring a snapshot to a control plane node.
+	// The restore runs once in the background, its progress is in status.restore; remove it and set it again to repeat it.
+	// +optional
+	Restore *EtcdRestoreSpec `json:"restore,omitempty"`
+}
+
+// EtcdBackupSink defines where the snapshots are stored.
+//
+// Exactly one of Local and S3 should be set.
+type EtcdBackupSink struct {
+	// Local stores the snapshots in a directory under the backup root of the controller
+	// (--etcd-backup-dir), which is usually a mounted persistent volume.
+	// +optional
+	Local *LocalBackupSink `json:"local,omitempty"`
+
+	// S3 stores the snapshots in an S3-compatible bucket.
+	// +optional
+	S3 *S3BackupSink `json:"s3,omitempty"`
+}
+
+// LocalBackupSink stores the snapshots in a local directory.
+type LocalBackupSink struct {
+	// Path is the directory relative to the backup root of the controller.
+	// +optional
+	Path string `json:"path,omitempty"`
+}
+
+// S3BackupSink stores the snapshots in an S3-compatible bucket.
+type S3BackupSink struct {
+	// Endpoint is the URL of the S3 endpoint, e.g. https://s3.us-east-1.amazonaws.com or http://minio:9000.
+	// +kubebuilder:validation:Required
+	Endpoint string `json:"endpoint"`
+
+	// Bucket is the name of the bucket.
+	// +kubebuilder:validation:Required
+	Bucket string `json:"bucket"`
+
+	// Prefix is prepended to the object keys.
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+
+	// Region is the region of the bucket.
+	// Defaults to us-east-1.
+	// +optional
+	Region string `json:"region,omitempty"`
+
+	// CredentialsSecretRef references a secret with the `access-key-id` and `secret-access-key` keys.
+	// +kubebuilder:validation:Required
+	CredentialsSecretRef corev1.SecretReference `json:"credentialsSecretRef"`
+
+	// InsecureSkipVerify disables the verification of the endpoint certificate.
+	// +optional
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+}
+
+// Secret keys holding S3 credentials.
+const (
+	S3AccessKeyIDKey     = "access-key-id"
+	S3SecretAccessKeyKey = "secret-access-key"
+)
+
+// EtcdRestoreSpec requests restoring a snapshot.
+//
+// The target node should have etcd not bootstrapped yet, e.g. after a reset.
+// The snapshot is uploaded to the node, and etcd is bootstrapped from it.
+type EtcdRestoreSpec struct {
+	// Snapshot is the name of the snapshot as listed in the status, e.g. production/production-20240101T000000Z.db.
+	// +kubebuilder:validation:Required
+	Snapshot string `json:"snapshot"`
+
+	// Target is the AdoptedServer or ServerBinding (kind and name) of the node to restore to.
+	// +kubebuilder:validation:Required
+	Target corev1.ObjectReference `json:"target"`
+
+	// SkipHashCheck skips the etcd integrity check of the snapshot, which is required for snapshots
+	// copied from the etcd data directory.
+	// +optional
+	SkipHashCheck bool `json:"skipHashCheck,omitempty"`
+}
+
+const (
+	// ConditionBackupSucceeded indicates whether the last scheduled snapshots were taken for all clusters.
+	ConditionBackupSucceeded clusterv1.ConditionType = "BackupSucceeded"
+)
+
+// Condition reasons for the BackupSucceeded condition.
+const (
+	// BackupInvalidPolicyReason (Severity=Error) documents that the policy spec is invalid.
+	BackupInvalidPolicyReason = "InvalidPolicy"
+	// BackupFailedReason (Severity=Warning) documents that the snapshots of some clusters failed.
+	BackupFailedReason = "BackupFailed"
+	// BackupNoTargetsReason (Severity=Warning) documents that the selectors matched no control plane nodes.
+	BackupNoTargetsReason = "NoTargets"
+)
+
+// Phases of an etcd restore.
+const (
+	EtcdRestoreRunning   = "Running"
+	EtcdRestoreSucceeded = "Succeeded"
+	EtcdRestoreFailed    = "Failed"
+)
+
+// EtcdBackupPolicyStatus defines the observed state of EtcdBackupPolicy.
+type EtcdBackupPolicyStatus struct {
+	// LastScheduleTime is when the snapshots were last scheduled.
+	// +optional
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+
+	// NextScheduleTime is when the snapshots are scheduled next.
+	// +optional
+	NextScheduleTime *metav1.Time `json:"nextScheduleTime,omitempty"`
+
+	// LastSuccessTime is when the snapshots of all clusters were last taken successfully.
+	// +optional
+	LastSuccessTime *metav1.Time `json:"lastSuccessTime,omitempty"`
+
+	// FailedAttempts is the number of consecutive failed attempts to take the scheduled snapshots.
+	// +optional
+	FailedAttempts int32 `json:"failedAttempts,omitempty"`
+
+	// NextRetryTime is when the failed snapshots are retried, if it's before the next schedule.
+	// +optional
+	NextRetryTime *metav1.Time `json:"nextRetryTime,omitempty"`
+
+	// Clusters lists the backup state of each selected cluster.
+	// +optional
+	Clusters []EtcdClusterBackupStatus `json:"clusters,omitempty"`
+
+	// Restore describes the last restore.
+	// +optional
+	Restore *EtcdRestoreStatus `json:"restore,omitempty"`
+
+	// Conditions defines current service state of the EtcdBackupPolicy.
+	// +optional
+	Conditions []clusterv1.Condition `json:"conditions,omitempty"`
+}
+
+// EtcdClusterBackupStatus describes the snapshots of a cluster.
+type EtcdClusterBackupStatus struct {
+	// Cluster is the name of the cluster.
+	Cluster string `json:"cluster"`
+
+	// Node is the node the last snapshot was taken from.
+	// +optional
+	Node string `json:"node,omitempty"`
+
+	// LastSuccessTime is when the last snapshot was taken successfully.
+	// +optional
+	LastSuccessTime *metav1.Time `json:"lastSuccessTime,omitempty"`
+
+	// Error describes why the last snapshot failed.
+	// +optional
+	Error string `json:"error,omitempty"`
+
+	// Snapshots lists the retained snapshots, newest first.
+	// +optional
+	Snapshots []EtcdSnapshotInfo `json:"snapshots,omitempty"`
+}
+
+// EtcdSnapshotInfo describes a stored snapshot.
+type EtcdSnapshotInfo struct {
+	// Name is the name of the snapshot in the sink.
+	Name string `json:"name"`
+
+	// Size is the size of the snapshot in bytes.
+	// +optional
+	Size int64 `json:"size,omitempty"`
+
+	// SHA256 is the checksum of the snapshot.
+	// +optional
+	SHA256 string `json:"sha256,omitempty"`
+
+	// CreationTime is when the snapshot was taken.
+	// +optional
+	CreationTime *metav1.Time `json:"creationTime,omitempty"`
+}
+
+// EtcdRestoreStatus describes a restore.
+type EtcdRestoreStatus struct {
+	// Snapshot is the name of the restored snapshot.
+	Snapshot string `json:"snapshot"`
+
+	// Target is the node the snapshot is restored to.
+	Target corev1.ObjectReference `json:"target"`
+
+	// Phase of the restore: "Running", "Succeeded" or "Failed".
+	// +optional
+	Phase string `json:"phase,omitempty"`
+
+	// StartTime is when the restore was started.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is when the restore succeeded or failed.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Error describes why the restore failed.
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Schedule",type="string",JSONPath=".spec.schedule",description="Snapshot schedule"
+// +kubebuilder:printcolumn:name="Last Success",type="date",JSONPath=".status.lastSuccessTime",description="Time since the last successful backup"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time since creation"
+// +kubebuilder:storageversion
+
+// EtcdBackupPolicy is the Schema for the etcdbackuppolicies API.
+// EtcdBackupPolicy takes scheduled etcd snapshots of the selected clusters, and restores them on request.
+type EtcdBackupPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   EtcdBackupPolicySpec   `json:"spec,omitempty"`
+	Status EtcdBackupPolicyStatus `json:"status,omitempty"`
+}
+
+// GetConditions returns the conditions from the status.
+func (policy *EtcdBackupPolicy) GetConditions() clusterv1.Conditions {
+	return policy.Status.Conditions
+}
+
+// SetConditions sets the conditions in the status.
+func (policy *EtcdBackupPolicy) SetConditions(conditions clusterv1.Conditions) {
+	policy.Status.Conditions = conditions
+}
+
+// +kubebuilder:object:root=true
+
+// EtcdBackupPolicyList contains a list of EtcdBackupPolicy.
+type EtcdBackupPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []EtcdBackupPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&EtcdBackupPolicy{}, &EtcdBackupPolicyList{})
+}
@@ -0,0 +1,197 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha2
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+// SourceOfTruth selects which side wins when a field differs between the Management API and the AdoptedServer.
+// +kubebuilder:validation:Enum=ManagementAPI;Sidero
+type SourceOfTruth string
+
+// SourceOfTruth values.
+const (
+	// SourceOfTruthManagementAPI overwrites the AdoptedServer field with the value listed by the Management API.
+	SourceOfTruthManagementAPI SourceOfTruth = "ManagementAPI"
+	// SourceOfTruthSidero keeps the AdoptedServer field, the value listed by the Management API is only used on creation.
+	SourceOfTruthSidero SourceOfTruth = "Sidero"
+)
+
+// InventorySourceOfTruth defines the source of truth for each of the synced AdoptedServer fields.
+//
+// Fields which are not set use Default.
+type InventorySourceOfTruth struct {
+	// Default is used for the fields which are not set.
+	// Defaults to ManagementAPI.
+	// +optional
+	Default SourceOfTruth `json:"default,omitempty"`
+
+	// Endpoint is the Talos API endpoint (spec.talos.endpoint).
+	// +optional
+	Endpoint SourceOfTruth `json:"endpoint,omitempty"`
+
+	// NodeType is the machine type (spec.talos.nodeType).
+	// +optional
+	NodeType SourceOfTruth `json:"nodeType,omitempty"`
+
+	// Hostname is the node hostname (spec.talos.hostname).
+	// +optional
+	Hostname SourceOfTruth `json:"hostname,omitempty"`
+
+	// Versions are the Talos and Kubernetes versions (spec.talos.talosVersion and spec.talos.kubernetesVersion).
+	// +optional
+	Versions SourceOfTruth `json:"versions,omitempty"`
+
+	// Labels are the custom labels (spec.labels).
+	// +optional
+	Labels SourceOfTruth `json:"labels,omitempty"`
+}
+
+// ManagementAPIInventorySpec defines the desired state of ManagementAPIInventory.
+type ManagementAPIInventorySpec struct {
+	// Endpoint is the base URL of the Talos Management API.
+	// +kubebuilder:validation:Required
+	Endpoint string `json:"endpoint"`
+
+	// SecretRef references a secret containing Management API credentials, see ManagementAPIConfig.SecretRef.
+	// The reference is copied to the created AdoptedServers.
+	// +optional
+	SecretRef *corev1.SecretReference `json:"secretRef,omitempty"`
+
+	// ClusterName is the cluster name in the Management API, servers of the cluster are listed.
+	// +kubebuilder:validation:Required
+	ClusterName string `json:"clusterName"`
+
+	// TalosSecretRef references a secret containing Talos API client credentials, copied to the created AdoptedServers.
+	// +optional
+	TalosSecretRef *corev1.SecretReference `json:"talosSecretRef,omitempty"`
+
+	// Interval is the interval between the inventory syncs.
+	// Defaults to 5m.
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+
+	// Labels are set as custom labels on the created AdoptedServers, the labels listed by the Management API take precedence.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Accept marks the created AdoptedServers as accepted.
+	// By default they are not accepted, so that the operator can review them before Sidero starts managing the nodes.
+	// +optional
+	Accept bool `json:"accept,omitempty"`
+
+	// SourceOfTruth resolves the conflicts between the Management API and the existing AdoptedServers.
+	// +optional
+	SourceOfTruth InventorySourceOfTruth `json:"sourceOfTruth,omitempty"`
+}
+
+const (
+	// ConditionInventorySynced indicates whether the last inventory sync was completed.
+	ConditionInventorySynced clusterv1.ConditionType = "InventorySynced"
+)
+
+// Condition reasons for the InventorySynced condition.
+const (
+	// InventorySyncFailedReason (Severity=Warning) documents that the servers couldn't be listed or reconciled.
+	InventorySyncFailedReason = "SyncFailed"
+)
+
+// ManagementAPIInventoryLabel is set on the AdoptedServers created by the inventory, and holds the name of the inventory.
+const ManagementAPIInventoryLabel = "metal.sidero.dev/management-api-inventory"
+
+// SyncRequestedAnnotation requests a sync of the ManagementAPIInventory or AdoptedServer before the interval elapses.
+//
+// It holds the RFC3339 time of the request, the object is synced if the last sync happened before it.
+const SyncRequestedAnnotation = "metal.sidero.dev/sync-requested"
+
+// ManagementAPIInventoryStatus defines the observed state of ManagementAPIInventory.
+type ManagementAPIInventoryStatus struct {
+	// LastSyncTime is when the last sync was completed.
+	// +optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+
+	// ObservedGeneration is the generation of the spec used by the last sync.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Servers lists the servers returned by the Management API during the last sync.
+	// +optional
+	Servers []InventoryServer `json:"servers,omitempty"`
+
+	// RemovedUpstream lists the AdoptedServers of the inventory which are no longer listed by the Management API.
+	// +optional
+	RemovedUpstream []string `json:"removedUpstream,omitempty"`
+
+	// Conditions defines current service state of the ManagementAPIInventory.
+	// +optional
+	Conditions []clusterv1.Condition `json:"conditions,omitempty"`
+}
+
+// InventoryServer describes a server listed by the Management API.
+type InventoryServer struct {
+	// Name is the server name in the Management API.
+	Name string `json:"name"`
+
+	// NodeID is the UUID of the node in the Management API.
+	// +optional
+	NodeID string `json:"nodeID,omitempty"`
+
+	// AdoptedServer is the name of the AdoptedServer representing the server.
+	// +optional
+	AdoptedServer string `json:"adoptedServer,omitempty"`
+
+	// Conflicts lists the fields which differ from the Management API and were kept as Sidero is their source of truth.
+	// +optional
+	Conflicts []string `json:"conflicts,omitempty"`
+
+	// Error is set if the AdoptedServer couldn't be created or updated.
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Endpoint",type="string",JSONPath=".spec.endpoint",description="Management API endpoint"
+// +kubebuilder:printcolumn:name="Cluster",type="string",JSONPath=".spec.clusterName",description="Management API cluster"
+// +kubebuilder:printcolumn:name="Last Sync",type="date",JSONPath=".status.lastSyncTime",description="Time since the last sync"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time since creation"
+// +kubebuilder:storageversion
+
+// ManagementAPIInventory is the Schema for the managementapiinventories API.
+// ManagementAPIInventory creates and updates AdoptedServers from the servers registered in the Management API.
+type ManagementAPIInventory struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ManagementAPIInventorySpec   `json:"spec,omitempty"`
+	Status ManagementAPIInventoryStatus `json:"status,omitempty"`
+}
+
+// GetConditions returns the conditions from the status.
+func (inventory *ManagementAPIInventory) GetConditions() clusterv1.Conditions {
+	return inventory.Status.Conditions
+}
+
+// SetConditions sets the conditions in the status.
+func (inventory *ManagementAPIInventory) SetConditions(conditions clusterv1.Conditions) {
+	inventory.Status.Conditions = conditions
+}
+
+// +kubebuilder:object:root=true
+
+// ManagementAPIInventoryList contains a list of ManagementAPIInventory.
+type ManagementAPIInventoryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ManagementAPIInventory `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ManagementAPIInventory{}, &ManagementAPIInventoryList{})
+}
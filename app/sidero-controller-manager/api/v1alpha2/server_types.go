@@ -294,6 +294,11 @@ type ServerStatus struct {
 
 	// Power is the current power state of the server: "on", "off" or "unknown".
 	Power string `json:"power,omitempty"`
+
+	// ManagementAPIStatus contains sync status with the Management API.
+	// +optional
+	// +k8s:conversion-gen=false
+	ManagementAPIStatus *ManagementAPIStatus `json:"managementAPIStatus,omitempty"`
 }
 
 // +kubebuilder:object:root=true
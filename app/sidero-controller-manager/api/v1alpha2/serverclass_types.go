@@ -49,6 +49,13 @@ type ServerClassSpec struct {
 	//
 	// +optional
 	BootFromDiskMethod siderotypes.BootFromDisk `json:"bootFromDiskMethod,omitempty"`
+	// ManagementAPI enables the sync of the servers of this server class to the Management API.
+	//
+	// Each server is registered as a node of the Management API cluster named after the CAPI cluster of its ServerBinding.
+	// Servers which are not allocated belong to the ClusterName cluster (or ClusterID if set), "sidero-pool" by default.
+	// +optional
+	// +k8s:conversion-gen=false
+	ManagementAPI *ManagementAPIConfig `json:"managementAPI,omitempty"`
 }
 
 // ServerClassStatus defines the observed state of ServerClass.
@@ -0,0 +1,131 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Actions of a TalosOperation.
+const (
+	TalosOperationReboot         = "reboot"
+	TalosOperationShutdown       = "shutdown"
+	TalosOperationReset          = "reset"
+	TalosOperationRestartService = "restart-service"
+)
+
+// Wipe modes of the reset action.
+const (
+	ResetWipeModeAll        = "all"
+	ResetWipeModeSystemDisk = "system-disk"
+	ResetWipeModeUserDisks  = "user-disks"
+)
+
+// TalosOperationSpec defines the desired state of TalosOperation.
+type TalosOperationSpec struct {
+	// AdoptedServer is the name of the AdoptedServer the operation runs on.
+	// +kubebuilder:validation:Required
+	AdoptedServer string `json:"adoptedServer"`
+
+	// Action is the operation to run: reboot, shutdown, reset or restart-service.
+	// +kubebuilder:validation:Enum=reboot;shutdown;reset;restart-service
+	// +kubebuilder:validation:Required
+	Action string `json:"action"`
+
+	// Service is the name of the service restarted by the restart-service action, e.g. kubelet.
+	// +optional
+	Service string `json:"service,omitempty"`
+
+	// Reset contains the options of the reset action.
+	// +optional
+	Reset *ResetOptions `json:"reset,omitempty"`
+
+	// Force skips the graceful shutdown of the node for the shutdown action.
+	// +optional
+	Force bool `json:"force,omitempty"`
+}
+
+// ResetOptions defines the options of the reset action.
+type ResetOptions struct {
+	// Graceful makes the node leave etcd and cordon itself before the reset.
+	// Defaults to true, a non-graceful reset of a control plane node might break the etcd quorum.
+	// +kubebuilder:default=true
+	// +optional
+	Graceful *bool `json:"graceful,omitempty"`
+
+	// Reboot reboots the node after the reset, otherwise the node is powered off.
+	// +optional
+	Reboot bool `json:"reboot,omitempty"`
+
+	// WipeMode defines the disks to wipe: all, system-disk or user-disks.
+	// Defaults to all.
+	// +kubebuilder:validation:Enum=all;system-disk;user-disks
+	// +optional
+	WipeMode string `json:"wipeMode,omitempty"`
+
+	// UserDisksToWipe lists the user disks wiped in the user-disks mode, e.g. /dev/sdb.
+	// +optional
+	UserDisksToWipe []string `json:"userDisksToWipe,omitempty"`
+}
+
+// Phases of a TalosOperation.
+const (
+	TalosOperationPending   = "Pending"
+	TalosOperationRunning   = "Running"
+	TalosOperationSucceeded = "Succeeded"
+	TalosOperationFailed    = "Failed"
+)
+
+// TalosOperationStatus defines the observed state of TalosOperation.
+type TalosOperationStatus struct {
+	// Phase of the operation: "Pending", "Running", "Succeeded" or "Failed".
+	// +optional
+	Phase string `json:"phase,omitempty"`
+
+	// StartTime is when the operation was started.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is when the operation succeeded or failed.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Error describes why the operation failed.
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,shortName=top
+// +kubebuilder:printcolumn:name="Server",type="string",JSONPath=".spec.adoptedServer",description="AdoptedServer the operation runs on"
+// +kubebuilder:printcolumn:name="Action",type="string",JSONPath=".spec.action",description="Operation action"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase",description="Operation phase"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time since creation"
+// +kubebuilder:storageversion
+
+// TalosOperation is the Schema for the talosoperations API.
+// TalosOperation runs a one-off power or lifecycle operation on an adopted node through the Talos API.
+// The operation runs at most once; create a new TalosOperation to repeat it.
+type TalosOperation struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   TalosOperationSpec   `json:"spec,omitempty"`
+	Status TalosOperationStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// TalosOperationList contains a list of TalosOperation.
+type TalosOperationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []TalosOperation `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&TalosOperation{}, &TalosOperationList{})
+}
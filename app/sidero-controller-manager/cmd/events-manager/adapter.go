@@ -89,6 +89,10 @@ func (a *Adapter) HandleEvent(ctx context.Context, event events.Event) error {
 		fields = append(fields, zap.String("machine", annotation.MachineName))
 	}
 
+	if annotation.AdoptedServerName != "" {
+		fields = append(fields, zap.String("adopted_server", annotation.AdoptedServerName))
+	}
+
 	switch event := event.Payload.(type) {
 	case *machine.AddressEvent:
 		fields = append(fields, zap.String("hostname", event.GetHostname()), zap.Strings("addresses", event.GetAddresses()))
@@ -237,6 +241,11 @@ func (a *Adapter) patchServerBinding(ctx context.Context, ip string, callback fu
 		return fmt.Errorf("failed to find ServerBindings for ip %s", ip)
 	}
 
+	if annotation.AdoptedServerName != "" {
+		// adopted servers are not provisioned by Sidero, so there is no ServerBinding to update
+		return nil
+	}
+
 	var serverbinding sidero.ServerBinding
 	if err := a.metalClient.Get(ctx, types.NamespacedName{Name: annotation.ServerUUID}, &serverbinding); err != nil {
 		return err
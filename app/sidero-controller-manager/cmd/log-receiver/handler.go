@@ -39,6 +39,10 @@ func logHandler(logger *zap.Logger, annotator *siderolink.Annotator) logreceiver
 			msg["machine"] = annotation.MachineName
 		}
 
+		if annotation.AdoptedServerName != "" {
+			msg["adopted_server"] = annotation.AdoptedServerName
+		}
+
 		if err := json.NewEncoder(os.Stdout).Encode(msg); err != nil {
 			logger.Error("error printing log message", zap.Error(err))
 		}
@@ -12,6 +12,7 @@ import (
 	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 
 	sidero "github.com/siderolabs/sidero/app/caps-controller-manager/api/v1alpha3"
+	metalv1 "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
 )
 
 func getMetalClient() (runtimeclient.Client, *rest.Config, error) {
@@ -27,6 +28,10 @@ func getMetalClient() (runtimeclient.Client, *rest.Config, error) {
 		return nil, nil, err
 	}
 
+	if err := metalv1.AddToScheme(scheme); err != nil {
+		return nil, nil, err
+	}
+
 	client, err := runtimeclient.New(kubeconfig, runtimeclient.Options{Scheme: scheme})
 
 	return client, kubeconfig, err
@@ -7,14 +7,20 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"net"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
+	talosclient "github.com/siderolabs/talos/pkg/machinery/client"
 	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/tools/reference"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
@@ -24,9 +30,15 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	metalv1 "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/internal/siderolink"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/internal/talos"
 	"github.com/siderolabs/sidero/app/sidero-controller-manager/pkg/constants"
 	"github.com/siderolabs/sidero/app/sidero-controller-manager/pkg/managementapi"
 )
@@ -35,8 +47,12 @@ const (
 	adoptedServerFinalizer = "metal.sidero.dev/adopted-server"
 	healthCheckInterval    = 30 * time.Second
 	syncInterval           = 5 * time.Minute
+	talosAPITimeout        = 10 * time.Second
 )
 
+// errManagementAPICredentials is returned when the Management API credentials can't be loaded.
+var errManagementAPICredentials = errors.New("failed to load Management API credentials")
+
 // AdoptedServerReconciler reconciles an AdoptedServer object.
 type AdoptedServerReconciler struct {
 	client.Client
@@ -44,13 +60,32 @@ type AdoptedServerReconciler struct {
 	Scheme    *runtime.Scheme
 	APIReader client.Reader
 	Recorder  record.EventRecorder
+
+	// APIEndpoint and APIPort are used to build the SideroLink API URL for adopted nodes.
+	APIEndpoint string
+	APIPort     uint16
+
+	// ManagementAPIClients is shared by the controllers talking to the Management API.
+	ManagementAPIClients *managementapi.Pool
+
+	// Notifications optionally triggers reconciles of the AdoptedServers from the Management API notifications.
+	Notifications <-chan event.GenericEvent
+
+	// BatchedManagementAPISync disables the Management API sync of each reconcile,
+	// the AdoptedServers are synced by the ManagementAPISyncLoop instead, unless a sync is requested by a notification.
+	BatchedManagementAPISync bool
 }
 
 // +kubebuilder:rbac:groups=metal.sidero.dev,resources=adoptedservers,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=metal.sidero.dev,resources=adoptedservers/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=metal.sidero.dev,resources=adoptedservers/finalizers,verbs=update
 // +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
-// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=servers,verbs=get;list;watch;create;update;patch
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=serverbindings;metalmachines,verbs=get;list;watch;create
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=serverbindings/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machines,verbs=get;list;watch;create
 
 func (r *AdoptedServerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
@@ -80,6 +115,10 @@ func (r *AdoptedServerReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 				metalv1.ConditionAdopted,
 				metalv1.ConditionSideroLinkReady,
 				metalv1.ConditionManagementAPISync,
+				metalv1.ConditionPromoted,
+				metalv1.ConditionVersionDrift,
+				metalv1.ConditionUpgraded,
+				metalv1.ConditionConfigPatched,
 			},
 		}); err != nil {
 			logger.Error(err, "Failed to patch AdoptedServer")
@@ -125,80 +164,127 @@ func (r *AdoptedServerReconciler) reconcileNormal(ctx context.Context, as *metal
 	conditions.MarkTrue(as, metalv1.ConditionAdopted)
 
 	// Step 1: Check Talos API connectivity
-	connected, err := r.checkTalosConnectivity(ctx, as)
+	talosClient, err := r.talosClient(ctx, as)
 	if err != nil {
-		logger.Error(err, "Failed to check Talos connectivity")
-		conditions.MarkFalse(as, metalv1.ConditionConnected, "ConnectivityCheckFailed", clusterv1.ConditionSeverityError, err.Error())
+		logger.Error(err, "Failed to load Talos API credentials")
+		conditions.MarkFalse(as, metalv1.ConditionConnected, metalv1.TalosCredentialsUnavailableReason, clusterv1.ConditionSeverityError, "%s", err.Error())
 		as.Status.Connected = false
 		as.Status.Ready = false
-		r.Recorder.Event(asRef, corev1.EventTypeWarning, "ConnectivityCheckFailed", fmt.Sprintf("Failed to check Talos API connectivity: %s", err.Error()))
+		r.Recorder.Event(asRef, corev1.EventTypeWarning, metalv1.TalosCredentialsUnavailableReason, fmt.Sprintf("Failed to load Talos API credentials: %s", err.Error()))
+		r.checkUnreachableUpgrade(as, asRef)
 		return ctrl.Result{RequeueAfter: healthCheckInterval}, nil
 	}
+	defer talosClient.Close() //nolint:errcheck
 
-	if !connected {
-		logger.Info("Talos API not reachable")
-		conditions.MarkFalse(as, metalv1.ConditionConnected, "Unreachable", clusterv1.ConditionSeverityWarning, "Talos API endpoint is not reachable")
+	probe, err := r.checkTalosConnectivity(ctx, as, talosClient)
+	if err != nil {
+		reason := talos.FailureReason(err)
+		severity := clusterv1.ConditionSeverityWarning
+		if reason == metalv1.TalosAuthenticationFailedReason {
+			severity = clusterv1.ConditionSeverityError
+		}
+
+		logger.Info("Talos API not reachable", "reason", reason, "error", err.Error())
+		conditions.MarkFalse(as, metalv1.ConditionConnected, reason, severity, "Talos API did not respond after %s: %s", probe.Latency.Round(time.Millisecond), err.Error())
 		as.Status.Connected = false
 		as.Status.Ready = false
-		r.Recorder.Event(asRef, corev1.EventTypeWarning, "Unreachable", "Talos API endpoint is not reachable")
+		r.Recorder.Event(asRef, corev1.EventTypeWarning, reason, fmt.Sprintf("Failed to check Talos API connectivity: %s", err.Error()))
+		r.checkUnreachableUpgrade(as, asRef)
 		return ctrl.Result{RequeueAfter: healthCheckInterval}, nil
 	}
 
 	// Connection successful
-	logger.Info("Talos API is reachable")
-	conditions.MarkTrue(as, metalv1.ConditionConnected)
+	logger.Info("Talos API is reachable", "version", probe.Version, "latency", probe.Latency)
+	conditions.Set(as, &clusterv1.Condition{
+		Type:    metalv1.ConditionConnected,
+		Status:  corev1.ConditionTrue,
+		Message: fmt.Sprintf("Talos %s responded in %s", probe.Version, probe.Latency.Round(time.Millisecond)),
+	})
 	as.Status.Connected = true
 	now := metav1.Now()
 	as.Status.LastContactTime = &now
 
 	// Step 2: Gather node information
-	if err := r.gatherNodeInfo(ctx, as); err != nil {
+	if err := r.gatherNodeInfo(ctx, as, asRef, talosClient); err != nil {
 		logger.Error(err, "Failed to gather node information")
 		// Don't fail reconciliation, just log the error
 	}
 
 	// Step 3: Perform health check
-	if err := r.performHealthCheck(ctx, as); err != nil {
-		logger.Error(err, "Failed to perform health check")
-		// Don't fail reconciliation, just log the error
+	r.performHealthCheck(ctx, as, talosClient)
+
+	// Step 4: Check version drift, and upgrade Talos if requested
+	r.checkVersionDrift(as, asRef)
+
+	if err := r.reconcileUpgrade(ctx, as, asRef, talosClient); err != nil {
+		logger.Error(err, "Failed to upgrade Talos")
+		conditions.MarkFalse(as, metalv1.ConditionUpgraded, metalv1.UpgradeFailedReason, clusterv1.ConditionSeverityWarning, "%s", err.Error())
+		r.Recorder.Event(asRef, corev1.EventTypeWarning, metalv1.UpgradeFailedReason, fmt.Sprintf("Failed to upgrade Talos: %s", err.Error()))
+	}
+
+	// Step 5: Apply machine configuration patches
+	if err := r.reconcileConfigPatches(ctx, as, asRef, talosClient); err != nil {
+		logger.Error(err, "Failed to apply machine config patches")
+		conditions.MarkFalse(as, metalv1.ConditionConfigPatched, metalv1.ConfigPatchFailedReason, clusterv1.ConditionSeverityWarning, "%s", err.Error())
+		r.Recorder.Event(asRef, corev1.EventTypeWarning, metalv1.ConfigPatchFailedReason, fmt.Sprintf("Failed to apply machine config patches: %s", err.Error()))
 	}
 
-	// Step 4: Setup SideroLink if enabled
-	if as.Spec.SideroLink != nil && as.Spec.SideroLink.Enabled {
-		if err := r.setupSideroLink(ctx, as); err != nil {
+	// Step 6: Setup SideroLink if enabled
+	switch {
+	case as.Spec.SideroLink != nil && as.Spec.SideroLink.Enabled:
+		if err := r.setupSideroLink(ctx, as, asRef, talosClient); err != nil {
 			logger.Error(err, "Failed to setup SideroLink")
-			conditions.MarkFalse(as, metalv1.ConditionSideroLinkReady, "SetupFailed", clusterv1.ConditionSeverityWarning, err.Error())
+			conditions.MarkFalse(as, metalv1.ConditionSideroLinkReady, metalv1.SideroLinkSetupFailedReason, clusterv1.ConditionSeverityWarning, "%s", err.Error())
 			r.Recorder.Event(asRef, corev1.EventTypeWarning, "SideroLinkSetupFailed", fmt.Sprintf("Failed to setup SideroLink: %s", err.Error()))
+		} else if as.Spec.SideroLink.PublicKey == "" {
+			conditions.MarkFalse(as, metalv1.ConditionSideroLinkReady, metalv1.SideroLinkWaitingForNodeReason, clusterv1.ConditionSeverityInfo, "Waiting for the node to establish the SideroLink tunnel")
 		} else {
 			conditions.MarkTrue(as, metalv1.ConditionSideroLinkReady)
 		}
-	} else {
+	case as.Spec.SideroLink != nil && as.Spec.SideroLink.Address != "":
+		// SideroLink was disabled, remove the configuration from the node
+		if err := r.teardownSideroLink(ctx, as, talosClient); err != nil {
+			logger.Error(err, "Failed to teardown SideroLink")
+			r.Recorder.Event(asRef, corev1.EventTypeWarning, "SideroLinkTeardownFailed", fmt.Sprintf("Failed to teardown SideroLink: %s", err.Error()))
+		} else {
+			as.Spec.SideroLink.Address = ""
+			as.Spec.SideroLink.PublicKey = ""
+			as.Status.SideroLinkStatus = nil
+
+			conditions.Delete(as, metalv1.ConditionSideroLinkReady)
+		}
+	default:
 		conditions.Delete(as, metalv1.ConditionSideroLinkReady)
 	}
 
-	// Step 5: Sync with Management API if enabled
-	if as.Spec.ManagementAPI != nil && as.Spec.ManagementAPI.Enabled {
-		if err := r.syncWithManagementAPI(ctx, as); err != nil {
+	// Step 7: Promote into the CAPI lifecycle if requested
+	if as.Spec.Promotion != nil {
+		if err := r.promote(ctx, as, asRef, talosClient); err != nil {
+			logger.Error(err, "Failed to promote AdoptedServer")
+			conditions.MarkFalse(as, metalv1.ConditionPromoted, metalv1.PromotionFailedReason, clusterv1.ConditionSeverityWarning, "%s", err.Error())
+			r.Recorder.Event(asRef, corev1.EventTypeWarning, metalv1.PromotionFailedReason, fmt.Sprintf("Failed to promote server: %s", err.Error()))
+		}
+	}
+
+	// Step 8: Sync with Management API if enabled
+	switch {
+	case as.Spec.ManagementAPI == nil || !as.Spec.ManagementAPI.Enabled:
+		conditions.Delete(as, metalv1.ConditionManagementAPISync)
+	case conditions.IsTrue(as, metalv1.ConditionRemovedUpstream):
+		// syncing would register the server again
+		conditions.MarkFalse(as, metalv1.ConditionManagementAPISync, metalv1.ManagementAPIRemovedUpstreamReason, clusterv1.ConditionSeverityInfo,
+			"Server was removed from the Management API")
+	case r.BatchedManagementAPISync && !managementAPISyncRequested(as):
+		// synced with the other servers of the cluster by the ManagementAPISyncLoop, unless a sync was requested
+		// by a Management API notification
+	default:
+		err := r.syncWithManagementAPI(ctx, as)
+		if err != nil {
 			logger.Error(err, "Failed to sync with Management API")
-			conditions.MarkFalse(as, metalv1.ConditionManagementAPISync, "SyncFailed", clusterv1.ConditionSeverityWarning, err.Error())
-			if as.Status.ManagementAPIStatus == nil {
-				as.Status.ManagementAPIStatus = &metalv1.ManagementAPIStatus{}
-			}
-			as.Status.ManagementAPIStatus.Synced = false
-			as.Status.ManagementAPIStatus.Error = err.Error()
 			r.Recorder.Event(asRef, corev1.EventTypeWarning, "ManagementAPISyncFailed", fmt.Sprintf("Failed to sync with Management API: %s", err.Error()))
-		} else {
-			conditions.MarkTrue(as, metalv1.ConditionManagementAPISync)
-			if as.Status.ManagementAPIStatus == nil {
-				as.Status.ManagementAPIStatus = &metalv1.ManagementAPIStatus{}
-			}
-			as.Status.ManagementAPIStatus.Synced = true
-			as.Status.ManagementAPIStatus.Error = ""
-			syncTime := metav1.Now()
-			as.Status.ManagementAPIStatus.LastSyncTime = &syncTime
 		}
-	} else {
-		conditions.Delete(as, metalv1.ConditionManagementAPISync)
+
+		setManagementAPISyncResult(as, err)
 	}
 
 	// Mark as ready if all critical checks pass
@@ -220,8 +306,8 @@ func (r *AdoptedServerReconciler) reconcileDelete(ctx context.Context, as *metal
 		return ctrl.Result{}, nil
 	}
 
-	// Cleanup: unregister from Management API if registered
-	if as.Spec.ManagementAPI != nil && as.Spec.ManagementAPI.Enabled {
+	// Cleanup: unregister from Management API if registered, unless it was already removed there
+	if as.Spec.ManagementAPI != nil && as.Spec.ManagementAPI.Enabled && !conditions.IsTrue(as, metalv1.ConditionRemovedUpstream) {
 		if err := r.unregisterFromManagementAPI(ctx, as); err != nil {
 			logger.Error(err, "Failed to unregister from Management API")
 			r.Recorder.Event(asRef, corev1.EventTypeWarning, "UnregisterFailed", fmt.Sprintf("Failed to unregister from Management API: %s", err.Error()))
@@ -230,8 +316,8 @@ func (r *AdoptedServerReconciler) reconcileDelete(ctx context.Context, as *metal
 	}
 
 	// Cleanup: teardown SideroLink if configured
-	if as.Spec.SideroLink != nil && as.Spec.SideroLink.Enabled {
-		if err := r.teardownSideroLink(ctx, as); err != nil {
+	if as.Spec.SideroLink != nil && as.Spec.SideroLink.Address != "" {
+		if err := r.teardownSideroLinkOnDelete(ctx, as); err != nil {
 			logger.Error(err, "Failed to teardown SideroLink")
 			r.Recorder.Event(asRef, corev1.EventTypeWarning, "SideroLinkTeardownFailed", fmt.Sprintf("Failed to teardown SideroLink: %s", err.Error()))
 			// Continue with deletion even if teardown fails
@@ -248,115 +334,293 @@ func (r *AdoptedServerReconciler) reconcileDelete(ctx context.Context, as *metal
 	return ctrl.Result{}, nil
 }
 
-// checkTalosConnectivity checks if the Talos API endpoint is reachable.
-// TODO: Implement actual talosctl connectivity check using talosctl or gRPC client
-func (r *AdoptedServerReconciler) checkTalosConnectivity(ctx context.Context, as *metalv1.AdoptedServer) (bool, error) {
+// talosClient creates a Talos API client using the credentials referenced by the AdoptedServer.
+func (r *AdoptedServerReconciler) talosClient(ctx context.Context, as *metalv1.AdoptedServer) (*talosclient.Client, error) {
+	return newAdoptedServerTalosClient(ctx, r.Client, as)
+}
+
+// newAdoptedServerTalosClient creates a Talos API client using the credentials referenced by the AdoptedServer.
+func newAdoptedServerTalosClient(ctx context.Context, c client.Reader, as *metalv1.AdoptedServer) (*talosclient.Client, error) {
+	ref := as.Spec.Talos.SecretRef
+	if ref == nil {
+		return nil, errors.New("spec.talos.secretRef is not set")
+	}
+
+	secret, err := getSecret(ctx, c, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	return talos.NewClient(ctx, as.Spec.Talos.Endpoint, secret)
+}
+
+// getSecret reads the referenced secret, the namespace defaults to "default".
+func getSecret(ctx context.Context, c client.Reader, ref *corev1.SecretReference) (*corev1.Secret, error) {
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = corev1.NamespaceDefault
+	}
+
+	var secret corev1.Secret
+
+	if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: ref.Name}, &secret); err != nil {
+		return nil, errors.Wrapf(err, "failed to get secret %s/%s", namespace, ref.Name)
+	}
+
+	return &secret, nil
+}
+
+// checkTalosConnectivity checks if the Talos API endpoint is reachable by calling the machine Version API.
+func (r *AdoptedServerReconciler) checkTalosConnectivity(ctx context.Context, as *metalv1.AdoptedServer, c *talosclient.Client) (talos.ProbeResult, error) {
 	logger := log.FromContext(ctx)
 	logger.Info("Checking Talos connectivity", "endpoint", as.Spec.Talos.Endpoint)
 
-	// Placeholder implementation
-	// In a real implementation, this would:
-	// 1. Use talosctl or gRPC client to connect to the endpoint
-	// 2. Execute a simple command like `talosctl version` or gRPC health check
-	// 3. Return true if successful, false otherwise
-
-	// For now, assume connectivity is successful
-	// TODO: Implement actual connectivity check
-	return true, nil
+	return talos.Probe(ctx, c, talosAPITimeout)
 }
 
-// gatherNodeInfo collects information from the Talos node.
-// TODO: Implement using talosctl or gRPC API
-func (r *AdoptedServerReconciler) gatherNodeInfo(ctx context.Context, as *metalv1.AdoptedServer) error {
+// gatherNodeInfo collects information from the Talos node and records it in the status.
+//
+// A NodeInfoChanged event is emitted if any of the reported values differ from the previous status.
+func (r *AdoptedServerReconciler) gatherNodeInfo(ctx context.Context, as *metalv1.AdoptedServer, asRef *corev1.ObjectReference, c *talosclient.Client) error {
 	logger := log.FromContext(ctx)
 	logger.Info("Gathering node information", "endpoint", as.Spec.Talos.Endpoint)
 
-	// Placeholder implementation
-	// In a real implementation, this would:
-	// 1. Query the node for system information
-	// 2. Update as.Status.NodeInfo with the gathered data
-	// 3. Update as.Status.Addresses with discovered IPs
+	ctx, cancel := context.WithTimeout(ctx, talosAPITimeout)
+	defer cancel()
 
-	// For now, create basic node info from spec
-	if as.Status.NodeInfo == nil {
-		as.Status.NodeInfo = &metalv1.NodeInfo{
-			OperatingSystem: "talos",
-		}
+	info, err := talos.ReadNodeInfo(ctx, c)
+	if err != nil {
+		return errors.Wrap(err, "failed to read node information")
 	}
 
-	// TODO: Implement actual node info gathering
+	nodeInfo := &metalv1.NodeInfo{
+		Hostname:          info.Hostname,
+		Architecture:      info.Architecture,
+		OperatingSystem:   "talos",
+		MachineID:         info.MachineID,
+		UUID:              info.UUID,
+		KernelVersion:     info.KernelVersion,
+		TalosVersion:      info.TalosVersion,
+		KubernetesVersion: info.KubernetesVersion,
+		ClusterName:       info.ClusterName,
+	}
+
+	addresses := make([]corev1.NodeAddress, 0, len(info.Addresses)+1)
+
+	for _, addr := range info.Addresses {
+		addresses = append(addresses, corev1.NodeAddress{
+			Type:    corev1.NodeInternalIP,
+			Address: addr.String(),
+		})
+	}
+
+	if info.Hostname != "" {
+		addresses = append(addresses, corev1.NodeAddress{
+			Type:    corev1.NodeHostName,
+			Address: info.Hostname,
+		})
+	}
+
+	changes := nodeInfoChanges(as.Status.NodeInfo, nodeInfo)
+
+	if !apiequality.Semantic.DeepEqual(as.Status.Addresses, addresses) {
+		changes = append(changes, fmt.Sprintf("addresses: %v -> %v", addressesString(as.Status.Addresses), addressesString(addresses)))
+	}
+
+	if len(changes) > 0 {
+		logger.Info("Node information changed", "changes", changes)
+		r.Recorder.Event(asRef, corev1.EventTypeNormal, "NodeInfoChanged", strings.Join(changes, ", "))
+	}
+
+	as.Status.NodeInfo = nodeInfo
+	as.Status.Addresses = addresses
+
 	return nil
 }
 
-// performHealthCheck checks the health of the Talos node.
-// TODO: Implement using talosctl health check or gRPC API
-func (r *AdoptedServerReconciler) performHealthCheck(ctx context.Context, as *metalv1.AdoptedServer) error {
+// nodeInfoChanges describes the fields which differ between the old and updated node information.
+func nodeInfoChanges(old, updated *metalv1.NodeInfo) []string {
+	if old == nil {
+		old = &metalv1.NodeInfo{}
+	}
+
+	var changes []string
+
+	for _, field := range []struct {
+		name         string
+		old, updated string
+	}{
+		{"hostname", old.Hostname, updated.Hostname},
+		{"architecture", old.Architecture, updated.Architecture},
+		{"operatingSystem", old.OperatingSystem, updated.OperatingSystem},
+		{"machineID", old.MachineID, updated.MachineID},
+		{"uuid", old.UUID, updated.UUID},
+		{"kernelVersion", old.KernelVersion, updated.KernelVersion},
+		{"talosVersion", old.TalosVersion, updated.TalosVersion},
+		{"kubernetesVersion", old.KubernetesVersion, updated.KubernetesVersion},
+		{"clusterName", old.ClusterName, updated.ClusterName},
+	} {
+		if field.old != field.updated {
+			changes = append(changes, fmt.Sprintf("%s: %q -> %q", field.name, field.old, field.updated))
+		}
+	}
+
+	return changes
+}
+
+func addressesString(addresses []corev1.NodeAddress) []string {
+	result := make([]string, 0, len(addresses))
+
+	for _, addr := range addresses {
+		result = append(result, addr.Address)
+	}
+
+	return result
+}
+
+// performHealthCheck runs the health checks against the Talos node and records the results in the status.
+//
+// Checks which cannot be run are reported as Unknown, so the health check itself never fails.
+func (r *AdoptedServerReconciler) performHealthCheck(ctx context.Context, as *metalv1.AdoptedServer, c *talosclient.Client) {
 	logger := log.FromContext(ctx)
 	logger.Info("Performing health check", "endpoint", as.Spec.Talos.Endpoint)
 
-	// Placeholder implementation
-	// In a real implementation, this would:
-	// 1. Execute health checks against the node
-	// 2. Check etcd health, kubelet status, etc.
-	// 3. Update as.Status.Health with results
+	ctx, cancel := context.WithTimeout(ctx, talosAPITimeout)
+	defer cancel()
+
+	results := talos.RunHealthChecks(ctx, c, talos.DefaultHealthChecks, as.Spec.Talos.NodeType == "controlplane")
+	status, message := talos.OverallHealth(results)
 
 	if as.Status.Health == nil {
 		as.Status.Health = &metalv1.HealthStatus{}
 	}
 
 	now := metav1.Now()
-	as.Status.Health.Status = "healthy"
+	as.Status.Health.Status = status
 	as.Status.Health.LastCheckTime = &now
-	as.Status.Health.Message = "All systems operational"
+	as.Status.Health.Message = message
+	as.Status.Health.Checks = results
 
-	// TODO: Implement actual health check
-	return nil
+	logger.Info("Health check completed", "status", status)
 }
 
-// setupSideroLink configures SideroLink monitoring for the adopted server.
-// TODO: Implement SideroLink setup
-func (r *AdoptedServerReconciler) setupSideroLink(ctx context.Context, as *metalv1.AdoptedServer) error {
+// setupSideroLink enrolls the adopted server into the SideroLink network.
+//
+// The node address is allocated from the SideroLink subnet, and the node is configured to connect
+// to the SideroLink API, which registers the node public key, so that the peer is accepted by the Wireguard device.
+// Events and kernel logs are delivered over SideroLink to the events-manager and log-receiver.
+func (r *AdoptedServerReconciler) setupSideroLink(ctx context.Context, as *metalv1.AdoptedServer, asRef *corev1.ObjectReference, c *talosclient.Client) error {
 	logger := log.FromContext(ctx)
 	logger.Info("Setting up SideroLink", "endpoint", as.Spec.Talos.Endpoint)
 
-	// Placeholder implementation
-	// In a real implementation, this would:
-	// 1. Generate or retrieve SideroLink Wireguard keys
-	// 2. Assign IPv6 address from the SideroLink pool
-	// 3. Configure the Talos node with SideroLink settings
-	// 4. Update as.Spec.SideroLink and as.Status.SideroLinkStatus
+	if !siderolink.Cfg.Subnet.IsValid() {
+		return errors.New("SideroLink configuration is not initialized")
+	}
+
+	if r.APIEndpoint == "" {
+		return errors.New("SideroLink API endpoint is not configured")
+	}
+
+	if as.Status.NodeInfo == nil || as.Status.NodeInfo.UUID == "" {
+		return errors.New("node UUID is not known yet")
+	}
+
+	if as.Spec.SideroLink.Address == "" {
+		address, err := siderolink.GenerateNodeAddress(siderolink.Cfg.Subnet)
+		if err != nil {
+			return errors.Wrap(err, "failed to allocate SideroLink address")
+		}
+
+		as.Spec.SideroLink.Address = address.String()
+	}
+
+	serverAddress := siderolink.Cfg.ServerAddress.Addr().String()
+
+	ctx, cancel := context.WithTimeout(ctx, talosAPITimeout)
+	defer cancel()
+
+	changed, err := talos.ConfigureSideroLink(ctx, c, &talos.SideroLinkSettings{
+		APIURL:            "grpc://" + net.JoinHostPort(r.APIEndpoint, strconv.Itoa(int(r.APIPort))),
+		EventSinkEndpoint: net.JoinHostPort(serverAddress, strconv.Itoa(siderolink.EventsSinkPort)),
+		KmsgLogURL:        "tcp://" + net.JoinHostPort(serverAddress, strconv.Itoa(siderolink.LogReceiverPort)),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to push SideroLink configuration")
+	}
+
+	if changed {
+		r.Recorder.Event(asRef, corev1.EventTypeNormal, "SideroLinkConfigured", fmt.Sprintf("Pushed SideroLink configuration with address %s", as.Spec.SideroLink.Address))
+	}
+
+	if as.Status.SideroLinkStatus == nil {
+		as.Status.SideroLinkStatus = &metalv1.SideroLinkStatus{}
+	}
+
+	as.Status.SideroLinkStatus.Connected = as.Spec.SideroLink.PublicKey != ""
 
-	// TODO: Implement actual SideroLink setup
 	return nil
 }
 
-// teardownSideroLink removes SideroLink configuration.
-func (r *AdoptedServerReconciler) teardownSideroLink(ctx context.Context, as *metalv1.AdoptedServer) error {
+// teardownSideroLink removes SideroLink configuration from the node.
+//
+// The Wireguard peer is removed by the siderolink-manager once the address is released.
+func (r *AdoptedServerReconciler) teardownSideroLink(ctx context.Context, as *metalv1.AdoptedServer, c *talosclient.Client) error {
 	logger := log.FromContext(ctx)
 	logger.Info("Tearing down SideroLink", "endpoint", as.Spec.Talos.Endpoint)
 
-	// Placeholder implementation
-	// TODO: Implement actual SideroLink teardown
+	ctx, cancel := context.WithTimeout(ctx, talosAPITimeout)
+	defer cancel()
+
+	if _, err := talos.ConfigureSideroLink(ctx, c, nil); err != nil {
+		return errors.Wrap(err, "failed to remove SideroLink configuration")
+	}
+
 	return nil
 }
 
+func (r *AdoptedServerReconciler) teardownSideroLinkOnDelete(ctx context.Context, as *metalv1.AdoptedServer) error {
+	talosClient, err := r.talosClient(ctx, as)
+	if err != nil {
+		return err
+	}
+
+	defer talosClient.Close() //nolint:errcheck
+
+	return r.teardownSideroLink(ctx, as, talosClient)
+}
+
 // syncWithManagementAPI synchronizes the adopted server with the Management API.
 func (r *AdoptedServerReconciler) syncWithManagementAPI(ctx context.Context, as *metalv1.AdoptedServer) error {
 	logger := log.FromContext(ctx)
 	logger.Info("Syncing with Management API", "endpoint", as.Spec.ManagementAPI.Endpoint)
 
-	// Create Management API client
-	client, err := managementapi.NewClient(as.Spec.ManagementAPI.Endpoint)
+	// Get the pooled Management API client
+	client, err := r.managementAPIClient(ctx, as)
 	if err != nil {
-		return errors.Wrap(err, "failed to create Management API client")
+		return err
 	}
-	defer client.Close()
 
 	// Test connectivity with health check
 	if _, err := client.HealthCheck(ctx); err != nil {
 		return errors.Wrap(err, "failed to connect to Management API")
 	}
 
+	// Join the cluster already registered by another AdoptedServer
+	if managementapi.ClusterID(as) == "" {
+		clusterID, err := r.registeredManagementAPICluster(ctx, as)
+		if err != nil {
+			return err
+		}
+
+		if clusterID != "" {
+			if as.Status.ManagementAPIStatus == nil {
+				as.Status.ManagementAPIStatus = &metalv1.ManagementAPIStatus{}
+			}
+
+			as.Status.ManagementAPIStatus.ClusterID = clusterID
+		}
+	}
+
 	// Create sync service and sync the adopted server
 	syncService := managementapi.NewSyncService(client, logger)
 	if err := syncService.SyncAdoptedServer(ctx, as); err != nil {
@@ -366,22 +630,108 @@ func (r *AdoptedServerReconciler) syncWithManagementAPI(ctx context.Context, as
 	return nil
 }
 
+// managementAPIClient returns the pooled Management API client using the credentials referenced by the AdoptedServer.
+func (r *AdoptedServerReconciler) managementAPIClient(ctx context.Context, as *metalv1.AdoptedServer) (*managementapi.Client, error) {
+	return newManagementAPIClient(ctx, r.Client, r.ManagementAPIClients, as.Spec.ManagementAPI)
+}
+
+// newManagementAPIClient returns the pooled Management API client using the credentials referenced by the config.
+//
+// The secret is read on every sync, so rotated credentials are used as soon as the secret is updated.
+func newManagementAPIClient(ctx context.Context, c client.Reader, pool *managementapi.Pool, config *metalv1.ManagementAPIConfig) (*managementapi.Client, error) {
+	var creds *managementapi.Credentials
+
+	if ref := config.SecretRef; ref != nil {
+		secret, err := getSecret(ctx, c, ref)
+		if err != nil {
+			return nil, errors.Wrap(errManagementAPICredentials, err.Error())
+		}
+
+		creds, err = managementapi.CredentialsFromSecret(secret)
+		if err != nil {
+			return nil, errors.Wrap(errManagementAPICredentials, err.Error())
+		}
+	}
+
+	client, err := pool.Client(config.Endpoint, creds)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create Management API client")
+	}
+
+	return client, nil
+}
+
+// setManagementAPISyncResult records the result of the Management API sync in the AdoptedServer status.
+func setManagementAPISyncResult(as *metalv1.AdoptedServer, err error) {
+	if as.Status.ManagementAPIStatus == nil {
+		as.Status.ManagementAPIStatus = &metalv1.ManagementAPIStatus{}
+	}
+
+	if err != nil {
+		markManagementAPISyncFailed(as, err)
+		as.Status.ManagementAPIStatus.Synced = false
+		as.Status.ManagementAPIStatus.Error = err.Error()
+
+		return
+	}
+
+	conditions.MarkTrue(as, metalv1.ConditionManagementAPISync)
+	as.Status.ManagementAPIStatus.Synced = true
+	as.Status.ManagementAPIStatus.Error = ""
+	syncTime := metav1.Now()
+	as.Status.ManagementAPIStatus.LastSyncTime = &syncTime
+}
+
+// managementAPISyncRequested returns true if a sync was requested after the last successful sync.
+//
+// The request and the sync times have a second resolution, a request in the same second as the sync is not skipped.
+func managementAPISyncRequested(as *metalv1.AdoptedServer) bool {
+	requested, err := time.Parse(time.RFC3339, as.Annotations[metalv1.SyncRequestedAnnotation])
+	if err != nil {
+		return false
+	}
+
+	if as.Status.ManagementAPIStatus == nil || as.Status.ManagementAPIStatus.LastSyncTime == nil {
+		return true
+	}
+
+	return !requested.Before(as.Status.ManagementAPIStatus.LastSyncTime.Truncate(time.Second))
+}
+
+// markManagementAPISyncFailed sets the ManagementAPISync condition reason from the sync error.
+func markManagementAPISyncFailed(as conditions.Setter, err error) {
+	switch {
+	case errors.Is(err, errManagementAPICredentials):
+		conditions.MarkFalse(as, metalv1.ConditionManagementAPISync, metalv1.ManagementAPICredentialsUnavailableReason, clusterv1.ConditionSeverityError, "%s", err.Error())
+	case errors.Is(err, managementapi.ErrUnauthorized):
+		conditions.MarkFalse(as, metalv1.ConditionManagementAPISync, metalv1.ManagementAPIAuthenticationFailedReason, clusterv1.ConditionSeverityError, "%s", err.Error())
+	case errors.Is(err, managementapi.ErrCircuitOpen):
+		conditions.MarkFalse(as, metalv1.ConditionManagementAPISync, metalv1.ManagementAPIUnavailableReason, clusterv1.ConditionSeverityWarning, "%s", err.Error())
+	default:
+		conditions.MarkFalse(as, metalv1.ConditionManagementAPISync, metalv1.ManagementAPISyncFailedReason, clusterv1.ConditionSeverityWarning, "%s", err.Error())
+	}
+}
+
 // unregisterFromManagementAPI removes the server from the Management API.
 func (r *AdoptedServerReconciler) unregisterFromManagementAPI(ctx context.Context, as *metalv1.AdoptedServer) error {
 	logger := log.FromContext(ctx)
 	logger.Info("Unregistering from Management API", "endpoint", as.Spec.ManagementAPI.Endpoint)
 
-	// Create Management API client
-	client, err := managementapi.NewClient(as.Spec.ManagementAPI.Endpoint)
+	// Get the pooled Management API client
+	client, err := r.managementAPIClient(ctx, as)
 	if err != nil {
 		logger.Error(err, "Failed to create Management API client during unregister")
 		return err
 	}
-	defer client.Close()
+
+	deleteCluster, err := r.lastInManagementAPICluster(ctx, as)
+	if err != nil {
+		return err
+	}
 
 	// Create sync service and unregister the adopted server
 	syncService := managementapi.NewSyncService(client, logger)
-	if err := syncService.UnregisterAdoptedServer(ctx, as); err != nil {
+	if err := syncService.UnregisterAdoptedServer(ctx, as, deleteCluster); err != nil {
 		logger.Error(err, "Failed to unregister adopted server from Management API")
 		return err
 	}
@@ -389,10 +739,128 @@ func (r *AdoptedServerReconciler) unregisterFromManagementAPI(ctx context.Contex
 	return nil
 }
 
+// registeredManagementAPICluster returns the ID of the cluster with the same name registered by another AdoptedServer, if any.
+func (r *AdoptedServerReconciler) registeredManagementAPICluster(ctx context.Context, as *metalv1.AdoptedServer) (string, error) {
+	var list metalv1.AdoptedServerList
+
+	if err := r.Client.List(ctx, &list); err != nil {
+		return "", errors.Wrap(err, "failed to list AdoptedServers")
+	}
+
+	for i := range list.Items {
+		other := &list.Items[i]
+
+		if other.Name == as.Name || other.Spec.ManagementAPI == nil || !other.Spec.ManagementAPI.Enabled {
+			continue
+		}
+
+		if other.Spec.ManagementAPI.Endpoint == as.Spec.ManagementAPI.Endpoint && other.Spec.ManagementAPI.ClusterName == as.Spec.ManagementAPI.ClusterName {
+			if clusterID := managementapi.ClusterID(other); clusterID != "" {
+				return clusterID, nil
+			}
+		}
+	}
+
+	return "", nil
+}
+
+// lastInManagementAPICluster returns true if no other AdoptedServer belongs to the same Management API cluster.
+//
+// AdoptedServers being deleted don't count, so that the cluster is deleted even if they are removed at the same time.
+func (r *AdoptedServerReconciler) lastInManagementAPICluster(ctx context.Context, as *metalv1.AdoptedServer) (bool, error) {
+	clusterID := managementapi.ClusterID(as)
+	if clusterID == "" {
+		return false, nil
+	}
+
+	var list metalv1.AdoptedServerList
+
+	if err := r.Client.List(ctx, &list); err != nil {
+		return false, errors.Wrap(err, "failed to list AdoptedServers")
+	}
+
+	for i := range list.Items {
+		other := &list.Items[i]
+
+		if other.Name == as.Name || !other.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		if other.Spec.ManagementAPI == nil || !other.Spec.ManagementAPI.Enabled || other.Spec.ManagementAPI.Endpoint != as.Spec.ManagementAPI.Endpoint {
+			continue
+		}
+
+		if managementapi.ClusterID(other) == clusterID {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
 // SetupWithManager sets up the controller with the Manager.
-func (r *AdoptedServerReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
-	return ctrl.NewControllerManagedBy(mgr).
+func (r *AdoptedServerReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, options controller.Options) error {
+	if err := mgr.GetFieldIndexer().IndexField(ctx, &metalv1.AdoptedServer{}, metalv1.AdoptedServerSecretRefField, func(rawObj client.Object) []string {
+		as := rawObj.(*metalv1.AdoptedServer)
+
+		refs := []*corev1.SecretReference{as.Spec.Talos.SecretRef}
+
+		if as.Spec.ManagementAPI != nil {
+			refs = append(refs, as.Spec.ManagementAPI.SecretRef)
+		}
+
+		var keys []string
+
+		for _, ref := range refs {
+			if ref == nil {
+				continue
+			}
+
+			namespace := ref.Namespace
+			if namespace == "" {
+				namespace = corev1.NamespaceDefault
+			}
+
+			keys = append(keys, namespace+"/"+ref.Name)
+		}
+
+		return keys
+	}); err != nil {
+		return err
+	}
+
+	builder := ctrl.NewControllerManagedBy(mgr).
 		WithOptions(options).
 		For(&metalv1.AdoptedServer{}).
-		Complete(r)
+		Watches(
+			&corev1.Secret{},
+			handler.EnqueueRequestsFromMapFunc(r.mapSecretToAdoptedServers),
+		)
+
+	if r.Notifications != nil {
+		builder = builder.WatchesRawSource(source.Channel(r.Notifications, &handler.EnqueueRequestForObject{}))
+	}
+
+	return builder.Complete(r)
+}
+
+// mapSecretToAdoptedServers reconciles the servers referencing the secret, so that rotated credentials are used right away.
+func (r *AdoptedServerReconciler) mapSecretToAdoptedServers(ctx context.Context, secret client.Object) []reconcile.Request {
+	var adoptedServers metalv1.AdoptedServerList
+
+	if err := r.List(ctx, &adoptedServers, client.MatchingFields{
+		metalv1.AdoptedServerSecretRefField: secret.GetNamespace() + "/" + secret.GetName(),
+	}); err != nil {
+		r.Log.Error(err, "failed to list adopted servers")
+
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(adoptedServers.Items))
+
+	for _, as := range adoptedServers.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: as.Name}})
+	}
+
+	return requests
 }
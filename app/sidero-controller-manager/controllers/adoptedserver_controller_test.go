@@ -0,0 +1,262 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package controllers_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/siderolabs/talos/pkg/machinery/api/machine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/protobuf/types/known/emptypb"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	metalv1 "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/controllers"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/internal/power/api"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/internal/talos"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/pkg/managementapi"
+	managementapifake "github.com/siderolabs/sidero/app/sidero-controller-manager/pkg/managementapi/fake"
+)
+
+// machineService is a stand-in for apid, it only reports the Talos version.
+type machineService struct {
+	machine.UnimplementedMachineServiceServer
+}
+
+func (machineService) Version(context.Context, *emptypb.Empty) (*machine.VersionResponse, error) {
+	return &machine.VersionResponse{
+		Messages: []*machine.Version{
+			{
+				Version: &machine.VersionInfo{
+					Tag:  "v1.11.5",
+					Arch: "amd64",
+				},
+			},
+		},
+	}, nil
+}
+
+// signedKeyPair returns a PEM encoded certificate and key signed by the parent, or self-signed if the parent is nil.
+func signedKeyPair(t *testing.T, template, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, []byte, []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return cert, key, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// talosAPI runs a Talos API stand-in requiring client certificates, and returns its endpoint and credentials.
+func talosAPI(t *testing.T) (string, *corev1.Secret) {
+	t.Helper()
+
+	ca, caKey, caPEM, _ := signedKeyPair(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{Organization: []string{"talos"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}, nil, nil)
+
+	leaf := func(serial int64, usage x509.ExtKeyUsage) ([]byte, []byte) {
+		_, _, certPEM, keyPEM := signedKeyPair(t, &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{Organization: []string{"os:admin"}},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+			IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		}, ca, caKey)
+
+		return certPEM, keyPEM
+	}
+
+	serverCertPEM, serverKeyPEM := leaf(2, x509.ExtKeyUsageServerAuth)
+	clientCertPEM, clientKeyPEM := leaf(3, x509.ExtKeyUsageClientAuth)
+
+	crt, err := tls.X509KeyPair(serverCertPEM, serverKeyPEM)
+	require.NoError(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{crt},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	})))
+
+	machine.RegisterMachineServiceServer(srv, machineService{})
+
+	go srv.Serve(lis) //nolint:errcheck
+
+	t.Cleanup(srv.Stop)
+
+	return lis.Addr().String(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: corev1.NamespaceDefault,
+			Name:      "talos-credentials",
+		},
+		Data: map[string][]byte{
+			talos.CAKey:   caPEM,
+			talos.CertKey: clientCertPEM,
+			talos.KeyKey:  clientKeyPEM,
+		},
+	}
+}
+
+func TestAdoptedServerManagementAPISync(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	require.NoError(t, metalv1.AddToScheme(scheme))
+
+	endpoint, secret := talosAPI(t)
+
+	srv := managementapifake.NewServer()
+	t.Cleanup(srv.Close)
+
+	as := &metalv1.AdoptedServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "node-1",
+		},
+		Spec: metalv1.AdoptedServerSpec{
+			Accepted: true,
+			Talos: metalv1.TalosConfig{
+				Endpoint: endpoint,
+				NodeType: "controlplane",
+				SecretRef: &corev1.SecretReference{
+					Namespace: secret.Namespace,
+					Name:      secret.Name,
+				},
+			},
+			ManagementAPI: &metalv1.ManagementAPIConfig{
+				Enabled:     true,
+				Endpoint:    srv.URL,
+				ClusterName: "cluster",
+			},
+		},
+	}
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(as, secret).
+		WithStatusSubresource(&metalv1.AdoptedServer{}).
+		Build()
+
+	pool := managementapi.NewPool(managementapi.WithRetryPolicy(managementapi.RetryPolicy{
+		MaxAttempts: 2,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    10 * time.Millisecond,
+	}))
+	t.Cleanup(pool.Close)
+
+	r := &controllers.AdoptedServerReconciler{
+		Client:               fakeClient,
+		Log:                  ctrl.Log,
+		Scheme:               scheme,
+		APIReader:            fakeClient,
+		Recorder:             record.NewFakeRecorder(100),
+		ManagementAPIClients: pool,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	t.Cleanup(cancel)
+
+	reconcile := func() *metalv1.AdoptedServer {
+		t.Helper()
+
+		_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: as.Name}})
+		require.NoError(t, err)
+
+		var reconciled metalv1.AdoptedServer
+
+		err = fakeClient.Get(ctx, types.NamespacedName{Name: as.Name}, &reconciled)
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+
+		require.NoError(t, err)
+
+		return &reconciled
+	}
+
+	// the Management API is unavailable until the retries are exhausted
+	srv.FailNext(api.ExplicitFailure, api.ExplicitFailure)
+
+	reconciled := reconcile()
+
+	require.True(t, reconciled.Status.Connected)
+	assert.Equal(t, metalv1.ManagementAPISyncFailedReason, conditions.GetReason(reconciled, metalv1.ConditionManagementAPISync))
+	require.NotNil(t, reconciled.Status.ManagementAPIStatus)
+	assert.False(t, reconciled.Status.ManagementAPIStatus.Synced)
+	assert.Empty(t, srv.Clusters())
+
+	// the next reconcile registers the server
+	reconciled = reconcile()
+
+	assert.True(t, conditions.IsTrue(reconciled, metalv1.ConditionManagementAPISync))
+	assert.True(t, reconciled.Status.ManagementAPIStatus.Synced)
+
+	clusters := srv.Clusters()
+	require.Len(t, clusters, 1)
+	assert.Equal(t, "cluster", clusters[0].Request.Name)
+	assert.Equal(t, clusters[0].ID, reconciled.Status.ManagementAPIStatus.ClusterID)
+
+	node, ok := srv.Node(reconciled.Status.ManagementAPIStatus.NodeID)
+	require.True(t, ok)
+	assert.Equal(t, "127.0.0.1", node.Request.IPAddress)
+	assert.Equal(t, "controlplane", node.Request.NodeType)
+
+	// deleting the last server of the cluster unregisters both
+	require.NoError(t, fakeClient.Delete(ctx, reconciled))
+
+	assert.Nil(t, reconcile())
+	assert.Empty(t, srv.Nodes())
+	assert.Empty(t, srv.Clusters())
+}
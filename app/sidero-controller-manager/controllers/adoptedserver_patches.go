@@ -0,0 +1,149 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/siderolabs/talos/pkg/machinery/api/machine"
+	talosclient "github.com/siderolabs/talos/pkg/machinery/client"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	metalv1 "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/internal/metadata"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/internal/talos"
+)
+
+// reconcileConfigPatches applies the machine configuration patches to the node.
+//
+// Patches are applied on top of the running machine configuration, so they are applied only once:
+// the hash of the applied patches is recorded in the status, and the patches are re-applied only when the hash changes.
+// In dry-run mode the patched configuration is validated by the node, and the reported changes are recorded in the status.
+func (r *AdoptedServerReconciler) reconcileConfigPatches(ctx context.Context, as *metalv1.AdoptedServer, asRef *corev1.ObjectReference, c *talosclient.Client) error {
+	logger := log.FromContext(ctx)
+
+	if len(as.Spec.ConfigPatches) == 0 && len(as.Spec.StrategicPatches) == 0 {
+		conditions.Delete(as, metalv1.ConditionConfigPatched)
+
+		return nil
+	}
+
+	// the node reboots during the upgrade, wait for it to complete
+	if as.Status.Upgrade != nil && as.Status.Upgrade.Phase == metalv1.UpgradePhaseInProgress {
+		return nil
+	}
+
+	hash, err := patchesHash(as)
+	if err != nil {
+		return err
+	}
+
+	if as.Status.ConfigPatches == nil {
+		as.Status.ConfigPatches = &metalv1.ConfigPatchStatus{}
+	}
+
+	status := as.Status.ConfigPatches
+	dryRun := as.Spec.ConfigApplyMode == metalv1.ConfigApplyModeDryRun
+
+	switch {
+	case dryRun && status.DryRunHash == hash:
+		return nil
+	case !dryRun && status.AppliedHash == hash:
+		conditions.MarkTrue(as, metalv1.ConditionConfigPatched)
+
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, talosAPITimeout)
+	defer cancel()
+
+	current, err := talos.ReadConfig(ctx, c)
+	if err != nil {
+		return errors.Wrap(err, "failed to read machine config")
+	}
+
+	patched, err := metadata.ApplyPatches(current, as.Spec.ConfigPatches, as.Spec.StrategicPatches)
+	if err != nil {
+		return errors.Wrap(err, "failed to patch machine config")
+	}
+
+	if !dryRun && bytes.Equal(current, patched) {
+		logger.Info("Machine config already patched")
+
+		status.AppliedHash = hash
+		conditions.MarkTrue(as, metalv1.ConditionConfigPatched)
+
+		return nil
+	}
+
+	mode := machine.ApplyConfigurationRequest_AUTO
+	if as.Spec.ConfigApplyMode == metalv1.ConfigApplyModeNoReboot {
+		mode = machine.ApplyConfigurationRequest_NO_REBOOT
+	}
+
+	details, err := talos.ApplyConfig(ctx, c, patched, mode, dryRun)
+	if err != nil {
+		return errors.Wrap(err, "failed to apply machine config")
+	}
+
+	status.Details = details
+
+	if dryRun {
+		logger.Info("Validated machine config patches", "details", details)
+
+		status.DryRunHash = hash
+
+		conditions.MarkFalse(as, metalv1.ConditionConfigPatched, metalv1.ConfigPatchDryRunReason, clusterv1.ConditionSeverityInfo, "Patches were validated in dry-run mode")
+		r.Recorder.Event(asRef, corev1.EventTypeNormal, "ConfigPatchDryRun", fmt.Sprintf("Validated machine config patches: %s", details))
+
+		return nil
+	}
+
+	appliedMode := as.Spec.ConfigApplyMode
+	if appliedMode == "" {
+		appliedMode = metalv1.ConfigApplyModeAuto
+	}
+
+	logger.Info("Applied machine config patches", "mode", appliedMode, "details", details)
+
+	now := metav1.Now()
+
+	status.AppliedHash = hash
+	status.AppliedMode = appliedMode
+	status.LastAppliedTime = &now
+
+	conditions.MarkTrue(as, metalv1.ConditionConfigPatched)
+	r.Recorder.Event(asRef, corev1.EventTypeNormal, "ConfigPatched", "Applied machine config patches")
+
+	return nil
+}
+
+// patchesHash returns the hash of the configuration patches in the spec.
+func patchesHash(as *metalv1.AdoptedServer) (string, error) {
+	data, err := json.Marshal(struct {
+		ConfigPatches    []metalv1.ConfigPatches `json:"configPatches,omitempty"`
+		StrategicPatches []string                `json:"strategicPatches,omitempty"`
+	}{
+		ConfigPatches:    as.Spec.ConfigPatches,
+		StrategicPatches: as.Spec.StrategicPatches,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal config patches")
+	}
+
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,297 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/siderolabs/go-pointer"
+	talosclient "github.com/siderolabs/talos/pkg/machinery/client"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	infrav1 "github.com/siderolabs/sidero/app/caps-controller-manager/api/v1alpha3"
+	metalv1 "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/internal/talos"
+)
+
+// promote imports the adopted node into an existing CAPI cluster.
+//
+// The Server is named after the node SMBIOS UUID, so that it matches the UUID the node reports over SideroLink and iPXE.
+// The ServerBinding is created with Talos installation conditions already set, so that the Server is marked as PXE booted
+// and never gets reprovisioned, and the Machine bootstrap data is the machine configuration currently running on the node.
+//
+// Every step is idempotent, objects which already exist are left untouched.
+func (r *AdoptedServerReconciler) promote(ctx context.Context, as *metalv1.AdoptedServer, asRef *corev1.ObjectReference, c *talosclient.Client) error {
+	logger := log.FromContext(ctx)
+
+	if as.Status.NodeInfo == nil || as.Status.NodeInfo.UUID == "" {
+		return errors.New("node UUID is not known yet")
+	}
+
+	promotion := as.Spec.Promotion
+
+	namespace := promotion.ClusterRef.Namespace
+	if namespace == "" {
+		namespace = corev1.NamespaceDefault
+	}
+
+	var cluster clusterv1.Cluster
+
+	if err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: promotion.ClusterRef.Name}, &cluster); err != nil {
+		return errors.Wrapf(err, "failed to get cluster %s/%s", namespace, promotion.ClusterRef.Name)
+	}
+
+	machineName := promotion.MachineName
+	if machineName == "" {
+		machineName = as.Name
+	}
+
+	serverName := strings.ToLower(as.Status.NodeInfo.UUID)
+
+	labels := map[string]string{
+		clusterv1.ClusterNameLabel: cluster.Name,
+	}
+
+	if as.Spec.Talos.NodeType == "controlplane" {
+		labels[clusterv1.MachineControlPlaneLabel] = ""
+	}
+
+	server, err := r.ensurePromotedServer(ctx, as, serverName)
+	if err != nil {
+		return err
+	}
+
+	bootstrapSecretName := machineName + "-bootstrap-data"
+
+	if err = r.ensureBootstrapSecret(ctx, c, types.NamespacedName{Namespace: namespace, Name: bootstrapSecretName}, labels); err != nil {
+		return err
+	}
+
+	metalMachine := &infrav1.MetalMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      machineName,
+			Labels:    labels,
+		},
+		Spec: infrav1.MetalMachineSpec{
+			ServerRef: &corev1.ObjectReference{
+				Kind: "Server",
+				Name: server.Name,
+			},
+		},
+	}
+
+	if err = r.createIfMissing(ctx, metalMachine); err != nil {
+		return errors.Wrap(err, "failed to create metal machine")
+	}
+
+	if err = r.ensurePromotedServerBinding(ctx, as, server, metalMachine, labels); err != nil {
+		return err
+	}
+
+	machine := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      machineName,
+			Labels:    labels,
+		},
+		Spec: clusterv1.MachineSpec{
+			ClusterName: cluster.Name,
+			Bootstrap: clusterv1.Bootstrap{
+				DataSecretName: pointer.To(bootstrapSecretName),
+			},
+			InfrastructureRef: corev1.ObjectReference{
+				APIVersion: infrav1.GroupVersion.String(),
+				Kind:       "MetalMachine",
+				Namespace:  namespace,
+				Name:       machineName,
+			},
+		},
+	}
+
+	if as.Status.NodeInfo.KubernetesVersion != "" {
+		machine.Spec.Version = pointer.To(as.Status.NodeInfo.KubernetesVersion)
+	}
+
+	if err = r.createIfMissing(ctx, machine); err != nil {
+		return errors.Wrap(err, "failed to create machine")
+	}
+
+	promoted := conditions.IsTrue(as, metalv1.ConditionPromoted)
+
+	as.Status.Promotion = &metalv1.PromotionStatus{
+		ServerRef: &corev1.ObjectReference{
+			Kind: "Server",
+			Name: server.Name,
+		},
+		MachineRef: &corev1.ObjectReference{
+			APIVersion: clusterv1.GroupVersion.String(),
+			Kind:       "Machine",
+			Namespace:  namespace,
+			Name:       machineName,
+		},
+	}
+
+	conditions.MarkTrue(as, metalv1.ConditionPromoted)
+
+	if !promoted {
+		logger.Info("Promoted AdoptedServer", "server", server.Name, "machine", machineName, "cluster", cluster.Name)
+		r.Recorder.Event(asRef, corev1.EventTypeNormal, "Promoted", fmt.Sprintf("Server %q imported into cluster %s/%s as machine %q.", server.Name, namespace, cluster.Name, machineName))
+	}
+
+	return nil
+}
+
+// ensurePromotedServer creates the Server for the adopted node, or verifies that the existing one was created by promotion.
+func (r *AdoptedServerReconciler) ensurePromotedServer(ctx context.Context, as *metalv1.AdoptedServer, name string) (*metalv1.Server, error) {
+	var server metalv1.Server
+
+	err := r.Get(ctx, types.NamespacedName{Name: name}, &server)
+	if err == nil {
+		if server.Annotations[metalv1.PromotedFromAnnotation] != as.Name {
+			return nil, errors.Errorf("server %q already exists and is not managed by this AdoptedServer", name)
+		}
+
+		return &server, nil
+	}
+
+	if !apierrors.IsNotFound(err) {
+		return nil, errors.Wrapf(err, "failed to get server %q", name)
+	}
+
+	server = metalv1.Server{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: as.Spec.Labels,
+			Annotations: map[string]string{
+				metalv1.PromotedFromAnnotation: as.Name,
+			},
+		},
+		Spec: metalv1.ServerSpec{
+			Hostname: as.Status.NodeInfo.Hostname,
+			Hardware: &metalv1.HardwareInformation{
+				System: &metalv1.SystemInformation{
+					Uuid: name,
+				},
+			},
+			Accepted: true,
+		},
+	}
+
+	if err = r.Create(ctx, &server); err != nil {
+		return nil, errors.Wrapf(err, "failed to create server %q", name)
+	}
+
+	return &server, nil
+}
+
+// ensureBootstrapSecret stores the machine configuration running on the node as the Machine bootstrap data.
+func (r *AdoptedServerReconciler) ensureBootstrapSecret(ctx context.Context, c *talosclient.Client, name types.NamespacedName, labels map[string]string) error {
+	var secret corev1.Secret
+
+	err := r.APIReader.Get(ctx, name, &secret)
+	if err == nil {
+		return nil
+	}
+
+	if !apierrors.IsNotFound(err) {
+		return errors.Wrap(err, "failed to get bootstrap secret")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, talosAPITimeout)
+	defer cancel()
+
+	config, err := talos.ReadConfig(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	secret = corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: name.Namespace,
+			Name:      name.Name,
+			Labels:    labels,
+		},
+		Type: clusterv1.ClusterSecretType,
+		Data: map[string][]byte{
+			"value": config,
+		},
+	}
+
+	return errors.Wrap(r.createIfMissing(ctx, &secret), "failed to create bootstrap secret")
+}
+
+// ensurePromotedServerBinding binds the Server to the MetalMachine, and marks Talos as already installed.
+func (r *AdoptedServerReconciler) ensurePromotedServerBinding(ctx context.Context, as *metalv1.AdoptedServer, server *metalv1.Server, metalMachine *infrav1.MetalMachine, labels map[string]string) error {
+	var serverBinding infrav1.ServerBinding
+
+	err := r.Get(ctx, types.NamespacedName{Name: server.Name}, &serverBinding)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return errors.Wrap(err, "failed to get server binding")
+		}
+
+		if metalMachine.UID == "" {
+			if err = r.Get(ctx, client.ObjectKeyFromObject(metalMachine), metalMachine); err != nil {
+				return errors.Wrap(err, "failed to get metal machine")
+			}
+		}
+
+		serverBinding = infrav1.ServerBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   server.Name,
+				Labels: labels,
+			},
+			Spec: infrav1.ServerBindingSpec{
+				ServerClassRef: as.Spec.Promotion.ServerClassRef.DeepCopy(),
+				MetalMachineRef: corev1.ObjectReference{
+					Kind:      "MetalMachine",
+					UID:       metalMachine.UID,
+					Namespace: metalMachine.Namespace,
+					Name:      metalMachine.Name,
+				},
+				Hostname: as.Status.NodeInfo.Hostname,
+			},
+		}
+
+		for _, address := range as.Status.Addresses {
+			if address.Type == corev1.NodeInternalIP {
+				serverBinding.Spec.Addresses = append(serverBinding.Spec.Addresses, address.Address)
+			}
+		}
+
+		if err = r.Create(ctx, &serverBinding); err != nil {
+			return errors.Wrap(err, "failed to create server binding")
+		}
+	}
+
+	if conditions.IsTrue(&serverBinding, infrav1.TalosInstalledCondition) {
+		return nil
+	}
+
+	conditions.MarkTrue(&serverBinding, infrav1.TalosConfigLoadedCondition)
+	conditions.MarkTrue(&serverBinding, infrav1.TalosConfigValidatedCondition)
+	conditions.MarkTrue(&serverBinding, infrav1.TalosInstalledCondition)
+
+	return errors.Wrap(r.Status().Update(ctx, &serverBinding), "failed to update server binding status")
+}
+
+func (r *AdoptedServerReconciler) createIfMissing(ctx context.Context, obj client.Object) error {
+	if err := r.Create(ctx, obj); err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	return nil
+}
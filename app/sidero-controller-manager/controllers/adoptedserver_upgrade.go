@@ -0,0 +1,284 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	talosclient "github.com/siderolabs/talos/pkg/machinery/client"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	metalv1 "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/internal/talos"
+)
+
+// upgradeTimeout is the time the node has to come back healthy with the new version after the upgrade is started.
+const upgradeTimeout = 30 * time.Minute
+
+// checkVersionDrift compares the Talos and Kubernetes versions in the spec with the versions running on the node.
+//
+// Versions which are not set in the spec, or not reported by the node are not compared.
+func (r *AdoptedServerReconciler) checkVersionDrift(as *metalv1.AdoptedServer, asRef *corev1.ObjectReference) {
+	if as.Status.NodeInfo == nil {
+		return
+	}
+
+	var drift []string
+
+	for _, version := range []struct {
+		name              string
+		expected, running string
+	}{
+		{"Talos", as.Spec.Talos.TalosVersion, as.Status.NodeInfo.TalosVersion},
+		{"Kubernetes", as.Spec.Talos.KubernetesVersion, as.Status.NodeInfo.KubernetesVersion},
+	} {
+		if version.expected == "" || version.running == "" {
+			continue
+		}
+
+		if !talos.VersionsEqual(version.expected, version.running) {
+			drift = append(drift, fmt.Sprintf("%s %s is running, %s is expected", version.name, version.running, version.expected))
+		}
+	}
+
+	if len(drift) == 0 {
+		conditions.MarkFalse(as, metalv1.ConditionVersionDrift, metalv1.VersionsMatchReason, clusterv1.ConditionSeverityInfo, "Running versions match the spec")
+
+		return
+	}
+
+	message := strings.Join(drift, ", ")
+
+	if !conditions.IsTrue(as, metalv1.ConditionVersionDrift) {
+		r.Recorder.Event(asRef, corev1.EventTypeWarning, metalv1.VersionMismatchReason, message)
+	}
+
+	conditions.Set(as, &clusterv1.Condition{
+		Type:     metalv1.ConditionVersionDrift,
+		Status:   corev1.ConditionTrue,
+		Reason:   metalv1.VersionMismatchReason,
+		Severity: clusterv1.ConditionSeverityWarning,
+		Message:  message,
+	})
+}
+
+// reconcileUpgrade upgrades the node to the desired Talos version.
+//
+// The upgrade is started once per target version and installer image, a failed upgrade is not retried until either changes.
+// The upgrade is complete once the node runs the desired version and passes the health checks.
+// Control plane nodes of the same cluster are upgraded one at a time, in the order of their names.
+func (r *AdoptedServerReconciler) reconcileUpgrade(ctx context.Context, as *metalv1.AdoptedServer, asRef *corev1.ObjectReference, c *talosclient.Client) error {
+	logger := log.FromContext(ctx)
+
+	desired := as.Spec.Talos.DesiredTalosVersion
+	if desired == "" {
+		conditions.Delete(as, metalv1.ConditionUpgraded)
+
+		return nil
+	}
+
+	if as.Status.NodeInfo == nil {
+		return errors.New("running Talos version is not known yet")
+	}
+
+	image := upgradeImage(as)
+	running := as.Status.NodeInfo.TalosVersion
+
+	if upgrade := as.Status.Upgrade; upgrade != nil && upgrade.TargetVersion == desired && upgrade.Image == image {
+		switch upgrade.Phase {
+		case metalv1.UpgradePhaseInProgress:
+			r.checkUpgrade(as, asRef)
+
+			return nil
+		case metalv1.UpgradePhaseCompleted:
+			conditions.MarkTrue(as, metalv1.ConditionUpgraded)
+
+			return nil
+		case metalv1.UpgradePhaseFailed:
+			conditions.MarkFalse(as, metalv1.ConditionUpgraded, metalv1.UpgradeFailedReason, clusterv1.ConditionSeverityWarning, "%s", upgrade.Error)
+
+			return nil
+		}
+	}
+
+	if talos.VersionsEqual(desired, running) {
+		conditions.MarkTrue(as, metalv1.ConditionUpgraded)
+
+		return nil
+	}
+
+	blocker, err := r.upgradeBlocker(ctx, as)
+	if err != nil {
+		return err
+	}
+
+	if blocker != "" {
+		logger.Info("Waiting for another control plane node to be upgraded", "node", blocker)
+		conditions.MarkFalse(as, metalv1.ConditionUpgraded, metalv1.UpgradeWaitingForLockReason, clusterv1.ConditionSeverityInfo, "Waiting for control plane node %q to be upgraded", blocker)
+
+		return nil
+	}
+
+	logger.Info("Upgrading Talos", "from", running, "to", desired, "image", image)
+
+	upgradeCtx, cancel := context.WithTimeout(ctx, talosAPITimeout)
+	defer cancel()
+
+	if err = talos.Upgrade(upgradeCtx, c, image); err != nil {
+		return errors.Wrap(err, "failed to start upgrade")
+	}
+
+	now := metav1.Now()
+
+	as.Status.Upgrade = &metalv1.UpgradeStatus{
+		Phase:         metalv1.UpgradePhaseInProgress,
+		TargetVersion: desired,
+		Image:         image,
+		StartTime:     &now,
+	}
+
+	conditions.MarkFalse(as, metalv1.ConditionUpgraded, metalv1.UpgradeInProgressReason, clusterv1.ConditionSeverityInfo, "Upgrading from %s to %s", running, desired)
+	r.Recorder.Event(asRef, corev1.EventTypeNormal, "UpgradeStarted", fmt.Sprintf("Upgrading Talos from %s to %s with %s", running, desired, image))
+
+	return nil
+}
+
+// checkUpgrade waits for the node to come back with the new version and pass the health checks.
+func (r *AdoptedServerReconciler) checkUpgrade(as *metalv1.AdoptedServer, asRef *corev1.ObjectReference) {
+	upgrade := as.Status.Upgrade
+
+	var waitingFor string
+
+	switch {
+	case !talos.VersionsEqual(upgrade.TargetVersion, as.Status.NodeInfo.TalosVersion):
+		waitingFor = fmt.Sprintf("node is running %s", as.Status.NodeInfo.TalosVersion)
+	case as.Status.Health == nil || (as.Status.Health.Status != metalv1.HealthStatusHealthy && as.Status.Health.Status != metalv1.HealthStatusDegraded):
+		waitingFor = "node is not healthy"
+	default:
+		now := metav1.Now()
+
+		upgrade.Phase = metalv1.UpgradePhaseCompleted
+		upgrade.CompletionTime = &now
+		upgrade.Error = ""
+
+		as.Spec.Talos.TalosVersion = upgrade.TargetVersion
+
+		conditions.MarkTrue(as, metalv1.ConditionUpgraded)
+		r.Recorder.Event(asRef, corev1.EventTypeNormal, "UpgradeCompleted", fmt.Sprintf("Upgraded Talos to %s", upgrade.TargetVersion))
+
+		return
+	}
+
+	r.waitForUpgrade(as, asRef, waitingFor)
+}
+
+// checkUnreachableUpgrade fails the upgrade in progress once its deadline passes while the node can't be reached.
+//
+// The node is expected to be unreachable while it reboots into the new version, but a node which never comes back
+// would otherwise keep the upgrade in progress, and block the upgrades of the other control plane nodes.
+func (r *AdoptedServerReconciler) checkUnreachableUpgrade(as *metalv1.AdoptedServer, asRef *corev1.ObjectReference) {
+	if as.Status.Upgrade == nil || as.Status.Upgrade.Phase != metalv1.UpgradePhaseInProgress {
+		return
+	}
+
+	r.waitForUpgrade(as, asRef, "node is not reachable")
+}
+
+// waitForUpgrade marks the upgrade in progress as failed if it didn't complete before the upgrade timeout.
+func (r *AdoptedServerReconciler) waitForUpgrade(as *metalv1.AdoptedServer, asRef *corev1.ObjectReference, waitingFor string) {
+	upgrade := as.Status.Upgrade
+
+	if upgrade.StartTime != nil && time.Since(upgrade.StartTime.Time) > upgradeTimeout {
+		upgrade.Phase = metalv1.UpgradePhaseFailed
+		upgrade.Error = fmt.Sprintf("upgrade to %s didn't complete in %s: %s", upgrade.TargetVersion, upgradeTimeout, waitingFor)
+
+		conditions.MarkFalse(as, metalv1.ConditionUpgraded, metalv1.UpgradeFailedReason, clusterv1.ConditionSeverityWarning, "%s", upgrade.Error)
+		r.Recorder.Event(asRef, corev1.EventTypeWarning, metalv1.UpgradeFailedReason, upgrade.Error)
+
+		return
+	}
+
+	conditions.MarkFalse(as, metalv1.ConditionUpgraded, metalv1.UpgradeInProgressReason, clusterv1.ConditionSeverityInfo, "Upgrading to %s: %s", upgrade.TargetVersion, waitingFor)
+}
+
+// upgradeBlocker returns the name of the control plane node which should be upgraded before this one.
+//
+// A control plane node waits for any node of the same group which is being upgraded, and for the nodes which
+// precede it by name and still need an upgrade, so a failed upgrade halts the rollout.
+func (r *AdoptedServerReconciler) upgradeBlocker(ctx context.Context, as *metalv1.AdoptedServer) (string, error) {
+	if as.Spec.Talos.NodeType != "controlplane" {
+		return "", nil
+	}
+
+	group := upgradeGroup(as)
+	if group == "" {
+		return "", nil
+	}
+
+	var adoptedServers metalv1.AdoptedServerList
+
+	if err := r.APIReader.List(ctx, &adoptedServers); err != nil {
+		return "", errors.Wrap(err, "failed to list adopted servers")
+	}
+
+	for _, other := range adoptedServers.Items {
+		if other.Name == as.Name || !other.Spec.Accepted || other.Spec.Talos.NodeType != "controlplane" || upgradeGroup(&other) != group {
+			continue
+		}
+
+		if other.Status.Upgrade != nil && other.Status.Upgrade.Phase == metalv1.UpgradePhaseInProgress {
+			return other.Name, nil
+		}
+
+		if other.Name < as.Name && needsUpgrade(&other) {
+			return other.Name, nil
+		}
+	}
+
+	return "", nil
+}
+
+// upgradeGroup returns the name of the cluster the node belongs to.
+func upgradeGroup(as *metalv1.AdoptedServer) string {
+	if as.Spec.ManagementAPI != nil && as.Spec.ManagementAPI.ClusterName != "" {
+		return as.Spec.ManagementAPI.ClusterName
+	}
+
+	if group := as.Labels[metalv1.UpgradeGroupLabel]; group != "" {
+		return group
+	}
+
+	if group := as.Spec.Labels[metalv1.UpgradeGroupLabel]; group != "" {
+		return group
+	}
+
+	if as.Status.NodeInfo != nil {
+		return as.Status.NodeInfo.ClusterName
+	}
+
+	return ""
+}
+
+func needsUpgrade(as *metalv1.AdoptedServer) bool {
+	desired := as.Spec.Talos.DesiredTalosVersion
+
+	return desired != "" && as.Status.NodeInfo != nil && !talos.VersionsEqual(desired, as.Status.NodeInfo.TalosVersion)
+}
+
+func upgradeImage(as *metalv1.AdoptedServer) string {
+	if as.Spec.Talos.InstallerImage != "" {
+		return as.Spec.Talos.InstallerImage
+	}
+
+	return talos.InstallerImage(as.Spec.Talos.DesiredTalosVersion)
+}
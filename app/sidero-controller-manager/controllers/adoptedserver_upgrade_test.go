@@ -0,0 +1,181 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package controllers_test
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	metalv1 "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/controllers"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/internal/talos"
+)
+
+// credentialsSecret returns Talos API credentials signed by a self-signed CA.
+func credentialsSecret(t *testing.T) *corev1.Secret {
+	t.Helper()
+
+	_, _, certPEM, keyPEM := signedKeyPair(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{Organization: []string{"os:admin"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+	}, nil, nil)
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: corev1.NamespaceDefault,
+			Name:      "talos-credentials",
+		},
+		Data: map[string][]byte{
+			talos.CAKey:   certPEM,
+			talos.CertKey: certPEM,
+			talos.KeyKey:  keyPEM,
+		},
+	}
+}
+
+// closedEndpoint returns a local endpoint nothing listens on.
+func closedEndpoint(t *testing.T) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	endpoint := l.Addr().String()
+
+	require.NoError(t, l.Close())
+
+	return endpoint
+}
+
+func TestAdoptedServerUpgradeUnreachable(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	require.NoError(t, metalv1.AddToScheme(scheme))
+
+	for _, test := range []struct {
+		name           string
+		secret         bool
+		startedAgo     time.Duration
+		expectedReason string
+		expected       string
+		expectedErr    string
+	}{
+		{
+			name:           "probe fails within the timeout",
+			secret:         true,
+			startedAgo:     time.Minute,
+			expectedReason: metalv1.TalosUnreachableReason,
+			expected:       metalv1.UpgradePhaseInProgress,
+		},
+		{
+			name:           "probe fails after the timeout",
+			secret:         true,
+			startedAgo:     time.Hour,
+			expectedReason: metalv1.TalosUnreachableReason,
+			expected:       metalv1.UpgradePhaseFailed,
+			expectedErr:    "upgrade to v1.11.5 didn't complete in 30m0s: node is not reachable",
+		},
+		{
+			name:           "credentials unavailable after the timeout",
+			startedAgo:     time.Hour,
+			expectedReason: metalv1.TalosCredentialsUnavailableReason,
+			expected:       metalv1.UpgradePhaseFailed,
+			expectedErr:    "upgrade to v1.11.5 didn't complete in 30m0s: node is not reachable",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			started := metav1.NewTime(time.Now().Add(-test.startedAgo))
+
+			as := &metalv1.AdoptedServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "node-1",
+					Finalizers: []string{"metal.sidero.dev/adopted-server"},
+				},
+				Spec: metalv1.AdoptedServerSpec{
+					Accepted: true,
+					Talos: metalv1.TalosConfig{
+						Endpoint:            closedEndpoint(t),
+						DesiredTalosVersion: "v1.11.5",
+						NodeType:            "controlplane",
+						SecretRef: &corev1.SecretReference{
+							Namespace: corev1.NamespaceDefault,
+							Name:      "talos-credentials",
+						},
+					},
+				},
+				Status: metalv1.AdoptedServerStatus{
+					Upgrade: &metalv1.UpgradeStatus{
+						Phase:         metalv1.UpgradePhaseInProgress,
+						TargetVersion: "v1.11.5",
+						Image:         "ghcr.io/siderolabs/installer:v1.11.5",
+						StartTime:     &started,
+					},
+				},
+			}
+
+			objects := []client.Object{as}
+			if test.secret {
+				objects = append(objects, credentialsSecret(t))
+			}
+
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(objects...).
+				WithStatusSubresource(&metalv1.AdoptedServer{}).
+				Build()
+
+			r := &controllers.AdoptedServerReconciler{
+				Client:    fakeClient,
+				Log:       ctrl.Log,
+				Scheme:    scheme,
+				APIReader: fakeClient,
+				Recorder:  record.NewFakeRecorder(100),
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			t.Cleanup(cancel)
+
+			_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: as.Name}})
+			require.NoError(t, err)
+
+			var reconciled metalv1.AdoptedServer
+
+			require.NoError(t, fakeClient.Get(ctx, types.NamespacedName{Name: as.Name}, &reconciled))
+
+			assert.False(t, reconciled.Status.Connected)
+			assert.Equal(t, test.expectedReason, conditions.GetReason(&reconciled, metalv1.ConditionConnected))
+			require.NotNil(t, reconciled.Status.Upgrade)
+			assert.Equal(t, test.expected, reconciled.Status.Upgrade.Phase)
+			assert.Equal(t, test.expectedErr, reconciled.Status.Upgrade.Error)
+		})
+	}
+}
@@ -0,0 +1,356 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	talosclient "github.com/siderolabs/talos/pkg/machinery/client"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/tools/reference"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+
+	metalv1 "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/internal/talos"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/pkg/constants"
+)
+
+const (
+	defaultScanInterval = time.Hour
+
+	// machineIDLabel is set on the AdoptedServers created by the scan, and holds the machine ID of the node.
+	machineIDLabel = "metal.sidero.dev/machine-id"
+)
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// errInvalidScanSpec is returned when the AdoptionScan spec can't be used for the scan.
+var errInvalidScanSpec = errors.New("invalid scan spec")
+
+// AdoptionScanReconciler reconciles an AdoptionScan object.
+type AdoptionScanReconciler struct {
+	client.Client
+	Log       logr.Logger
+	Scheme    *runtime.Scheme
+	APIReader client.Reader
+	Recorder  record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=adoptionscans,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=adoptionscans/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=adoptedservers,verbs=get;list;watch;create
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+func (r *AdoptionScanReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, err error) {
+	logger := r.Log.WithValues("adoptionscan", req.NamespacedName)
+
+	scan := &metalv1.AdoptionScan{}
+	if err = r.APIReader.Get(ctx, req.NamespacedName, scan); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	interval := defaultScanInterval
+	if scan.Spec.Interval != nil && scan.Spec.Interval.Duration > 0 {
+		interval = scan.Spec.Interval.Duration
+	}
+
+	// rescan only when the spec changes, or the interval elapses
+	if scan.Status.ObservedGeneration == scan.Generation && scan.Status.LastScanTime != nil {
+		if remaining := interval - time.Since(scan.Status.LastScanTime.Time); remaining > 0 {
+			return ctrl.Result{RequeueAfter: remaining}, nil
+		}
+	}
+
+	patchHelper, err := patch.NewHelper(scan, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	defer func() {
+		if e := patchHelper.Patch(ctx, scan, patch.WithOwnedConditions{
+			Conditions: []clusterv1.ConditionType{metalv1.ConditionScanSucceeded},
+		}); e != nil {
+			logger.Error(e, "failed to patch AdoptionScan")
+
+			if err == nil {
+				err = e
+			}
+		}
+	}()
+
+	scanRef, err := reference.GetReference(r.Scheme, scan)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	result, err := r.scan(ctx, scan)
+	if err != nil {
+		logger.Error(err, "scan failed")
+
+		if errors.Is(err, errInvalidScanSpec) {
+			conditions.MarkFalse(scan, metalv1.ConditionScanSucceeded, metalv1.ScanInvalidSpecReason, clusterv1.ConditionSeverityError, "%s", err.Error())
+			scan.Status.ObservedGeneration = scan.Generation
+
+			// wait for the spec to be fixed
+			return ctrl.Result{}, nil
+		}
+
+		conditions.MarkFalse(scan, metalv1.ConditionScanSucceeded, metalv1.ScanFailedReason, clusterv1.ConditionSeverityWarning, "%s", err.Error())
+		r.Recorder.Event(scanRef, corev1.EventTypeWarning, metalv1.ScanFailedReason, fmt.Sprintf("Scan failed: %s", err))
+
+		return ctrl.Result{RequeueAfter: constants.DefaultRequeueAfter}, nil
+	}
+
+	nodes, err := r.adoptNodes(ctx, scan, scanRef, result.Nodes)
+	if err != nil {
+		conditions.MarkFalse(scan, metalv1.ConditionScanSucceeded, metalv1.ScanFailedReason, clusterv1.ConditionSeverityWarning, "%s", err.Error())
+
+		return ctrl.Result{}, err
+	}
+
+	unreachable := make([]metalv1.UnreachableEndpoint, 0, len(result.Unreachable))
+
+	for _, endpoint := range result.Unreachable {
+		unreachable = append(unreachable, metalv1.UnreachableEndpoint{
+			Endpoint: endpoint.Endpoint,
+			Error:    endpoint.Error,
+		})
+	}
+
+	now := metav1.Now()
+
+	scan.Status.Nodes = nodes
+	scan.Status.Unreachable = unreachable
+	scan.Status.LastScanTime = &now
+	scan.Status.ObservedGeneration = scan.Generation
+
+	conditions.Set(scan, &clusterv1.Condition{
+		Type:    metalv1.ConditionScanSucceeded,
+		Status:  corev1.ConditionTrue,
+		Message: fmt.Sprintf("Found %d nodes, %d endpoints unreachable", len(nodes), len(unreachable)),
+	})
+
+	logger.Info("scan completed", "nodes", len(nodes), "unreachable", len(unreachable))
+
+	return ctrl.Result{RequeueAfter: interval}, nil
+}
+
+// scan discovers Talos nodes either via the seed endpoint, or by probing the CIDRs.
+func (r *AdoptionScanReconciler) scan(ctx context.Context, scan *metalv1.AdoptionScan) (talos.DiscoveryResult, error) {
+	port := talos.DefaultPort
+	if scan.Spec.Port != 0 {
+		port = int(scan.Spec.Port)
+	}
+
+	var prefixes []netip.Prefix
+
+	for _, cidr := range scan.Spec.CIDRs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return talos.DiscoveryResult{}, errors.Wrapf(errInvalidScanSpec, "failed to parse CIDR %q: %s", cidr, err)
+		}
+
+		prefixes = append(prefixes, prefix)
+	}
+
+	if len(prefixes) == 0 && scan.Spec.SeedEndpoint == "" {
+		return talos.DiscoveryResult{}, errors.Wrap(errInvalidScanSpec, "either cidrs or seedEndpoint should be set")
+	}
+
+	secret, err := getSecret(ctx, r.Client, &scan.Spec.TalosSecretRef)
+	if err != nil {
+		return talos.DiscoveryResult{}, err
+	}
+
+	dial := func(ctx context.Context, endpoint string) (*talosclient.Client, error) {
+		return talos.NewClient(ctx, endpoint, secret)
+	}
+
+	var result talos.DiscoveryResult
+
+	if scan.Spec.SeedEndpoint != "" {
+		seed, err := metalv1.NormalizeTalosEndpoint(scan.Spec.SeedEndpoint)
+		if err != nil {
+			return talos.DiscoveryResult{}, errors.Wrap(errInvalidScanSpec, err.Error())
+		}
+
+		if result, err = talos.DiscoverMembers(ctx, dial, seed, port, talosAPITimeout); err != nil {
+			return talos.DiscoveryResult{}, err
+		}
+	}
+
+	if len(prefixes) > 0 {
+		scanned, err := talos.DiscoverCIDRs(ctx, dial, prefixes, port, talosAPITimeout)
+		if err != nil {
+			return talos.DiscoveryResult{}, errors.Wrap(errInvalidScanSpec, err.Error())
+		}
+
+		result.Nodes = append(result.Nodes, scanned.Nodes...)
+		result.Unreachable = append(result.Unreachable, scanned.Unreachable...)
+	}
+
+	return result, nil
+}
+
+// adoptNodes creates AdoptedServers for the discovered nodes which are not represented by any AdoptedServer yet.
+//
+// Nodes are matched to the existing AdoptedServers by the machine ID, or by the Talos API endpoint.
+// New AdoptedServers are not accepted, so that the operator can review them before Sidero starts managing the nodes.
+func (r *AdoptionScanReconciler) adoptNodes(ctx context.Context, scan *metalv1.AdoptionScan, scanRef *corev1.ObjectReference, discovered []talos.DiscoveredNode) ([]metalv1.DiscoveredNode, error) {
+	var adoptedServers metalv1.AdoptedServerList
+
+	if err := r.APIReader.List(ctx, &adoptedServers); err != nil {
+		return nil, errors.Wrap(err, "failed to list adopted servers")
+	}
+
+	var (
+		byMachineID = map[string]string{}
+		byEndpoint  = map[string]string{}
+		names       = map[string]struct{}{}
+	)
+
+	for _, as := range adoptedServers.Items {
+		names[as.Name] = struct{}{}
+
+		if machineID := as.Labels[machineIDLabel]; machineID != "" {
+			byMachineID[machineID] = as.Name
+		}
+
+		if as.Status.NodeInfo != nil && as.Status.NodeInfo.MachineID != "" {
+			byMachineID[as.Status.NodeInfo.MachineID] = as.Name
+		}
+
+		if endpoint, err := metalv1.NormalizeTalosEndpoint(as.Spec.Talos.Endpoint); err == nil {
+			byEndpoint[endpoint] = as.Name
+		}
+	}
+
+	nodes := make([]metalv1.DiscoveredNode, 0, len(discovered))
+	seen := map[string]struct{}{}
+
+	for _, node := range discovered {
+		// nodes might be found both via the seed and the CIDRs
+		if _, ok := seen[node.MachineID]; ok {
+			continue
+		}
+
+		seen[node.MachineID] = struct{}{}
+
+		status := metalv1.DiscoveredNode{
+			Endpoint:     node.Endpoint,
+			MachineID:    node.MachineID,
+			Hostname:     node.Hostname,
+			NodeType:     node.MachineType,
+			TalosVersion: node.TalosVersion,
+		}
+
+		name, ok := byMachineID[node.MachineID]
+		if !ok {
+			name, ok = byEndpoint[node.Endpoint]
+		}
+
+		if !ok {
+			name = adoptedServerName(node, names)
+
+			if err := r.createAdoptedServer(ctx, scan, name, node); err != nil {
+				return nil, err
+			}
+
+			r.Recorder.Event(scanRef, corev1.EventTypeNormal, "AdoptedServerCreated", fmt.Sprintf("Created AdoptedServer %q for node %s.", name, node.Endpoint))
+
+			names[name] = struct{}{}
+		}
+
+		status.AdoptedServer = name
+
+		nodes = append(nodes, status)
+	}
+
+	return nodes, nil
+}
+
+func (r *AdoptionScanReconciler) createAdoptedServer(ctx context.Context, scan *metalv1.AdoptionScan, name string, node talos.DiscoveredNode) error {
+	labels := map[string]string{
+		metalv1.AdoptionScanLabel: scan.Name,
+	}
+
+	if node.MachineID != "" && len(validation.IsValidLabelValue(node.MachineID)) == 0 {
+		labels[machineIDLabel] = node.MachineID
+	}
+
+	as := &metalv1.AdoptedServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: labels,
+		},
+		Spec: metalv1.AdoptedServerSpec{
+			Talos: metalv1.TalosConfig{
+				Endpoint:     node.Endpoint,
+				TalosVersion: node.TalosVersion,
+				Hostname:     node.Hostname,
+				SecretRef:    scan.Spec.TalosSecretRef.DeepCopy(),
+			},
+			Labels:   scan.Spec.Labels,
+			Accepted: false,
+		},
+	}
+
+	switch node.MachineType {
+	case "controlplane", "worker":
+		as.Spec.Talos.NodeType = node.MachineType
+	}
+
+	if err := r.Create(ctx, as); err != nil && !apierrors.IsAlreadyExists(err) {
+		return errors.Wrapf(err, "failed to create adopted server %q", name)
+	}
+
+	return nil
+}
+
+// adoptedServerName derives the AdoptedServer name from the node hostname, falling back to the machine ID.
+func adoptedServerName(node talos.DiscoveredNode, taken map[string]struct{}) string {
+	suffix := invalidNameChars.ReplaceAllString(strings.ToLower(node.MachineID), "")
+	if len(suffix) > 8 {
+		suffix = suffix[:8]
+	}
+
+	name := strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(node.Hostname), "-"), "-.")
+
+	if name == "" || len(validation.IsDNS1123Subdomain(name)) > 0 {
+		return "talos-" + suffix
+	}
+
+	if _, ok := taken[name]; ok {
+		return name + "-" + suffix
+	}
+
+	return name
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *AdoptionScanReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		WithOptions(options).
+		For(&metalv1.AdoptionScan{}).
+		Complete(r)
+}
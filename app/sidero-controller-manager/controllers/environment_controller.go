@@ -6,14 +6,16 @@ package controllers
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
 	"sync"
-	"time"
 
 	"github.com/go-logr/logr"
 	multierror "github.com/hashicorp/go-multierror"
@@ -25,9 +27,14 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 
 	metalv1 "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/internal/assets"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/internal/imagefactory"
 	"github.com/siderolabs/sidero/app/sidero-controller-manager/pkg/constants"
 )
 
+// errExtractUKI is returned when the UKI can't be extracted from the boot asset.
+var errExtractUKI = errors.New("error extracting UKI")
+
 // EnvironmentReconciler reconciles a Environment object.
 type EnvironmentReconciler struct {
 	client.Client
@@ -36,6 +43,14 @@ type EnvironmentReconciler struct {
 	TalosRelease string
 	APIEndpoint  string
 	APIPort      uint16
+
+	// AssetMirror is the default asset mirror of the air-gapped Environments, all Environments are air-gapped if set.
+	AssetMirror string
+	// AllowedAssetHosts are the hosts air-gapped Environments can download assets from besides the asset mirror.
+	AllowedAssetHosts []string
+
+	// AssetsDir is the directory the Environment assets are saved to, defaults to /var/lib/sidero/env.
+	AssetsDir string
 }
 
 // +kubebuilder:rbac:groups=metal.sidero.dev,resources=environments,verbs=get;list;watch;create;update;patch;delete
@@ -63,7 +78,20 @@ func (r *EnvironmentReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
-	envs := filepath.Join("/var/lib/sidero/env", env.GetName())
+	if env.Spec.Schematic != nil {
+		if err := r.resolveSchematic(ctx, &env); err != nil {
+			return ctrl.Result{}, fmt.Errorf("error resolving schematic: %w", err)
+		}
+	} else {
+		env.Status.Schematic = nil
+	}
+
+	assetsDir := r.AssetsDir
+	if assetsDir == "" {
+		assetsDir = "/var/lib/sidero/env"
+	}
+
+	envs := filepath.Join(assetsDir, env.GetName())
 
 	if _, err := os.Stat(envs); os.IsNotExist(err) {
 		if err = os.MkdirAll(envs, 0o777); err != nil {
@@ -84,54 +112,81 @@ func (r *EnvironmentReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		Asset    metalv1.Asset
 	}{}
 
+	bootAsset, kernel, initrd := env.BootAssets()
+
 	// Add BootAsset if specified (preferred for Talos 1.10+)
-	if env.Spec.BootAsset != nil && env.Spec.BootAsset.URL != "" {
+	if bootAsset.URL != "" {
 		assetTasks = append(assetTasks, struct {
 			BaseName string
 			Asset    metalv1.Asset
 		}{
-			BaseName: "boot.raw.xz", // Boot asset filename
-			Asset: metalv1.Asset{
-				URL:    env.Spec.BootAsset.URL,
-				SHA512: env.Spec.BootAsset.SHA512,
-			},
+			BaseName: constants.BootAsset,
+			Asset:    bootAsset,
 		})
 	}
 
 	// Add legacy Kernel/Initrd assets (fallback or explicit preference)
-	if env.Spec.Kernel.URL != "" {
+	if kernel.URL != "" {
 		assetTasks = append(assetTasks, struct {
 			BaseName string
 			Asset    metalv1.Asset
 		}{
 			BaseName: constants.KernelAsset,
-			Asset:    env.Spec.Kernel.Asset,
+			Asset:    kernel,
 		})
 	}
 
-	if env.Spec.Initrd.URL != "" {
+	if initrd.URL != "" {
 		assetTasks = append(assetTasks, struct {
 			BaseName string
 			Asset    metalv1.Asset
 		}{
 			BaseName: constants.InitrdAsset,
-			Asset:    env.Spec.Initrd.Asset,
+			Asset:    initrd,
+		})
+	}
+
+	// Add the air-gap image cache, served with the other assets
+	if imageCache, ok := env.ImageCacheAsset(); ok {
+		assetTasks = append(assetTasks, struct {
+			BaseName string
+			Asset    metalv1.Asset
+		}{
+			BaseName: constants.ImageCacheAsset,
+			Asset:    imageCache,
 		})
 	}
 
+	mirror := r.mirror(&env)
+
 	for _, assetTask := range assetTasks {
 		file := filepath.Join(envs, assetTask.BaseName)
 
-		setReady := func(ready bool) {
-			status := "False"
-			if ready {
-				status = "True"
-			}
+		// source is the asset the file is downloaded from, which is on the asset mirror if mirrorURL is set
+		source := assetTask.Asset
+		mirrorURL := ""
 
+		setReady := func(err error) {
 			condition := metalv1.AssetCondition{
-				Asset:  assetTask.Asset,
-				Status: status,
-				Type:   "Ready",
+				Asset:     assetTask.Asset,
+				Status:    "True",
+				Type:      "Ready",
+				MirrorURL: mirrorURL,
+			}
+
+			if err != nil {
+				condition.Status = "False"
+				condition.Reason = metalv1.AssetDownloadFailedReason
+				condition.Message = err.Error()
+
+				switch {
+				case errors.Is(err, assets.ErrChecksumMismatch):
+					condition.Reason = metalv1.AssetChecksumMismatchReason
+				case errors.Is(err, errExtractUKI):
+					condition.Reason = metalv1.AssetExtractionFailedReason
+				case errors.Is(err, assets.ErrHostNotAllowed):
+					condition.Reason = metalv1.AssetHostNotAllowedReason
+				}
 			}
 
 			mu.Lock()
@@ -145,138 +200,274 @@ func (r *EnvironmentReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			go func() {
 				defer wg.Done()
 
-				if err := save(ctx, assetTask.Asset, file); err != nil {
-					setReady(false)
+				var err error
+
+				if mirror != nil {
+					err = mirror.Download(ctx, source, file)
+				} else {
+					err = assets.Download(ctx, source, file)
+				}
+
+				if err == nil && assetTask.BaseName == constants.BootAsset {
+					err = extractUKI(l, file, filepath.Join(envs, constants.UKIAsset))
+				}
 
+				if err != nil {
+					mu.Lock()
 					result = multierror.Append(result, fmt.Errorf("error saving %q: %w", assetTask.Asset.URL, err))
+					mu.Unlock()
+				} else {
+					l.Info("saved asset", "url", source.URL)
 				}
 
-				setReady(true)
-				l.Info("saved asset", "url", assetTask.Asset.URL)
+				setReady(err)
 			}()
 		}
 
-		if _, err := os.Stat(file); os.IsNotExist(err) {
-			l.Info("saving asset", "url", assetTask.Asset.URL)
+		if mirror != nil {
+			resolved, onMirror, err := mirror.Resolve(assetTask.Asset.URL)
+			if err != nil {
+				mu.Lock()
+				result = multierror.Append(result, fmt.Errorf("error saving %q: %w", assetTask.Asset.URL, err))
+				mu.Unlock()
+
+				setReady(err)
+
+				continue
+			}
+
+			source.URL = resolved
+
+			if onMirror {
+				mirrorURL = resolved
+			}
+		}
+
+		if !assetExists(file, assetTask.BaseName, envs) {
+			l.Info("saving asset", "url", source.URL)
 			saveAsset(file)
 
 			continue
 		}
 
 		// If we reach here, the file derived from the URL exists, and now we need
-		// to update it if the URL has changed.
+		// to update it if the URL or the checksum has changed.
 
 		l.Info("checking if update required", "file", file)
 
 		ready := false
 
 		for _, condition := range env.Status.Conditions {
-			if assetTask.Asset.URL == condition.URL {
+			if assetTask.Asset == condition.Asset && condition.MirrorURL == mirrorURL && condition.Status == "True" {
 				ready = true
 			}
 		}
 
 		if ready {
 			l.Info("update not required", "file", file)
-			setReady(true)
+			setReady(nil)
 
 			continue
 		}
 
 		l.Info("update required", "file", file)
 
-		// At this point the file exists, but the URL for the file has changed. We
-		// need to update the file using the new URL.
+		// At this point the file exists, but the URL (or the mirror) for the file has changed, or
+		// the previous download failed. We need to update the file using the new URL.
 
-		l.Info("updating asset", "url", assetTask.Asset.URL)
+		l.Info("updating asset", "url", source.URL)
 		saveAsset(file)
 	}
 
 	wg.Wait()
 
-	if result.ErrorOrNil() != nil {
-		return ctrl.Result{}, result.ErrorOrNil()
-	}
-
+	// failed assets are recorded in the status before retrying
 	env.Status.Conditions = conditions
+	env.Status.BootPath = metalv1.BootPathKernelInitrd
+
+	if bootAsset.URL != "" {
+		for _, condition := range conditions {
+			if condition.Asset == bootAsset && condition.Status == "True" {
+				env.Status.BootPath = metalv1.BootPathUKI
+			}
+		}
+	}
 
 	if err := r.Status().Update(ctx, &env); err != nil {
 		return ctrl.Result{}, err
 	}
 
+	if result.ErrorOrNil() != nil {
+		return ctrl.Result{}, result.ErrorOrNil()
+	}
+
 	return ctrl.Result{}, nil
 }
 
-// ReconcileEnvironmentDefault ensures that Environment "default" exist.
-func ReconcileEnvironmentDefault(ctx context.Context, c client.Client, talosRelease, apiEndpoint string, apiPort uint16) error {
-	key := types.NamespacedName{
-		Name: metalv1.EnvironmentDefault,
-	}
+// resolveSchematic creates the Image Factory schematic of the Environment, and derives its boot assets from it.
+//
+// The derived assets are recorded in the status, the schematic is not created again until its inputs change.
+func (r *EnvironmentReconciler) resolveSchematic(ctx context.Context, env *metalv1.Environment) error {
+	schematic := env.Spec.Schematic
 
-	env := metalv1.Environment{}
-	err := c.Get(ctx, key, &env)
+	endpoint, registry, insecureSkipVerify := imagefactory.DefaultEndpoint, "", false
 
-	if apierrors.IsNotFound(err) {
-		env.Name = metalv1.EnvironmentDefault
-		env.Spec = *metalv1.EnvironmentDefaultSpec(talosRelease, apiEndpoint, apiPort)
+	switch {
+	case env.Spec.AirGap != nil && env.Spec.AirGap.LocalImageFactory != nil:
+		factory := env.Spec.AirGap.LocalImageFactory
 
-		err = c.Create(ctx, &env)
+		endpoint, registry, insecureSkipVerify = factory.Endpoint, factory.Registry, factory.InsecureSkipVerify
+	case r.mirror(env) != nil:
+		return errors.New("air-gapped environments require a local Image Factory")
 	}
 
-	return err
-}
+	customization := imagefactory.Customization{
+		ExtraKernelArgs: schematic.KernelArgs,
+		SystemExtensions: imagefactory.SystemExtensions{
+			OfficialExtensions: schematic.Extensions,
+		},
+	}
 
-func (r *EnvironmentReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, options controller.Options) error {
-	if r.TalosRelease == "" {
-		return errors.New("TalosRelease is not set")
+	for _, meta := range schematic.Meta {
+		customization.Meta = append(customization.Meta, imagefactory.MetaValue{Key: meta.Key, Value: meta.Value})
 	}
 
-	return ctrl.NewControllerManagedBy(mgr).
-		WithOptions(options).
-		For(&metalv1.Environment{}).
-		Complete(r)
-}
+	talosVersion := schematic.TalosVersion
+	if talosVersion == "" {
+		talosVersion = r.TalosRelease
+	}
 
-func save(ctx context.Context, asset metalv1.Asset, file string) error {
-	url := asset.URL
+	arch := schematic.Arch
+	if arch == "" {
+		arch = "amd64"
+	}
 
-	if url == "" {
-		return errors.New("missing URL")
+	inputs, err := json.Marshal(struct {
+		Endpoint      string                     `json:"endpoint"`
+		Registry      string                     `json:"registry"`
+		Customization imagefactory.Customization `json:"customization"`
+		TalosVersion  string                     `json:"talosVersion"`
+		Arch          string                     `json:"arch"`
+	}{endpoint, registry, customization, talosVersion, arch})
+	if err != nil {
+		return err
 	}
 
-	requestContext, cancel := context.WithTimeout(ctx, 5*time.Minute)
-	defer cancel()
+	inputsHash := sha256.Sum256(inputs)
 
-	req, err := http.NewRequestWithContext(requestContext, http.MethodGet, url, nil)
+	if env.Status.Schematic != nil && env.Status.Schematic.InputsHash == hex.EncodeToString(inputsHash[:]) {
+		return nil
+	}
+
+	factory, err := imagefactory.NewClient(endpoint, registry, insecureSkipVerify)
 	if err != nil {
 		return err
 	}
 
-	client := &http.Client{}
-
-	resp, err := client.Do(req)
+	id, err := factory.CreateSchematic(ctx, imagefactory.Schematic{Customization: customization})
 	if err != nil {
 		return err
 	}
 
-	defer resp.Body.Close()
+	derived := factory.Assets(id, talosVersion, arch)
+
+	env.Status.Schematic = &metalv1.SchematicStatus{
+		ID:             id,
+		Factory:        endpoint,
+		TalosVersion:   talosVersion,
+		UKIURL:         derived.UKI,
+		InstallerImage: derived.Installer,
+		BootAssetURL:   derived.BootAsset,
+		KernelURL:      derived.Kernel,
+		InitrdURL:      derived.Initrd,
+		InputsHash:     hex.EncodeToString(inputsHash[:]),
+	}
+
+	r.Log.Info("derived boot assets from schematic", "environment", env.Name, "schematic", id)
+
+	return nil
+}
+
+// mirror returns the asset mirror of the Environment, or nil if it's not air-gapped.
+func (r *EnvironmentReconciler) mirror(env *metalv1.Environment) *assets.Mirror {
+	airGap := env.Spec.AirGap
+
+	if r.AssetMirror == "" && (airGap == nil || !airGap.Enabled) {
+		return nil
+	}
+
+	mirror := &assets.Mirror{
+		URL:          r.AssetMirror,
+		AllowedHosts: r.AllowedAssetHosts,
+	}
+
+	if airGap != nil && airGap.AssetMirror != "" {
+		mirror.URL = airGap.AssetMirror
+	}
 
-	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
-		w, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY, 0o666)
-		if err != nil {
-			return err
+	// the boot assets derived from the schematic are downloaded from the local Image Factory
+	if airGap != nil && airGap.LocalImageFactory != nil {
+		if factory, err := url.Parse(airGap.LocalImageFactory.Endpoint); err == nil && factory.Host != "" {
+			mirror.AllowedHosts = append(slices.Clone(mirror.AllowedHosts), factory.Host)
 		}
+	}
 
-		defer w.Close()
+	return mirror
+}
 
-		r := resp.Body
+// assetExists checks whether the asset was saved, the boot asset is saved once its UKI is extracted as well.
+func assetExists(file, baseName, envs string) bool {
+	if _, err := os.Stat(file); err != nil {
+		return false
+	}
 
-		if _, err := io.Copy(w, r); err != nil {
-			return err
+	if baseName == constants.BootAsset {
+		if _, err := os.Stat(filepath.Join(envs, constants.UKIAsset)); err != nil {
+			return false
 		}
-	} else {
-		return fmt.Errorf("failed to download asset: %d", resp.StatusCode)
 	}
 
+	return true
+}
+
+// extractUKI extracts the UKI from the boot asset, so that UEFI servers can chain-load it over HTTP.
+func extractUKI(l logr.Logger, bootAsset, uki string) error {
+	name, err := assets.ExtractUKI(bootAsset, uki)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errExtractUKI, err)
+	}
+
+	l.Info("extracted UKI", "name", name)
+
 	return nil
 }
+
+// ReconcileEnvironmentDefault ensures that Environment "default" exist.
+func ReconcileEnvironmentDefault(ctx context.Context, c client.Client, talosRelease, apiEndpoint string, apiPort uint16) error {
+	key := types.NamespacedName{
+		Name: metalv1.EnvironmentDefault,
+	}
+
+	env := metalv1.Environment{}
+	err := c.Get(ctx, key, &env)
+
+	if apierrors.IsNotFound(err) {
+		env.Name = metalv1.EnvironmentDefault
+		env.Spec = *metalv1.EnvironmentDefaultSpec(talosRelease, apiEndpoint, apiPort)
+
+		err = c.Create(ctx, &env)
+	}
+
+	return err
+}
+
+func (r *EnvironmentReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, options controller.Options) error {
+	if r.TalosRelease == "" {
+		return errors.New("TalosRelease is not set")
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		WithOptions(options).
+		For(&metalv1.Environment{}).
+		Complete(r)
+}
@@ -0,0 +1,159 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package controllers_test
+
+import (
+	"context"
+	"crypto/sha512"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	metalv1 "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/controllers"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/pkg/constants"
+)
+
+// imageFactory is a stand-in for a local Image Factory, it serves the kernel and initrd of the schematic.
+func imageFactory(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var created atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/schematics":
+			created.Add(1)
+
+			w.Write([]byte(`{"id":"abcd"}`)) //nolint:errcheck
+		case r.Method == http.MethodGet && r.URL.Path == "/image/abcd/v1.11.5/kernel-amd64":
+			w.Write([]byte("kernel")) //nolint:errcheck
+		case r.Method == http.MethodGet && r.URL.Path == "/image/abcd/v1.11.5/initramfs-amd64.xz":
+			w.Write([]byte("initrd")) //nolint:errcheck
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	t.Cleanup(srv.Close)
+
+	return srv, &created
+}
+
+func TestEnvironmentLocalImageFactory(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, metalv1.AddToScheme(scheme))
+
+	factory, created := imageFactory(t)
+
+	kernelSHA512 := sha512.Sum512([]byte("kernel"))
+
+	env := &metalv1.Environment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "airgap",
+		},
+		Spec: metalv1.EnvironmentSpec{
+			Schematic: &metalv1.Schematic{
+				TalosVersion: "v1.11.5",
+				Extensions:   []string{"siderolabs/iscsi-tools"},
+			},
+			// the checksum applies to the kernel derived from the schematic
+			Kernel: metalv1.Kernel{
+				Asset: metalv1.Asset{SHA512: hex.EncodeToString(kernelSHA512[:])},
+			},
+			AirGap: &metalv1.AirGapConfig{
+				Enabled:     true,
+				AssetMirror: "https://artifacts.local/talos",
+				LocalImageFactory: &metalv1.LocalImageFactory{
+					Endpoint: factory.URL,
+					Registry: "registry.local:5000",
+				},
+			},
+		},
+	}
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(env).
+		WithStatusSubresource(&metalv1.Environment{}).
+		Build()
+
+	assetsDir := t.TempDir()
+
+	r := &controllers.EnvironmentReconciler{
+		Client:       fakeClient,
+		Log:          ctrl.Log,
+		Scheme:       scheme,
+		TalosRelease: "v1.11.5",
+		AssetsDir:    assetsDir,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	t.Cleanup(cancel)
+
+	// the factory doesn't serve the boot asset
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: env.Name}})
+	require.Error(t, err)
+
+	var reconciled metalv1.Environment
+
+	require.NoError(t, fakeClient.Get(ctx, types.NamespacedName{Name: env.Name}, &reconciled))
+
+	// the derived assets are recorded in the status, the spec is kept as is
+	assert.Equal(t, env.Spec, reconciled.Spec)
+
+	image := factory.URL + "/image/abcd/v1.11.5/"
+
+	require.NotNil(t, reconciled.Status.Schematic)
+	assert.Equal(t, "abcd", reconciled.Status.Schematic.ID)
+	assert.Equal(t, image+"metal-amd64.raw.xz", reconciled.Status.Schematic.BootAssetURL)
+	assert.Equal(t, image+"kernel-amd64", reconciled.Status.Schematic.KernelURL)
+	assert.Equal(t, image+"initramfs-amd64.xz", reconciled.Status.Schematic.InitrdURL)
+	assert.Equal(t, "registry.local:5000/installer/abcd:v1.11.5", reconciled.Status.Schematic.InstallerImage)
+
+	conditions := map[string]metalv1.AssetCondition{}
+
+	for _, condition := range reconciled.Status.Conditions {
+		conditions[condition.URL] = condition
+	}
+
+	// the local Image Factory assets are downloaded from it, not refused or rewritten onto the mirror
+	require.Contains(t, conditions, image+"kernel-amd64")
+	assert.Equal(t, "True", conditions[image+"kernel-amd64"].Status)
+	assert.Equal(t, env.Spec.Kernel.SHA512, conditions[image+"kernel-amd64"].SHA512)
+	assert.Empty(t, conditions[image+"kernel-amd64"].MirrorURL)
+
+	require.Contains(t, conditions, image+"initramfs-amd64.xz")
+	assert.Equal(t, "True", conditions[image+"initramfs-amd64.xz"].Status)
+
+	require.Contains(t, conditions, image+"metal-amd64.raw.xz")
+	assert.Equal(t, metalv1.AssetDownloadFailedReason, conditions[image+"metal-amd64.raw.xz"].Reason)
+
+	kernel, err := os.ReadFile(filepath.Join(assetsDir, env.Name, constants.KernelAsset))
+	require.NoError(t, err)
+
+	assert.Equal(t, "kernel", string(kernel))
+
+	// the schematic is created once, until its inputs change
+	_, err = r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: env.Name}})
+	require.Error(t, err)
+
+	assert.EqualValues(t, 1, created.Load())
+}
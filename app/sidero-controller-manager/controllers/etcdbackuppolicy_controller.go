@@ -0,0 +1,729 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
+	talosclient "github.com/siderolabs/talos/pkg/machinery/client"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/tools/reference"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+
+	infrav1 "github.com/siderolabs/sidero/app/caps-controller-manager/api/v1alpha3"
+	metalv1 "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/internal/backup"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/internal/talos"
+)
+
+const (
+	defaultBackupRetention = 7
+	etcdSnapshotTimeout    = 10 * time.Minute
+
+	// the failed snapshots are retried with an exponential backoff, until the next schedule.
+	backupRetryBaseDelay = time.Minute
+	backupRetryMaxDelay  = time.Hour
+
+	etcdRestorePollInterval = 10 * time.Second
+)
+
+// errInvalidBackupPolicy is returned when the EtcdBackupPolicy spec can't be used for the backup.
+var errInvalidBackupPolicy = errors.New("invalid backup policy")
+
+// EtcdBackupPolicyReconciler reconciles an EtcdBackupPolicy object.
+type EtcdBackupPolicyReconciler struct {
+	client.Client
+	Log       logr.Logger
+	Scheme    *runtime.Scheme
+	APIReader client.Reader
+	Recorder  record.EventRecorder
+
+	// BackupDir is the root directory of the local sinks, local sinks are disabled if it's empty.
+	BackupDir string
+
+	restoresMu sync.Mutex
+	restores   map[string]*etcdRestore
+}
+
+// etcdRestore is a restore running in the background, so that it doesn't block the reconcile.
+type etcdRestore struct {
+	snapshot string
+	target   corev1.ObjectReference
+
+	// done is closed once the restore completes, err is set before.
+	done chan struct{}
+	err  error
+}
+
+// backupTarget is a control plane node the etcd snapshot can be taken from.
+type backupTarget struct {
+	ref       corev1.ObjectReference
+	endpoint  string
+	secretRef corev1.SecretReference
+}
+
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=etcdbackuppolicies,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=etcdbackuppolicies/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=adoptedservers,verbs=get;list;watch
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=serverbindings,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Reconcile takes the scheduled etcd snapshots, and runs the requested restore.
+//
+// Missed schedules are not caught up: if the controller was down, a single snapshot is taken on start.
+func (r *EtcdBackupPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, err error) {
+	logger := r.Log.WithValues("etcdbackuppolicy", req.NamespacedName)
+
+	policy := &metalv1.EtcdBackupPolicy{}
+	if err = r.APIReader.Get(ctx, req.NamespacedName, policy); err != nil {
+		if apierrors.IsNotFound(err) {
+			r.untrackRestore(req.Name)
+		}
+
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	patchHelper, err := patch.NewHelper(policy, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	defer func() {
+		if e := patchHelper.Patch(ctx, policy, patch.WithOwnedConditions{
+			Conditions: []clusterv1.ConditionType{metalv1.ConditionBackupSucceeded},
+		}); e != nil {
+			logger.Error(e, "failed to patch EtcdBackupPolicy")
+
+			if err == nil {
+				err = e
+			}
+		}
+	}()
+
+	policyRef, err := reference.GetReference(r.Scheme, policy)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	schedule, err := cron.ParseStandard(policy.Spec.Schedule)
+	if err != nil {
+		err = errors.Wrapf(errInvalidBackupPolicy, "failed to parse schedule %q: %s", policy.Spec.Schedule, err)
+	}
+
+	var sink backup.Sink
+
+	if err == nil {
+		sink, err = r.sink(ctx, policy)
+	}
+
+	if err != nil {
+		if errors.Is(err, errInvalidBackupPolicy) {
+			conditions.MarkFalse(policy, metalv1.ConditionBackupSucceeded, metalv1.BackupInvalidPolicyReason, clusterv1.ConditionSeverityError, "%s", err.Error())
+			policy.Status.NextScheduleTime = nil
+
+			// wait for the spec to be fixed
+			return ctrl.Result{}, nil
+		}
+
+		return ctrl.Result{}, err
+	}
+
+	restoreRequeue, err := r.reconcileRestore(ctx, policy, policyRef, patchHelper, sink)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if policy.Spec.Suspend {
+		policy.Status.NextScheduleTime = nil
+		policy.Status.NextRetryTime = nil
+
+		return ctrl.Result{RequeueAfter: restoreRequeue}, nil
+	}
+
+	now := time.Now()
+
+	last := policy.CreationTimestamp.Time
+	if policy.Status.LastScheduleTime != nil {
+		last = policy.Status.LastScheduleTime.Time
+	}
+
+	next := schedule.Next(now)
+
+	switch {
+	case !now.Before(schedule.Next(last)):
+		policy.Status.LastScheduleTime = &metav1.Time{Time: now}
+
+		r.backup(ctx, policy, policyRef, sink, now, false)
+		scheduleBackupRetry(policy, now, next)
+	case policy.Status.NextRetryTime != nil && !now.Before(policy.Status.NextRetryTime.Time):
+		r.backup(ctx, policy, policyRef, sink, now, true)
+		scheduleBackupRetry(policy, now, next)
+	}
+
+	policy.Status.NextScheduleTime = &metav1.Time{Time: next}
+
+	requeueAfter := next.Sub(now)
+
+	if policy.Status.NextRetryTime != nil {
+		requeueAfter = min(requeueAfter, policy.Status.NextRetryTime.Sub(now))
+	}
+
+	if restoreRequeue > 0 {
+		requeueAfter = min(requeueAfter, restoreRequeue)
+	}
+
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// scheduleBackupRetry retries the failed snapshots with an exponential backoff, if it's before the next schedule.
+func scheduleBackupRetry(policy *metalv1.EtcdBackupPolicy, now, next time.Time) {
+	if conditions.GetReason(policy, metalv1.ConditionBackupSucceeded) != metalv1.BackupFailedReason {
+		policy.Status.FailedAttempts = 0
+		policy.Status.NextRetryTime = nil
+
+		return
+	}
+
+	policy.Status.FailedAttempts++
+
+	delay := min(backupRetryBaseDelay<<min(policy.Status.FailedAttempts-1, 10), backupRetryMaxDelay)
+
+	if retry := now.Add(delay); retry.Before(next) {
+		policy.Status.NextRetryTime = &metav1.Time{Time: retry}
+	} else {
+		policy.Status.NextRetryTime = nil
+	}
+}
+
+// backup takes a snapshot of each selected cluster, and removes the snapshots beyond the retention.
+//
+// The snapshot of a cluster is taken from the first control plane node which succeeds,
+// so a single unavailable node doesn't fail the backup.
+// A retry takes the snapshots of the clusters which have none since the last schedule only.
+func (r *EtcdBackupPolicyReconciler) backup(ctx context.Context, policy *metalv1.EtcdBackupPolicy, policyRef *corev1.ObjectReference, sink backup.Sink, now time.Time, retry bool) {
+	logger := r.Log.WithValues("etcdbackuppolicy", policy.Name)
+
+	targets, err := r.backupTargets(ctx, policy)
+	if err != nil {
+		conditions.MarkFalse(policy, metalv1.ConditionBackupSucceeded, metalv1.BackupFailedReason, clusterv1.ConditionSeverityWarning, "%s", err.Error())
+
+		return
+	}
+
+	if len(targets) == 0 {
+		conditions.MarkFalse(policy, metalv1.ConditionBackupSucceeded, metalv1.BackupNoTargetsReason, clusterv1.ConditionSeverityWarning, "No control plane nodes match the selectors")
+
+		return
+	}
+
+	retention := int(policy.Spec.Retention)
+	if retention <= 0 {
+		retention = defaultBackupRetention
+	}
+
+	clusters := make([]string, 0, len(targets))
+
+	for cluster := range targets {
+		clusters = append(clusters, cluster)
+	}
+
+	slices.Sort(clusters)
+
+	previous := map[string]metalv1.EtcdClusterBackupStatus{}
+
+	for _, status := range policy.Status.Clusters {
+		previous[status.Cluster] = status
+	}
+
+	var failed []string
+
+	statuses := make([]metalv1.EtcdClusterBackupStatus, 0, len(clusters))
+
+	for _, cluster := range clusters {
+		status := previous[cluster]
+		status.Cluster = cluster
+
+		if retry && status.LastSuccessTime != nil && !status.LastSuccessTime.Before(policy.Status.LastScheduleTime) {
+			statuses = append(statuses, status)
+
+			continue
+		}
+
+		node, snapshots, err := r.backupCluster(ctx, cluster, targets[cluster], sink, retention, now)
+		if err != nil {
+			logger.Error(err, "etcd snapshot failed", "cluster", cluster)
+			r.Recorder.Event(policyRef, corev1.EventTypeWarning, metalv1.BackupFailedReason, fmt.Sprintf("Snapshot of cluster %q failed: %s", cluster, err))
+
+			failed = append(failed, cluster)
+			status.Error = err.Error()
+		} else {
+			logger.Info("took etcd snapshot", "cluster", cluster, "node", node, "snapshot", snapshots[0].Name)
+
+			status.Node = node
+			status.Error = ""
+			status.LastSuccessTime = &metav1.Time{Time: now}
+			status.Snapshots = snapshots
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	policy.Status.Clusters = statuses
+
+	if len(failed) > 0 {
+		conditions.MarkFalse(policy, metalv1.ConditionBackupSucceeded, metalv1.BackupFailedReason, clusterv1.ConditionSeverityWarning,
+			"Snapshots failed for clusters: %s", strings.Join(failed, ", "))
+
+		return
+	}
+
+	policy.Status.LastSuccessTime = &metav1.Time{Time: now}
+	conditions.MarkTrue(policy, metalv1.ConditionBackupSucceeded)
+}
+
+// backupCluster takes the snapshot from the first node which succeeds, and applies the retention.
+func (r *EtcdBackupPolicyReconciler) backupCluster(ctx context.Context, cluster string, targets []backupTarget, sink backup.Sink, retention int, now time.Time) (string, []metalv1.EtcdSnapshotInfo, error) {
+	var errs []string
+
+	for _, target := range targets {
+		if err := r.snapshot(ctx, cluster, target, sink, now); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", target.ref.Name, err))
+
+			continue
+		}
+
+		retained, err := backup.Prune(ctx, sink, cluster, retention)
+		if err != nil {
+			return "", nil, errors.Wrap(err, "failed to apply retention")
+		}
+
+		snapshots := make([]metalv1.EtcdSnapshotInfo, 0, len(retained))
+
+		for _, snapshot := range retained {
+			snapshots = append(snapshots, metalv1.EtcdSnapshotInfo{
+				Name:         snapshot.Name,
+				Size:         snapshot.Size,
+				SHA256:       snapshot.SHA256,
+				CreationTime: &metav1.Time{Time: snapshot.CreationTime},
+			})
+		}
+
+		return target.ref.Name, snapshots, nil
+	}
+
+	return "", nil, errors.New(strings.Join(errs, "; "))
+}
+
+func (r *EtcdBackupPolicyReconciler) snapshot(ctx context.Context, cluster string, target backupTarget, sink backup.Sink, now time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, etcdSnapshotTimeout)
+	defer cancel()
+
+	c, err := r.talosClient(ctx, target)
+	if err != nil {
+		return err
+	}
+
+	defer c.Close() //nolint:errcheck
+
+	_, err = backup.Save(ctx, sink, cluster, now, func(w io.Writer) error {
+		_, err := talos.EtcdSnapshot(ctx, c, w)
+
+		return err
+	})
+
+	return err
+}
+
+// reconcileRestore starts the requested restore in the background, and records its result once it completes.
+//
+// It returns the interval to check the running restore at, zero if no restore is running.
+//
+// The restore runs at most once: the Running phase is persisted before the restore is started,
+// so a restore found in the Running phase which is not tracked by the controller was interrupted,
+// and it's marked as failed instead of being run again.
+func (r *EtcdBackupPolicyReconciler) reconcileRestore(ctx context.Context, policy *metalv1.EtcdBackupPolicy, policyRef *corev1.ObjectReference,
+	patchHelper *patch.Helper, sink backup.Sink,
+) (time.Duration, error) {
+	logger := r.Log.WithValues("etcdbackuppolicy", policy.Name)
+
+	spec := policy.Spec.Restore
+	status := policy.Status.Restore
+
+	if running := r.trackedRestore(policy.Name); running != nil {
+		select {
+		case <-running.done:
+		default:
+			// a single restore runs at a time, even if the request changed
+			return etcdRestorePollInterval, nil
+		}
+
+		r.untrackRestore(policy.Name)
+
+		if status != nil && status.Phase == metalv1.EtcdRestoreRunning && status.Snapshot == running.snapshot && status.Target == running.target {
+			r.completeRestore(policy, policyRef, running.err)
+		}
+	}
+
+	if spec == nil {
+		// forget the last restore, so that it can be requested again
+		policy.Status.Restore = nil
+
+		return 0, nil
+	}
+
+	if status != nil && status.Snapshot == spec.Snapshot && status.Target == spec.Target {
+		if status.Phase == metalv1.EtcdRestoreRunning {
+			r.failRestore(policy, policyRef, errors.New("restore was interrupted, its result is unknown"))
+		}
+
+		return 0, nil
+	}
+
+	now := metav1.Now()
+
+	policy.Status.Restore = &metalv1.EtcdRestoreStatus{
+		Snapshot:  spec.Snapshot,
+		Target:    spec.Target,
+		StartTime: &now,
+	}
+
+	target, err := r.restoreTarget(ctx, &spec.Target)
+	if err != nil {
+		r.failRestore(policy, policyRef, err)
+
+		return 0, nil
+	}
+
+	policy.Status.Restore.Phase = metalv1.EtcdRestoreRunning
+
+	if err = patchHelper.Patch(ctx, policy); err != nil {
+		// the restore didn't start, so it can be retried
+		policy.Status.Restore = status
+
+		return 0, err
+	}
+
+	logger.Info("restoring etcd snapshot", "snapshot", spec.Snapshot, "target", spec.Target.Name)
+	r.Recorder.Event(policyRef, corev1.EventTypeNormal, "RestoreStarted", fmt.Sprintf("Restoring snapshot %q to %s %q", spec.Snapshot, spec.Target.Kind, spec.Target.Name))
+
+	restore := &etcdRestore{
+		snapshot: spec.Snapshot,
+		target:   spec.Target,
+		done:     make(chan struct{}),
+	}
+
+	r.trackRestore(policy.Name, restore)
+
+	restoreSpec := *spec
+
+	go func() {
+		defer close(restore.done)
+
+		// the restore outlives the reconcile, it's bounded by the snapshot timeout only
+		restore.err = r.restore(context.Background(), target, sink, &restoreSpec)
+	}()
+
+	return etcdRestorePollInterval, nil
+}
+
+// completeRestore records the result of the restore.
+func (r *EtcdBackupPolicyReconciler) completeRestore(policy *metalv1.EtcdBackupPolicy, policyRef *corev1.ObjectReference, err error) {
+	restore := policy.Status.Restore
+
+	if err != nil {
+		r.Log.Error(err, "restore failed", "etcdbackuppolicy", policy.Name, "snapshot", restore.Snapshot, "target", restore.Target.Name)
+		r.failRestore(policy, policyRef, err)
+
+		return
+	}
+
+	completed := metav1.Now()
+
+	restore.Phase = metalv1.EtcdRestoreSucceeded
+	restore.CompletionTime = &completed
+
+	r.Recorder.Event(policyRef, corev1.EventTypeNormal, "RestoreSucceeded", fmt.Sprintf("Restored snapshot %q to %s %q", restore.Snapshot, restore.Target.Kind, restore.Target.Name))
+}
+
+func (r *EtcdBackupPolicyReconciler) trackedRestore(policy string) *etcdRestore {
+	r.restoresMu.Lock()
+	defer r.restoresMu.Unlock()
+
+	return r.restores[policy]
+}
+
+func (r *EtcdBackupPolicyReconciler) trackRestore(policy string, restore *etcdRestore) {
+	r.restoresMu.Lock()
+	defer r.restoresMu.Unlock()
+
+	if r.restores == nil {
+		r.restores = map[string]*etcdRestore{}
+	}
+
+	r.restores[policy] = restore
+}
+
+func (r *EtcdBackupPolicyReconciler) untrackRestore(policy string) {
+	r.restoresMu.Lock()
+	defer r.restoresMu.Unlock()
+
+	delete(r.restores, policy)
+}
+
+func (r *EtcdBackupPolicyReconciler) restore(ctx context.Context, target backupTarget, sink backup.Sink, spec *metalv1.EtcdRestoreSpec) error {
+	ctx, cancel := context.WithTimeout(ctx, etcdSnapshotTimeout)
+	defer cancel()
+
+	snapshot, err := backup.Open(ctx, sink, spec.Snapshot)
+	if err != nil {
+		return err
+	}
+
+	defer snapshot.Close() //nolint:errcheck
+
+	c, err := r.talosClient(ctx, target)
+	if err != nil {
+		return err
+	}
+
+	defer c.Close() //nolint:errcheck
+
+	return talos.RecoverEtcd(ctx, c, snapshot, spec.SkipHashCheck)
+}
+
+// failRestore marks the restore as failed.
+func (r *EtcdBackupPolicyReconciler) failRestore(policy *metalv1.EtcdBackupPolicy, policyRef *corev1.ObjectReference, err error) {
+	now := metav1.Now()
+
+	restore := policy.Status.Restore
+
+	restore.Phase = metalv1.EtcdRestoreFailed
+	restore.CompletionTime = &now
+	restore.Error = err.Error()
+
+	r.Recorder.Event(policyRef, corev1.EventTypeWarning, "RestoreFailed", fmt.Sprintf("Restore of snapshot %q failed: %s", restore.Snapshot, err))
+}
+
+// sink creates the sink the snapshots are stored in.
+func (r *EtcdBackupPolicyReconciler) sink(ctx context.Context, policy *metalv1.EtcdBackupPolicy) (backup.Sink, error) {
+	spec := policy.Spec.Sink
+
+	switch {
+	case spec.Local != nil && spec.S3 != nil:
+		return nil, errors.Wrap(errInvalidBackupPolicy, "only one of sink.local and sink.s3 should be set")
+	case spec.Local != nil:
+		if r.BackupDir == "" {
+			return nil, errors.Wrap(errInvalidBackupPolicy, "local sinks are disabled, set --etcd-backup-dir to enable them")
+		}
+
+		path := filepath.Clean(spec.Local.Path)
+		if !filepath.IsLocal(path) {
+			return nil, errors.Wrapf(errInvalidBackupPolicy, "sink.local.path %q should be a relative path within the backup directory", spec.Local.Path)
+		}
+
+		return &backup.LocalSink{Root: filepath.Join(r.BackupDir, path)}, nil
+	case spec.S3 != nil:
+		secret, err := getSecret(ctx, r.Client, &spec.S3.CredentialsSecretRef)
+		if err != nil {
+			return nil, err
+		}
+
+		sink, err := backup.NewS3Sink(backup.S3Config{
+			Endpoint:           spec.S3.Endpoint,
+			Bucket:             spec.S3.Bucket,
+			Prefix:             spec.S3.Prefix,
+			Region:             spec.S3.Region,
+			AccessKeyID:        string(secret.Data[metalv1.S3AccessKeyIDKey]),
+			SecretAccessKey:    string(secret.Data[metalv1.S3SecretAccessKeyKey]),
+			InsecureSkipVerify: spec.S3.InsecureSkipVerify,
+		})
+		if err != nil {
+			return nil, errors.Wrap(errInvalidBackupPolicy, err.Error())
+		}
+
+		return sink, nil
+	default:
+		return nil, errors.Wrap(errInvalidBackupPolicy, "either sink.local or sink.s3 should be set")
+	}
+}
+
+// backupTargets returns the selected control plane nodes grouped by cluster.
+func (r *EtcdBackupPolicyReconciler) backupTargets(ctx context.Context, policy *metalv1.EtcdBackupPolicy) (map[string][]backupTarget, error) {
+	targets := map[string][]backupTarget{}
+
+	if policy.Spec.AdoptedServerSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(policy.Spec.AdoptedServerSelector)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse adoptedServerSelector")
+		}
+
+		var adoptedServers metalv1.AdoptedServerList
+
+		if err = r.List(ctx, &adoptedServers, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+			return nil, errors.Wrap(err, "failed to list adopted servers")
+		}
+
+		for _, as := range adoptedServers.Items {
+			target, ok := adoptedServerBackupTarget(&as)
+			if !ok || !as.Status.Connected {
+				continue
+			}
+
+			cluster := upgradeGroup(&as)
+			if cluster == "" {
+				cluster = as.Name
+			}
+
+			targets[cluster] = append(targets[cluster], target)
+		}
+	}
+
+	if policy.Spec.ServerBindingSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(policy.Spec.ServerBindingSelector)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse serverBindingSelector")
+		}
+
+		var serverBindings infrav1.ServerBindingList
+
+		if err = r.List(ctx, &serverBindings, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+			return nil, errors.Wrap(err, "failed to list server bindings")
+		}
+
+		for _, serverBinding := range serverBindings.Items {
+			target, ok := serverBindingBackupTarget(&serverBinding)
+			if !ok {
+				continue
+			}
+
+			cluster := serverBinding.Labels[clusterv1.ClusterNameLabel]
+
+			targets[cluster] = append(targets[cluster], target)
+		}
+	}
+
+	for _, clusterTargets := range targets {
+		slices.SortFunc(clusterTargets, func(a, b backupTarget) int {
+			return strings.Compare(a.ref.Name, b.ref.Name)
+		})
+	}
+
+	return targets, nil
+}
+
+// restoreTarget returns the node referenced by the restore.
+func (r *EtcdBackupPolicyReconciler) restoreTarget(ctx context.Context, ref *corev1.ObjectReference) (backupTarget, error) {
+	switch ref.Kind {
+	case "AdoptedServer":
+		var as metalv1.AdoptedServer
+
+		if err := r.Get(ctx, types.NamespacedName{Name: ref.Name}, &as); err != nil {
+			return backupTarget{}, errors.Wrapf(err, "failed to get adopted server %q", ref.Name)
+		}
+
+		target, ok := adoptedServerBackupTarget(&as)
+		if !ok {
+			return backupTarget{}, errors.Errorf("adopted server %q is not an accepted control plane node with Talos API credentials", ref.Name)
+		}
+
+		return target, nil
+	case "ServerBinding":
+		var serverBinding infrav1.ServerBinding
+
+		if err := r.Get(ctx, types.NamespacedName{Name: ref.Name}, &serverBinding); err != nil {
+			return backupTarget{}, errors.Wrapf(err, "failed to get server binding %q", ref.Name)
+		}
+
+		target, ok := serverBindingBackupTarget(&serverBinding)
+		if !ok {
+			return backupTarget{}, errors.Errorf("server binding %q is not a control plane machine with an address", ref.Name)
+		}
+
+		return target, nil
+	default:
+		return backupTarget{}, errors.Errorf("unsupported restore target kind %q, should be either AdoptedServer or ServerBinding", ref.Kind)
+	}
+}
+
+func adoptedServerBackupTarget(as *metalv1.AdoptedServer) (backupTarget, bool) {
+	if !as.Spec.Accepted || as.Spec.Talos.NodeType != "controlplane" || as.Spec.Talos.SecretRef == nil {
+		return backupTarget{}, false
+	}
+
+	return backupTarget{
+		ref: corev1.ObjectReference{
+			Kind: "AdoptedServer",
+			Name: as.Name,
+		},
+		endpoint:  as.Spec.Talos.Endpoint,
+		secretRef: *as.Spec.Talos.SecretRef,
+	}, true
+}
+
+// serverBindingBackupTarget uses the talosconfig generated by the Talos bootstrap provider for the cluster.
+//
+// ServerBindings are cluster-scoped, the talosconfig is in the namespace of the MetalMachine.
+func serverBindingBackupTarget(serverBinding *infrav1.ServerBinding) (backupTarget, bool) {
+	cluster := serverBinding.Labels[clusterv1.ClusterNameLabel]
+
+	if _, controlPlane := serverBinding.Labels[clusterv1.MachineControlPlaneLabel]; !controlPlane || cluster == "" || len(serverBinding.Spec.Addresses) == 0 {
+		return backupTarget{}, false
+	}
+
+	endpoint, err := metalv1.NormalizeTalosEndpoint(serverBinding.Spec.Addresses[0])
+	if err != nil {
+		return backupTarget{}, false
+	}
+
+	return backupTarget{
+		ref: corev1.ObjectReference{
+			Kind: "ServerBinding",
+			Name: serverBinding.Name,
+		},
+		endpoint: endpoint,
+		secretRef: corev1.SecretReference{
+			Namespace: serverBinding.Spec.MetalMachineRef.Namespace,
+			Name:      cluster + "-talosconfig",
+		},
+	}, true
+}
+
+func (r *EtcdBackupPolicyReconciler) talosClient(ctx context.Context, target backupTarget) (*talosclient.Client, error) {
+	secret, err := getSecret(ctx, r.Client, &target.secretRef)
+	if err != nil {
+		return nil, err
+	}
+
+	return talos.NewClient(ctx, target.endpoint, secret)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *EtcdBackupPolicyReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		WithOptions(options).
+		For(&metalv1.EtcdBackupPolicy{}).
+		Complete(r)
+}
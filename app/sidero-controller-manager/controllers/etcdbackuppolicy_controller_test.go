@@ -0,0 +1,194 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package controllers_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	metalv1 "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/controllers"
+)
+
+// etcdBackupPolicySetup returns the reconciler of the policy, with a connected control plane AdoptedServer to back up.
+func etcdBackupPolicySetup(t *testing.T, policy *metalv1.EtcdBackupPolicy) (*controllers.EtcdBackupPolicyReconciler, client.Client) {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	require.NoError(t, metalv1.AddToScheme(scheme))
+
+	endpoint, secret := talosAPI(t)
+
+	as := &metalv1.AdoptedServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "cp-1",
+			Labels: map[string]string{"backup": "true"},
+		},
+		Spec: metalv1.AdoptedServerSpec{
+			Accepted: true,
+			Talos: metalv1.TalosConfig{
+				Endpoint: endpoint,
+				NodeType: "controlplane",
+				SecretRef: &corev1.SecretReference{
+					Namespace: secret.Namespace,
+					Name:      secret.Name,
+				},
+			},
+		},
+		Status: metalv1.AdoptedServerStatus{
+			Connected: true,
+		},
+	}
+
+	policy.Spec.AdoptedServerSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"backup": "true"}}
+	policy.Spec.Sink = metalv1.EtcdBackupSink{Local: &metalv1.LocalBackupSink{}}
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(policy, as, secret).
+		WithStatusSubresource(&metalv1.EtcdBackupPolicy{}, &metalv1.AdoptedServer{}).
+		Build()
+
+	return &controllers.EtcdBackupPolicyReconciler{
+		Client:    fakeClient,
+		Log:       ctrl.Log,
+		Scheme:    scheme,
+		APIReader: fakeClient,
+		Recorder:  record.NewFakeRecorder(100),
+		BackupDir: t.TempDir(),
+	}, fakeClient
+}
+
+func TestEtcdBackupPolicyRetry(t *testing.T) {
+	t.Parallel()
+
+	policy := &metalv1.EtcdBackupPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "daily",
+		},
+		Spec: metalv1.EtcdBackupPolicySpec{
+			Schedule: "@every 24h",
+		},
+	}
+
+	r, fakeClient := etcdBackupPolicySetup(t, policy)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	t.Cleanup(cancel)
+
+	reconcile := func() (ctrl.Result, *metalv1.EtcdBackupPolicy) {
+		t.Helper()
+
+		result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: policy.Name}})
+		require.NoError(t, err)
+
+		var reconciled metalv1.EtcdBackupPolicy
+
+		require.NoError(t, fakeClient.Get(ctx, types.NamespacedName{Name: policy.Name}, &reconciled))
+
+		return result, &reconciled
+	}
+
+	// the stand-in doesn't take snapshots, the backup is retried well before the next schedule
+	result, reconciled := reconcile()
+
+	assert.Equal(t, metalv1.BackupFailedReason, conditions.GetReason(reconciled, metalv1.ConditionBackupSucceeded))
+	assert.EqualValues(t, 1, reconciled.Status.FailedAttempts)
+	require.NotNil(t, reconciled.Status.NextRetryTime)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), reconciled.Status.NextRetryTime.Time, 5*time.Second)
+	assert.LessOrEqual(t, result.RequeueAfter, time.Minute)
+	require.Len(t, reconciled.Status.Clusters, 1)
+	assert.NotEmpty(t, reconciled.Status.Clusters[0].Error)
+
+	scheduled := reconciled.Status.LastScheduleTime
+
+	// once due, the retry backs off further, and keeps the schedule
+	reconciled.Status.NextRetryTime = &metav1.Time{Time: time.Now().Add(-time.Second)}
+	require.NoError(t, fakeClient.Status().Update(ctx, reconciled))
+
+	_, reconciled = reconcile()
+
+	assert.EqualValues(t, 2, reconciled.Status.FailedAttempts)
+	require.NotNil(t, reconciled.Status.NextRetryTime)
+	assert.WithinDuration(t, time.Now().Add(2*time.Minute), reconciled.Status.NextRetryTime.Time, 5*time.Second)
+	assert.Equal(t, scheduled, reconciled.Status.LastScheduleTime)
+}
+
+func TestEtcdBackupPolicyRestore(t *testing.T) {
+	t.Parallel()
+
+	policy := &metalv1.EtcdBackupPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "restore",
+		},
+		Spec: metalv1.EtcdBackupPolicySpec{
+			Schedule: "@every 24h",
+			Suspend:  true,
+			Restore: &metalv1.EtcdRestoreSpec{
+				Snapshot: "cp-1/cp-1-20240101T000000Z.db",
+				Target: corev1.ObjectReference{
+					Kind: "AdoptedServer",
+					Name: "cp-1",
+				},
+			},
+		},
+	}
+
+	r, fakeClient := etcdBackupPolicySetup(t, policy)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	t.Cleanup(cancel)
+
+	reconcile := func() (ctrl.Result, *metalv1.EtcdRestoreStatus) {
+		t.Helper()
+
+		result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: policy.Name}})
+		require.NoError(t, err)
+
+		var reconciled metalv1.EtcdBackupPolicy
+
+		require.NoError(t, fakeClient.Get(ctx, types.NamespacedName{Name: policy.Name}, &reconciled))
+		require.NotNil(t, reconciled.Status.Restore)
+
+		return result, reconciled.Status.Restore
+	}
+
+	// the restore runs in the background, the reconcile only starts it
+	result, restore := reconcile()
+
+	assert.Equal(t, metalv1.EtcdRestoreRunning, restore.Phase)
+	assert.Positive(t, result.RequeueAfter)
+
+	// the result is recorded once the restore completes, the snapshot doesn't exist
+	require.Eventually(t, func() bool {
+		result, restore = reconcile()
+
+		return restore.Phase != metalv1.EtcdRestoreRunning
+	}, 30*time.Second, 100*time.Millisecond)
+
+	assert.Equal(t, metalv1.EtcdRestoreFailed, restore.Phase)
+	assert.NotEmpty(t, restore.Error)
+	assert.NotNil(t, restore.CompletionTime)
+	assert.Zero(t, result.RequeueAfter)
+
+	// the restore isn't run again
+	_, completed := reconcile()
+
+	assert.Equal(t, restore, completed)
+}
@@ -0,0 +1,287 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/tools/reference"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	metalv1 "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/pkg/managementapi"
+)
+
+// defaultManagementAPISyncConcurrency is the number of clusters synced at the same time by default.
+const defaultManagementAPISyncConcurrency = 4
+
+// ManagementAPISyncLoop periodically syncs all the AdoptedServers with the Management API, grouped by cluster.
+//
+// Each endpoint is health checked once, then each cluster gets its aggregated status update, and the status of all
+// of its nodes is updated with a single batch request. The result of the sync is written to each AdoptedServer status.
+type ManagementAPISyncLoop struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// ManagementAPIClients is shared by the controllers talking to the Management API.
+	ManagementAPIClients *managementapi.Pool
+
+	// Interval between the syncs.
+	Interval time.Duration
+	// Concurrency is the number of endpoints and clusters synced at the same time.
+	Concurrency int
+}
+
+// managementAPICluster is the group of AdoptedServers synced together.
+type managementAPICluster struct {
+	config  *metalv1.ManagementAPIConfig
+	servers []*metalv1.AdoptedServer
+}
+
+// SetupWithManager runs the sync loop on the leader.
+func (l *ManagementAPISyncLoop) SetupWithManager(mgr ctrl.Manager) error {
+	if l.Interval <= 0 {
+		return errors.New("Management API sync interval must be positive")
+	}
+
+	return mgr.Add(l)
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable.
+func (l *ManagementAPISyncLoop) NeedLeaderElection() bool {
+	return true
+}
+
+// Start implements manager.Runnable.
+func (l *ManagementAPISyncLoop) Start(ctx context.Context) error {
+	ticker := time.NewTicker(l.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := l.sync(ctx); err != nil {
+			l.Log.Error(err, "failed to sync AdoptedServers with Management API")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// sync runs a single sync of all the AdoptedServers.
+func (l *ManagementAPISyncLoop) sync(ctx context.Context) error {
+	var list metalv1.AdoptedServerList
+
+	if err := l.List(ctx, &list); err != nil {
+		return errors.Wrap(err, "failed to list AdoptedServers")
+	}
+
+	clusters := map[string]*managementAPICluster{}
+	patchHelpers := map[string]*patch.Helper{}
+
+	for i := range list.Items {
+		as := &list.Items[i]
+
+		if !managementAPISyncEnabled(as) {
+			continue
+		}
+
+		patchHelper, err := patch.NewHelper(as, l.Client)
+		if err != nil {
+			return err
+		}
+
+		patchHelpers[as.Name] = patchHelper
+
+		key := managementAPIClusterKey(as.Spec.ManagementAPI)
+
+		if clusters[key] == nil {
+			clusters[key] = &managementAPICluster{config: as.Spec.ManagementAPI}
+		}
+
+		clusters[key].servers = append(clusters[key].servers, as)
+	}
+
+	if len(clusters) == 0 {
+		return nil
+	}
+
+	endpointErrors := l.checkEndpoints(ctx, clusters)
+
+	var (
+		mu      sync.Mutex
+		results = map[string]error{}
+	)
+
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.SetLimit(l.concurrency())
+
+	for _, cluster := range clusters {
+		eg.Go(func() error {
+			nodeErrors, err := l.syncCluster(egCtx, cluster, endpointErrors[cluster.config.Endpoint])
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			for _, as := range cluster.servers {
+				results[as.Name] = err
+				if err == nil {
+					results[as.Name] = nodeErrors[as.Name]
+				}
+			}
+
+			return nil
+		})
+	}
+
+	eg.Wait() //nolint:errcheck
+
+	for _, cluster := range clusters {
+		for _, as := range cluster.servers {
+			l.setResult(ctx, as, results[as.Name])
+
+			if err := patchHelpers[as.Name].Patch(ctx, as, patch.WithOwnedConditions{
+				Conditions: []clusterv1.ConditionType{metalv1.ConditionManagementAPISync},
+			}); err != nil {
+				l.Log.Error(err, "failed to patch AdoptedServer", "name", as.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkEndpoints health checks each Management API endpoint once, and returns the errors by endpoint.
+func (l *ManagementAPISyncLoop) checkEndpoints(ctx context.Context, clusters map[string]*managementAPICluster) map[string]error {
+	configs := map[string]*metalv1.ManagementAPIConfig{}
+
+	for _, cluster := range clusters {
+		configs[cluster.config.Endpoint] = cluster.config
+	}
+
+	var (
+		mu             sync.Mutex
+		endpointErrors = map[string]error{}
+	)
+
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.SetLimit(l.concurrency())
+
+	for endpoint, config := range configs {
+		eg.Go(func() error {
+			err := l.checkEndpoint(egCtx, config)
+
+			mu.Lock()
+			endpointErrors[endpoint] = err
+			mu.Unlock()
+
+			return nil
+		})
+	}
+
+	eg.Wait() //nolint:errcheck
+
+	return endpointErrors
+}
+
+func (l *ManagementAPISyncLoop) checkEndpoint(ctx context.Context, config *metalv1.ManagementAPIConfig) error {
+	client, err := newManagementAPIClient(ctx, l.Client, l.ManagementAPIClients, config)
+	if err != nil {
+		return err
+	}
+
+	if _, err := client.HealthCheck(ctx); err != nil {
+		return errors.Wrap(err, "failed to connect to Management API")
+	}
+
+	return nil
+}
+
+// syncCluster syncs all the AdoptedServers of the cluster, unless its endpoint failed the health check.
+func (l *ManagementAPISyncLoop) syncCluster(ctx context.Context, cluster *managementAPICluster, endpointErr error) (map[string]error, error) {
+	if endpointErr != nil {
+		return nil, endpointErr
+	}
+
+	client, err := newManagementAPIClient(ctx, l.Client, l.ManagementAPIClients, cluster.config)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := l.Log.WithValues("endpoint", cluster.config.Endpoint, "cluster", cluster.config.ClusterName)
+	logger.Info("Syncing cluster with Management API", "nodes", len(cluster.servers))
+
+	nodeErrors, err := managementapi.NewSyncService(client, logger).SyncCluster(ctx, cluster.servers)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to sync cluster with Management API")
+	}
+
+	return nodeErrors, nil
+}
+
+// setResult records the result of the sync in the AdoptedServer status.
+func (l *ManagementAPISyncLoop) setResult(ctx context.Context, as *metalv1.AdoptedServer, err error) {
+	if err != nil {
+		l.Log.Error(err, "failed to sync AdoptedServer with Management API", "name", as.Name)
+
+		if asRef, refErr := reference.GetReference(l.Scheme, as); refErr == nil {
+			l.Recorder.Event(asRef, corev1.EventTypeWarning, "ManagementAPISyncFailed", fmt.Sprintf("Failed to sync with Management API: %s", err.Error()))
+		}
+	}
+
+	setManagementAPISyncResult(as, err)
+}
+
+func (l *ManagementAPISyncLoop) concurrency() int {
+	if l.Concurrency <= 0 {
+		return defaultManagementAPISyncConcurrency
+	}
+
+	return l.Concurrency
+}
+
+// managementAPISyncEnabled returns true if the AdoptedServer should be synced by the sync loop.
+//
+// The AdoptedServer should have been reconciled at least once (so that it gets unregistered on deletion), and it
+// shouldn't have been removed from the Management API, as syncing would register it again.
+func managementAPISyncEnabled(as *metalv1.AdoptedServer) bool {
+	switch {
+	case as.Spec.ManagementAPI == nil || !as.Spec.ManagementAPI.Enabled:
+		return false
+	case !as.Spec.Accepted || !as.DeletionTimestamp.IsZero():
+		return false
+	case !controllerutil.ContainsFinalizer(as, adoptedServerFinalizer):
+		return false
+	default:
+		return !conditions.IsTrue(as, metalv1.ConditionRemovedUpstream)
+	}
+}
+
+// managementAPIClusterKey groups the AdoptedServers by endpoint and cluster, the cluster ID takes precedence over the name.
+func managementAPIClusterKey(config *metalv1.ManagementAPIConfig) string {
+	if config.ClusterID != "" {
+		return config.Endpoint + "\x00id:" + config.ClusterID
+	}
+
+	return config.Endpoint + "\x00name:" + config.ClusterName
+}
@@ -0,0 +1,419 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/tools/reference"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+
+	metalv1 "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/pkg/constants"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/pkg/managementapi"
+)
+
+const defaultInventoryInterval = 5 * time.Minute
+
+// ManagementAPIInventoryReconciler reconciles a ManagementAPIInventory object.
+//
+// It lists the servers registered in the Management API for the cluster, and creates or updates the matching AdoptedServers.
+type ManagementAPIInventoryReconciler struct {
+	client.Client
+	Log       logr.Logger
+	Scheme    *runtime.Scheme
+	APIReader client.Reader
+	Recorder  record.EventRecorder
+
+	// ManagementAPIClients is shared by the controllers talking to the Management API.
+	ManagementAPIClients *managementapi.Pool
+}
+
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=managementapiinventories,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=managementapiinventories/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=adoptedservers,verbs=get;list;watch;create;update;patch
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=adoptedservers/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+func (r *ManagementAPIInventoryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, err error) {
+	logger := r.Log.WithValues("managementapiinventory", req.NamespacedName)
+
+	inventory := &metalv1.ManagementAPIInventory{}
+	if err = r.APIReader.Get(ctx, req.NamespacedName, inventory); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	interval := defaultInventoryInterval
+	if inventory.Spec.Interval != nil && inventory.Spec.Interval.Duration > 0 {
+		interval = inventory.Spec.Interval.Duration
+	}
+
+	// resync only when the spec changes, the interval elapses, or a sync is requested
+	if inventory.Status.ObservedGeneration == inventory.Generation && inventory.Status.LastSyncTime != nil && !syncRequested(inventory) {
+		if remaining := interval - time.Since(inventory.Status.LastSyncTime.Time); remaining > 0 {
+			return ctrl.Result{RequeueAfter: remaining}, nil
+		}
+	}
+
+	patchHelper, err := patch.NewHelper(inventory, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	defer func() {
+		if e := patchHelper.Patch(ctx, inventory, patch.WithOwnedConditions{
+			Conditions: []clusterv1.ConditionType{metalv1.ConditionInventorySynced},
+		}); e != nil {
+			logger.Error(e, "failed to patch ManagementAPIInventory")
+
+			if err == nil {
+				err = e
+			}
+		}
+	}()
+
+	inventoryRef, err := reference.GetReference(r.Scheme, inventory)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	servers, err := r.listServers(ctx, inventory)
+	if err != nil {
+		logger.Error(err, "failed to list servers")
+
+		conditions.MarkFalse(inventory, metalv1.ConditionInventorySynced, metalv1.InventorySyncFailedReason, clusterv1.ConditionSeverityWarning, "%s", err.Error())
+		r.Recorder.Event(inventoryRef, corev1.EventTypeWarning, metalv1.InventorySyncFailedReason, fmt.Sprintf("Failed to list servers: %s", err))
+
+		return ctrl.Result{RequeueAfter: constants.DefaultRequeueAfter}, nil
+	}
+
+	statuses, removed, err := r.reconcileServers(ctx, inventory, inventoryRef, servers)
+	if err != nil {
+		conditions.MarkFalse(inventory, metalv1.ConditionInventorySynced, metalv1.InventorySyncFailedReason, clusterv1.ConditionSeverityWarning, "%s", err.Error())
+
+		return ctrl.Result{}, err
+	}
+
+	now := metav1.Now()
+
+	inventory.Status.Servers = statuses
+	inventory.Status.RemovedUpstream = removed
+	inventory.Status.LastSyncTime = &now
+	inventory.Status.ObservedGeneration = inventory.Generation
+
+	conditions.Set(inventory, &clusterv1.Condition{
+		Type:    metalv1.ConditionInventorySynced,
+		Status:  corev1.ConditionTrue,
+		Message: fmt.Sprintf("Listed %d servers, %d removed upstream", len(statuses), len(removed)),
+	})
+
+	logger.Info("inventory synced", "servers", len(statuses), "removed", len(removed))
+
+	return ctrl.Result{RequeueAfter: interval}, nil
+}
+
+// listServers lists the servers of the cluster in the Management API.
+func (r *ManagementAPIInventoryReconciler) listServers(ctx context.Context, inventory *metalv1.ManagementAPIInventory) ([]managementapi.ServerInfo, error) {
+	var creds *managementapi.Credentials
+
+	if ref := inventory.Spec.SecretRef; ref != nil {
+		secret, err := getSecret(ctx, r.Client, ref)
+		if err != nil {
+			return nil, errors.Wrap(errManagementAPICredentials, err.Error())
+		}
+
+		creds, err = managementapi.CredentialsFromSecret(secret)
+		if err != nil {
+			return nil, errors.Wrap(errManagementAPICredentials, err.Error())
+		}
+	}
+
+	apiClient, err := r.ManagementAPIClients.Client(inventory.Spec.Endpoint, creds)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create Management API client")
+	}
+
+	resp, err := apiClient.ListServers(ctx, inventory.Spec.ClusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	servers := make([]managementapi.ServerInfo, 0, len(resp.Servers))
+
+	for _, server := range resp.Servers {
+		// the filter might not be supported by the Management API
+		if server.ClusterName == "" || server.ClusterName == inventory.Spec.ClusterName {
+			servers = append(servers, server)
+		}
+	}
+
+	return servers, nil
+}
+
+// reconcileServers creates or updates the AdoptedServers of the listed servers, and marks the missing ones as removed upstream.
+//
+// Servers are matched to the AdoptedServers by the Management API node ID, or by name. Existing AdoptedServers are only
+// taken over if they belong to the same Management API cluster.
+func (r *ManagementAPIInventoryReconciler) reconcileServers(ctx context.Context, inventory *metalv1.ManagementAPIInventory, inventoryRef *corev1.ObjectReference, servers []managementapi.ServerInfo) ([]metalv1.InventoryServer, []string, error) {
+	var adoptedServers metalv1.AdoptedServerList
+
+	if err := r.APIReader.List(ctx, &adoptedServers); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to list adopted servers")
+	}
+
+	var (
+		byNodeID = map[string]*metalv1.AdoptedServer{}
+		byName   = map[string]*metalv1.AdoptedServer{}
+	)
+
+	for i := range adoptedServers.Items {
+		as := &adoptedServers.Items[i]
+
+		byName[as.Name] = as
+
+		if as.Status.ManagementAPIStatus != nil && as.Status.ManagementAPIStatus.NodeID != "" {
+			byNodeID[as.Status.ManagementAPIStatus.NodeID] = as
+		}
+	}
+
+	statuses := make([]metalv1.InventoryServer, 0, len(servers))
+	listed := map[string]struct{}{}
+
+	for i := range servers {
+		server := &servers[i]
+
+		status := metalv1.InventoryServer{
+			Name:   server.Name,
+			NodeID: server.NodeID,
+		}
+
+		as := byNodeID[server.NodeID]
+		if server.NodeID == "" || as == nil {
+			as = byName[inventoryServerName(server)]
+		}
+
+		var err error
+
+		switch {
+		case as == nil:
+			as, err = r.createAdoptedServer(ctx, inventory, server)
+			if err == nil {
+				r.Recorder.Event(inventoryRef, corev1.EventTypeNormal, "AdoptedServerCreated", fmt.Sprintf("Created AdoptedServer %q for server %s.", as.Name, server.Name))
+			}
+		case !ownedByInventory(inventory, as):
+			err = errors.Errorf("AdoptedServer %q exists and doesn't belong to the Management API cluster %q", as.Name, inventory.Spec.ClusterName)
+		default:
+			status.Conflicts, err = r.updateAdoptedServer(ctx, inventory, as, server)
+		}
+
+		if as != nil {
+			status.AdoptedServer = as.Name
+			listed[as.Name] = struct{}{}
+		}
+
+		if err != nil {
+			status.AdoptedServer = ""
+			status.Error = err.Error()
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	var removed []string
+
+	for i := range adoptedServers.Items {
+		as := &adoptedServers.Items[i]
+
+		if _, ok := listed[as.Name]; ok || as.Labels[metalv1.ManagementAPIInventoryLabel] != inventory.Name {
+			continue
+		}
+
+		if !conditions.IsTrue(as, metalv1.ConditionRemovedUpstream) {
+			if err := r.markRemovedUpstream(ctx, inventory, as); err != nil {
+				return nil, nil, err
+			}
+
+			r.Recorder.Event(inventoryRef, corev1.EventTypeNormal, "RemovedUpstream", fmt.Sprintf("AdoptedServer %q is no longer listed by the Management API.", as.Name))
+		}
+
+		removed = append(removed, as.Name)
+	}
+
+	return statuses, removed, nil
+}
+
+func (r *ManagementAPIInventoryReconciler) createAdoptedServer(ctx context.Context, inventory *metalv1.ManagementAPIInventory, server *managementapi.ServerInfo) (*metalv1.AdoptedServer, error) {
+	if server.TalosEndpoint == "" {
+		return nil, errors.Errorf("server %q has no Talos endpoint", server.Name)
+	}
+
+	as := &metalv1.AdoptedServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: inventoryServerName(server),
+			Labels: map[string]string{
+				metalv1.ManagementAPIInventoryLabel: inventory.Name,
+			},
+		},
+		Spec: metalv1.AdoptedServerSpec{
+			Talos: metalv1.TalosConfig{
+				SecretRef: inventory.Spec.TalosSecretRef.DeepCopy(),
+			},
+			ManagementAPI: &metalv1.ManagementAPIConfig{
+				Enabled:     true,
+				Endpoint:    inventory.Spec.Endpoint,
+				ClusterName: inventory.Spec.ClusterName,
+				ClusterID:   server.ClusterID,
+				SecretRef:   inventory.Spec.SecretRef.DeepCopy(),
+			},
+			Accepted: inventory.Spec.Accept,
+		},
+	}
+
+	if len(inventory.Spec.Labels) > 0 {
+		as.Spec.Labels = make(map[string]string, len(inventory.Spec.Labels))
+
+		for key, value := range inventory.Spec.Labels {
+			as.Spec.Labels[key] = value
+		}
+	}
+
+	// everything comes from the Management API on creation
+	managementapi.ApplyServerInfo(as, server, metalv1.InventorySourceOfTruth{})
+
+	if err := r.Create(ctx, as); err != nil {
+		return nil, errors.Wrapf(err, "failed to create adopted server %q", as.Name)
+	}
+
+	// the node is already registered, record its ID so that the AdoptedServer controller doesn't register it again
+	as.Status.ManagementAPIStatus = &metalv1.ManagementAPIStatus{
+		ClusterID: server.ClusterID,
+		NodeID:    server.NodeID,
+	}
+
+	if err := r.Status().Update(ctx, as); err != nil {
+		return nil, errors.Wrapf(err, "failed to update adopted server %q status", as.Name)
+	}
+
+	return as, nil
+}
+
+// updateAdoptedServer applies the listed server to the AdoptedServer according to the source of truth policy.
+func (r *ManagementAPIInventoryReconciler) updateAdoptedServer(ctx context.Context, inventory *metalv1.ManagementAPIInventory, as *metalv1.AdoptedServer, server *managementapi.ServerInfo) ([]string, error) {
+	patchHelper, err := patch.NewHelper(as, r.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	if as.Labels == nil {
+		as.Labels = map[string]string{}
+	}
+
+	as.Labels[metalv1.ManagementAPIInventoryLabel] = inventory.Name
+
+	conflicts := managementapi.ApplyServerInfo(as, server, inventory.Spec.SourceOfTruth)
+
+	if as.Status.ManagementAPIStatus == nil {
+		as.Status.ManagementAPIStatus = &metalv1.ManagementAPIStatus{}
+	}
+
+	if as.Status.ManagementAPIStatus.NodeID == "" && server.NodeID != "" {
+		as.Status.ManagementAPIStatus.NodeID = server.NodeID
+	}
+
+	conditions.Delete(as, metalv1.ConditionRemovedUpstream)
+
+	if err := patchHelper.Patch(ctx, as, patch.WithOwnedConditions{
+		Conditions: []clusterv1.ConditionType{metalv1.ConditionRemovedUpstream},
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed to update adopted server %q", as.Name)
+	}
+
+	return conflicts, nil
+}
+
+// markRemovedUpstream sets the RemovedUpstream condition, AdoptedServers are never deleted by the inventory.
+func (r *ManagementAPIInventoryReconciler) markRemovedUpstream(ctx context.Context, inventory *metalv1.ManagementAPIInventory, as *metalv1.AdoptedServer) error {
+	patchHelper, err := patch.NewHelper(as, r.Client)
+	if err != nil {
+		return err
+	}
+
+	conditions.Set(as, &clusterv1.Condition{
+		Type:    metalv1.ConditionRemovedUpstream,
+		Status:  corev1.ConditionTrue,
+		Message: fmt.Sprintf("Server is no longer listed by the Management API for the cluster %q", inventory.Spec.ClusterName),
+	})
+
+	if err := patchHelper.Patch(ctx, as, patch.WithOwnedConditions{
+		Conditions: []clusterv1.ConditionType{metalv1.ConditionRemovedUpstream},
+	}); err != nil {
+		return errors.Wrapf(err, "failed to update adopted server %q", as.Name)
+	}
+
+	return nil
+}
+
+// syncRequested returns true if the sync was requested after the last sync.
+//
+// The request and the sync times have a second resolution, a request in the same second as the sync is not skipped.
+func syncRequested(inventory *metalv1.ManagementAPIInventory) bool {
+	requested, err := time.Parse(time.RFC3339, inventory.Annotations[metalv1.SyncRequestedAnnotation])
+	if err != nil {
+		return false
+	}
+
+	return !requested.Before(inventory.Status.LastSyncTime.Truncate(time.Second))
+}
+
+// ownedByInventory returns true if the AdoptedServer was created by the inventory, or belongs to the same Management API cluster.
+func ownedByInventory(inventory *metalv1.ManagementAPIInventory, as *metalv1.AdoptedServer) bool {
+	if as.Labels[metalv1.ManagementAPIInventoryLabel] == inventory.Name {
+		return true
+	}
+
+	return as.Spec.ManagementAPI != nil &&
+		as.Spec.ManagementAPI.Endpoint == inventory.Spec.Endpoint &&
+		as.Spec.ManagementAPI.ClusterName == inventory.Spec.ClusterName
+}
+
+// inventoryServerName derives the AdoptedServer name from the server name, falling back to the node ID.
+func inventoryServerName(server *managementapi.ServerInfo) string {
+	name := strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(server.Name), "-"), "-.")
+
+	if name != "" && len(validation.IsDNS1123Subdomain(name)) == 0 {
+		return name
+	}
+
+	suffix := invalidNameChars.ReplaceAllString(strings.ToLower(server.NodeID), "")
+	if len(suffix) > 8 {
+		suffix = suffix[:8]
+	}
+
+	return "node-" + suffix
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ManagementAPIInventoryReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		WithOptions(options).
+		For(&metalv1.ManagementAPIInventory{}).
+		Complete(r)
+}
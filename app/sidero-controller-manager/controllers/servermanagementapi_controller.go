@@ -0,0 +1,464 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/tools/reference"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	infrav1 "github.com/siderolabs/sidero/app/caps-controller-manager/api/v1alpha3"
+	metalv1 "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/pkg/managementapi"
+)
+
+const serverManagementAPIFinalizer = "metal.sidero.dev/management-api"
+
+// ServerManagementAPIReconciler syncs the bare-metal Servers with the Management API.
+//
+// The sync is enabled by the ServerClass of the Server, or for all the Servers by the Default config.
+// Disabling the sync forgets the registration, the node is left in the Management API.
+type ServerManagementAPIReconciler struct {
+	client.Client
+	Log       logr.Logger
+	Scheme    *runtime.Scheme
+	APIReader client.Reader
+	Recorder  record.EventRecorder
+
+	// ManagementAPIClients is shared by the controllers talking to the Management API.
+	ManagementAPIClients *managementapi.Pool
+
+	// Default enables the sync of the Servers whose ServerClass has no Management API config.
+	Default *metalv1.ManagementAPIConfig
+}
+
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=servers,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=servers/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=servers/finalizers,verbs=update
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=serverclasses,verbs=get;list;watch
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=serverbindings,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Reconcile registers the Server as a node of its Management API cluster, and keeps its status up to date.
+func (r *ServerManagementAPIReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, err error) {
+	logger := r.Log.WithValues("server", req.NamespacedName)
+
+	server := &metalv1.Server{}
+	if err = r.APIReader.Get(ctx, req.NamespacedName, server); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	serverRef, err := reference.GetReference(r.Scheme, server)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	patchHelper, err := patch.NewHelper(server, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	defer func() {
+		if e := patchHelper.Patch(ctx, server, patch.WithOwnedConditions{
+			Conditions: []clusterv1.ConditionType{metalv1.ConditionManagementAPISync},
+		}); e != nil {
+			logger.Error(e, "failed to patch server")
+
+			if err == nil {
+				err = e
+			}
+		}
+	}()
+
+	serverBinding, err := r.serverBinding(ctx, server)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	config, err := r.managementAPIConfig(ctx, server, serverBinding)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if !server.DeletionTimestamp.IsZero() {
+		if config != nil && controllerutil.ContainsFinalizer(server, serverManagementAPIFinalizer) {
+			if err = r.unregister(ctx, logger, server, config); err != nil {
+				// Continue with deletion even if unregister fails
+				logger.Error(err, "failed to unregister from Management API")
+				r.Recorder.Event(serverRef, corev1.EventTypeWarning, "UnregisterFailed", fmt.Sprintf("Failed to unregister from Management API: %s", err.Error()))
+			}
+		}
+
+		controllerutil.RemoveFinalizer(server, serverManagementAPIFinalizer)
+
+		return ctrl.Result{}, nil
+	}
+
+	if config == nil {
+		controllerutil.RemoveFinalizer(server, serverManagementAPIFinalizer)
+		conditions.Delete(server, metalv1.ConditionManagementAPISync)
+
+		server.Status.ManagementAPIStatus = nil
+
+		return ctrl.Result{}, nil
+	}
+
+	controllerutil.AddFinalizer(server, serverManagementAPIFinalizer)
+
+	if server.Status.ManagementAPIStatus == nil {
+		server.Status.ManagementAPIStatus = &metalv1.ManagementAPIStatus{}
+	}
+
+	status := server.Status.ManagementAPIStatus
+
+	if err = r.sync(ctx, logger, server, serverBinding, config); err != nil {
+		logger.Error(err, "failed to sync with Management API")
+		markManagementAPISyncFailed(server, err)
+
+		status.Synced = false
+		status.Error = err.Error()
+
+		r.Recorder.Event(serverRef, corev1.EventTypeWarning, "ManagementAPISyncFailed", fmt.Sprintf("Failed to sync with Management API: %s", err.Error()))
+
+		return ctrl.Result{RequeueAfter: healthCheckInterval}, nil
+	}
+
+	conditions.MarkTrue(server, metalv1.ConditionManagementAPISync)
+
+	syncTime := metav1.Now()
+
+	status.Synced = true
+	status.Error = ""
+	status.LastSyncTime = &syncTime
+
+	return ctrl.Result{RequeueAfter: syncInterval}, nil
+}
+
+// sync registers or updates the Server in the Management API.
+func (r *ServerManagementAPIReconciler) sync(ctx context.Context, logger logr.Logger, server *metalv1.Server, serverBinding *infrav1.ServerBinding, config *metalv1.ManagementAPIConfig) error {
+	client, err := newManagementAPIClient(ctx, r.Client, r.ManagementAPIClients, config)
+	if err != nil {
+		return err
+	}
+
+	cluster, err := r.serverCluster(ctx, server, serverBinding, config)
+	if err != nil {
+		return err
+	}
+
+	syncService := managementapi.NewSyncService(client, logger)
+
+	status := server.Status.ManagementAPIStatus
+
+	// Leaving a cluster: clean it up if no other Server is left there
+	if status.ClusterName != "" && status.ClusterName != cluster.Name {
+		deleteCluster, err := r.lastInManagementAPICluster(ctx, server)
+		if err != nil {
+			return err
+		}
+
+		if err = syncService.UnregisterServer(ctx, server, deleteCluster); err != nil {
+			return errors.Wrap(err, "failed to unregister server from the previous cluster")
+		}
+	}
+
+	// Join the cluster already registered by another Server
+	if cluster.ID == "" && status.ClusterID == "" {
+		if cluster.ID, err = r.registeredManagementAPICluster(ctx, server, cluster.Name); err != nil {
+			return err
+		}
+	}
+
+	if err = syncService.SyncServer(ctx, server, cluster); err != nil {
+		return errors.Wrap(err, "failed to sync server with Management API")
+	}
+
+	return nil
+}
+
+// unregister removes the Server from the Management API.
+func (r *ServerManagementAPIReconciler) unregister(ctx context.Context, logger logr.Logger, server *metalv1.Server, config *metalv1.ManagementAPIConfig) error {
+	client, err := newManagementAPIClient(ctx, r.Client, r.ManagementAPIClients, config)
+	if err != nil {
+		return err
+	}
+
+	deleteCluster, err := r.lastInManagementAPICluster(ctx, server)
+	if err != nil {
+		return err
+	}
+
+	return managementapi.NewSyncService(client, logger).UnregisterServer(ctx, server, deleteCluster)
+}
+
+// serverBinding returns the ServerBinding of the Server, or nil if the Server is not allocated.
+func (r *ServerManagementAPIReconciler) serverBinding(ctx context.Context, server *metalv1.Server) (*infrav1.ServerBinding, error) {
+	var serverBinding infrav1.ServerBinding
+
+	if err := r.Get(ctx, types.NamespacedName{Name: server.Name}, &serverBinding); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+
+		return nil, errors.Wrap(err, "failed to get server binding")
+	}
+
+	return &serverBinding, nil
+}
+
+// managementAPIConfig returns the Management API config of the Server, or nil if the sync is disabled.
+//
+// The ServerClass of the ServerBinding takes precedence, then the ServerClasses the Server belongs to, in name order,
+// then the Default config.
+func (r *ServerManagementAPIReconciler) managementAPIConfig(ctx context.Context, server *metalv1.Server, serverBinding *infrav1.ServerBinding) (*metalv1.ManagementAPIConfig, error) {
+	config := r.Default
+
+	enabled := func(config *metalv1.ManagementAPIConfig) *metalv1.ManagementAPIConfig {
+		if config == nil || !config.Enabled {
+			return nil
+		}
+
+		return config
+	}
+
+	if serverBinding != nil && serverBinding.Spec.ServerClassRef != nil {
+		var serverClass metalv1.ServerClass
+
+		err := r.Get(ctx, types.NamespacedName{Name: serverBinding.Spec.ServerClassRef.Name}, &serverClass)
+
+		switch {
+		case err == nil:
+			if serverClass.Spec.ManagementAPI != nil {
+				return enabled(serverClass.Spec.ManagementAPI), nil
+			}
+		case !apierrors.IsNotFound(err):
+			return nil, errors.Wrap(err, "failed to get server class")
+		}
+	}
+
+	var serverClasses metalv1.ServerClassList
+
+	if err := r.List(ctx, &serverClasses); err != nil {
+		return nil, errors.Wrap(err, "failed to list server classes")
+	}
+
+	slices.SortFunc(serverClasses.Items, func(a, b metalv1.ServerClass) int {
+		switch {
+		case a.Name < b.Name:
+			return -1
+		case a.Name > b.Name:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	for _, serverClass := range serverClasses.Items {
+		if serverClass.Spec.ManagementAPI == nil {
+			continue
+		}
+
+		if slices.Contains(serverClass.Status.ServersAvailable, server.Name) || slices.Contains(serverClass.Status.ServersInUse, server.Name) {
+			return enabled(serverClass.Spec.ManagementAPI), nil
+		}
+	}
+
+	return enabled(config), nil
+}
+
+// serverCluster returns the Management API cluster of the Server: the CAPI cluster of its ServerBinding, or the pool cluster.
+func (r *ServerManagementAPIReconciler) serverCluster(ctx context.Context, server *metalv1.Server, serverBinding *infrav1.ServerBinding, config *metalv1.ManagementAPIConfig) (*managementapi.ServerCluster, error) {
+	if serverBinding != nil && server.Status.InUse {
+		if name := serverBinding.Labels[clusterv1.ClusterNameLabel]; name != "" {
+			cluster := &managementapi.ServerCluster{
+				Name:     name,
+				NodeType: "worker",
+				Managed:  true,
+			}
+
+			if _, controlPlane := serverBinding.Labels[clusterv1.MachineControlPlaneLabel]; controlPlane {
+				cluster.NodeType = "controlplane"
+			}
+
+			var capiCluster clusterv1.Cluster
+
+			err := r.Get(ctx, types.NamespacedName{Namespace: serverBinding.Spec.MetalMachineRef.Namespace, Name: name}, &capiCluster)
+
+			switch {
+			case err == nil:
+				cluster.EndpointIP = capiCluster.Spec.ControlPlaneEndpoint.Host
+			case !apierrors.IsNotFound(err):
+				return nil, errors.Wrapf(err, "failed to get cluster %q", name)
+			}
+
+			return cluster, nil
+		}
+	}
+
+	cluster := &managementapi.ServerCluster{
+		Name: config.ClusterName,
+		ID:   config.ClusterID,
+	}
+
+	if cluster.Name == "" {
+		cluster.Name = managementapi.DefaultPoolClusterName
+	}
+
+	return cluster, nil
+}
+
+// registeredManagementAPICluster returns the ID of the cluster registered by another Server, if any.
+func (r *ServerManagementAPIReconciler) registeredManagementAPICluster(ctx context.Context, server *metalv1.Server, clusterName string) (string, error) {
+	var list metalv1.ServerList
+
+	if err := r.List(ctx, &list); err != nil {
+		return "", errors.Wrap(err, "failed to list servers")
+	}
+
+	for i := range list.Items {
+		other := &list.Items[i]
+
+		if other.Name == server.Name || other.Status.ManagementAPIStatus == nil {
+			continue
+		}
+
+		if other.Status.ManagementAPIStatus.ClusterName == clusterName && other.Status.ManagementAPIStatus.ClusterID != "" {
+			return other.Status.ManagementAPIStatus.ClusterID, nil
+		}
+	}
+
+	return "", nil
+}
+
+// lastInManagementAPICluster returns true if no other Server is registered in the Management API cluster of the Server.
+//
+// The clusters taken from the ServerClass config are never deleted.
+func (r *ServerManagementAPIReconciler) lastInManagementAPICluster(ctx context.Context, server *metalv1.Server) (bool, error) {
+	status := server.Status.ManagementAPIStatus
+	if status == nil || status.ClusterID == "" {
+		return false, nil
+	}
+
+	var serverClasses metalv1.ServerClassList
+
+	if err := r.List(ctx, &serverClasses); err != nil {
+		return false, errors.Wrap(err, "failed to list server classes")
+	}
+
+	for _, serverClass := range serverClasses.Items {
+		if serverClass.Spec.ManagementAPI != nil && serverClass.Spec.ManagementAPI.ClusterID == status.ClusterID {
+			return false, nil
+		}
+	}
+
+	if r.Default != nil && r.Default.ClusterID == status.ClusterID {
+		return false, nil
+	}
+
+	var list metalv1.ServerList
+
+	if err := r.List(ctx, &list); err != nil {
+		return false, errors.Wrap(err, "failed to list servers")
+	}
+
+	for i := range list.Items {
+		other := &list.Items[i]
+
+		if other.Name == server.Name || !other.DeletionTimestamp.IsZero() || other.Status.ManagementAPIStatus == nil {
+			continue
+		}
+
+		if other.Status.ManagementAPIStatus.ClusterID == status.ClusterID {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ServerManagementAPIReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("server-management-api").
+		WithOptions(options).
+		For(&metalv1.Server{}, builder.WithPredicates(serverSyncPredicate())).
+		Watches(
+			&infrav1.ServerBinding{},
+			handler.EnqueueRequestsFromMapFunc(func(_ context.Context, serverBinding client.Object) []reconcile.Request {
+				return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: serverBinding.GetName()}}}
+			}),
+		).
+		Watches(
+			&metalv1.ServerClass{},
+			handler.EnqueueRequestsFromMapFunc(r.mapServerClassToServers),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
+		Complete(r)
+}
+
+// serverSyncPredicate skips the updates of the Server which don't change what is synced, including the sync status itself.
+func serverSyncPredicate() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldServer, ok := e.ObjectOld.(*metalv1.Server)
+			if !ok {
+				return true
+			}
+
+			newServer, ok := e.ObjectNew.(*metalv1.Server)
+			if !ok {
+				return true
+			}
+
+			return oldServer.Generation != newServer.Generation ||
+				!newServer.DeletionTimestamp.IsZero() ||
+				oldServer.Status.InUse != newServer.Status.InUse ||
+				oldServer.Status.IsClean != newServer.Status.IsClean ||
+				oldServer.Status.Power != newServer.Status.Power ||
+				!apiequality.Semantic.DeepEqual(oldServer.Status.Addresses, newServer.Status.Addresses)
+		},
+	}
+}
+
+// mapServerClassToServers reconciles the servers of the server class, so that the sync is enabled or disabled right away.
+func (r *ServerManagementAPIReconciler) mapServerClassToServers(_ context.Context, obj client.Object) []reconcile.Request {
+	serverClass, ok := obj.(*metalv1.ServerClass)
+	if !ok {
+		return nil
+	}
+
+	var requests []reconcile.Request
+
+	for _, name := range append(slices.Clone(serverClass.Status.ServersAvailable), serverClass.Status.ServersInUse...) {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: name}})
+	}
+
+	return requests
+}
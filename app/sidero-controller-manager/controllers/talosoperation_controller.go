@@ -0,0 +1,183 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/tools/reference"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	metalv1 "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/internal/talos"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/pkg/constants"
+)
+
+// TalosOperationReconciler reconciles a TalosOperation object.
+type TalosOperationReconciler struct {
+	client.Client
+	Log       logr.Logger
+	Scheme    *runtime.Scheme
+	APIReader client.Reader
+	Recorder  record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=talosoperations,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=talosoperations/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=metal.sidero.dev,resources=adoptedservers,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Reconcile runs the operation on the adopted node.
+//
+// The operation runs at most once: the Running phase is persisted before the Talos API is called,
+// so an operation found in the Running phase was interrupted, and it's marked as failed instead of being run again.
+func (r *TalosOperationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, err error) {
+	logger := r.Log.WithValues("talosoperation", req.NamespacedName)
+
+	op := &metalv1.TalosOperation{}
+	if err = r.APIReader.Get(ctx, req.NamespacedName, op); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if op.Status.Phase == metalv1.TalosOperationSucceeded || op.Status.Phase == metalv1.TalosOperationFailed {
+		return ctrl.Result{}, nil
+	}
+
+	patchHelper, err := patch.NewHelper(op, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	defer func() {
+		if e := patchHelper.Patch(ctx, op); e != nil {
+			logger.Error(e, "failed to patch TalosOperation")
+
+			if err == nil {
+				err = e
+			}
+		}
+	}()
+
+	as := &metalv1.AdoptedServer{}
+	if err = r.APIReader.Get(ctx, types.NamespacedName{Name: op.Spec.AdoptedServer}, as); err != nil {
+		if apierrors.IsNotFound(err) {
+			r.fail(op, nil, fmt.Errorf("adopted server %q not found", op.Spec.AdoptedServer))
+
+			return ctrl.Result{}, nil
+		}
+
+		return ctrl.Result{}, err
+	}
+
+	asRef, err := reference.GetReference(r.Scheme, as)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// operations are removed together with the server
+	if err = controllerutil.SetOwnerReference(as, op, r.Scheme); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if op.Status.Phase == metalv1.TalosOperationRunning {
+		r.fail(op, asRef, errors.New("operation was interrupted, its result is unknown"))
+
+		return ctrl.Result{}, nil
+	}
+
+	if !as.Spec.Accepted {
+		r.fail(op, asRef, errors.New("adopted server is not accepted"))
+
+		return ctrl.Result{}, nil
+	}
+
+	op.Status.Phase = metalv1.TalosOperationPending
+
+	if !as.Status.Connected {
+		op.Status.Error = "waiting for the adopted server to be connected"
+
+		return ctrl.Result{RequeueAfter: constants.DefaultRequeueAfter}, nil
+	}
+
+	talosClient, err := newAdoptedServerTalosClient(ctx, r.Client, as)
+	if err != nil {
+		op.Status.Error = err.Error()
+
+		return ctrl.Result{}, err
+	}
+
+	defer talosClient.Close() //nolint:errcheck
+
+	now := metav1.Now()
+
+	op.Status.Phase = metalv1.TalosOperationRunning
+	op.Status.StartTime = &now
+	op.Status.Error = ""
+
+	if err = patchHelper.Patch(ctx, op); err != nil {
+		// the operation didn't start, so it can be retried
+		op.Status.Phase = metalv1.TalosOperationPending
+		op.Status.StartTime = nil
+
+		return ctrl.Result{}, err
+	}
+
+	logger.Info("running operation", "server", as.Name, "action", op.Spec.Action)
+	r.Recorder.Event(asRef, corev1.EventTypeNormal, "TalosOperationStarted", fmt.Sprintf("Started %s operation %q", op.Spec.Action, op.Name))
+
+	opCtx, cancel := context.WithTimeout(ctx, talosAPITimeout)
+	defer cancel()
+
+	if err = talos.RunOperation(opCtx, talosClient, &op.Spec); err != nil {
+		logger.Error(err, "operation failed", "server", as.Name, "action", op.Spec.Action)
+		r.fail(op, asRef, err)
+
+		return ctrl.Result{}, nil
+	}
+
+	completed := metav1.Now()
+
+	op.Status.Phase = metalv1.TalosOperationSucceeded
+	op.Status.CompletionTime = &completed
+
+	r.Recorder.Event(asRef, corev1.EventTypeNormal, "TalosOperationSucceeded", fmt.Sprintf("Completed %s operation %q", op.Spec.Action, op.Name))
+
+	return ctrl.Result{}, nil
+}
+
+// fail marks the operation as failed, and records the failure as an event on the adopted server.
+func (r *TalosOperationReconciler) fail(op *metalv1.TalosOperation, asRef *corev1.ObjectReference, err error) {
+	now := metav1.Now()
+
+	op.Status.Phase = metalv1.TalosOperationFailed
+	op.Status.CompletionTime = &now
+	op.Status.Error = err.Error()
+
+	if asRef != nil {
+		r.Recorder.Event(asRef, corev1.EventTypeWarning, "TalosOperationFailed", fmt.Sprintf("The %s operation %q failed: %s", op.Spec.Action, op.Name, err))
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *TalosOperationReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		WithOptions(options).
+		For(&metalv1.TalosOperation{}).
+		Complete(r)
+}
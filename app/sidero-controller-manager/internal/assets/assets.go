@@ -0,0 +1,141 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package assets downloads the Environment assets and serves them to the servers.
+package assets
+
+import (
+	"context"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	metalv1 "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
+)
+
+// ErrChecksumMismatch is returned when the downloaded asset doesn't match its declared SHA512 digest.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// downloadTimeout is the timeout of a single asset download.
+const downloadTimeout = 5 * time.Minute
+
+// partialSuffix is the suffix of the temporary files assets are downloaded to, they are hidden files as well.
+const partialSuffix = ".part"
+
+// Download downloads the asset to the file.
+//
+// The asset is streamed into a temporary file next to the target while being hashed, verified against the declared
+// SHA512 digest (if any), and then renamed over the target. The target is either the previous or the new complete
+// asset, never a partial one.
+func Download(ctx context.Context, asset metalv1.Asset, file string) error {
+	return download(ctx, http.DefaultClient, asset, file)
+}
+
+func download(ctx context.Context, client *http.Client, asset metalv1.Asset, file string) error {
+	if asset.URL == "" {
+		return errors.New("missing URL")
+	}
+
+	requestContext, cancel := context.WithTimeout(ctx, downloadTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(requestContext, http.MethodGet, asset.URL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("failed to download asset: %d", resp.StatusCode)
+	}
+
+	return Save(resp.Body, asset.SHA512, file)
+}
+
+// Save writes the contents of r to the file atomically, verifying the SHA512 digest if it's not empty.
+func Save(r io.Reader, digest, file string) error {
+	dir, base := filepath.Split(file)
+
+	removePartial(dir, base)
+
+	tmp, err := os.CreateTemp(dir, "."+base+".*"+partialSuffix)
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name()) //nolint:errcheck
+
+	if err = write(tmp, r, digest); err != nil {
+		tmp.Close() //nolint:errcheck
+
+		return err
+	}
+
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), file)
+}
+
+func write(w *os.File, r io.Reader, digest string) error {
+	hash := sha512.New()
+
+	if _, err := io.Copy(io.MultiWriter(w, hash), r); err != nil {
+		return err
+	}
+
+	if digest != "" {
+		actual := hex.EncodeToString(hash.Sum(nil))
+
+		if !strings.EqualFold(actual, strings.TrimSpace(digest)) {
+			return fmt.Errorf("%w: expected sha512 %s, got %s", ErrChecksumMismatch, digest, actual)
+		}
+	}
+
+	if err := w.Chmod(0o644); err != nil {
+		return err
+	}
+
+	return w.Sync()
+}
+
+// removePartial removes the temporary files left by interrupted downloads of the file.
+func removePartial(dir, base string) {
+	matches, _ := filepath.Glob(filepath.Join(dir, "."+base+".*"+partialSuffix)) //nolint:errcheck
+
+	for _, match := range matches {
+		os.Remove(match) //nolint:errcheck
+	}
+}
+
+// FileServer serves the assets under root, hidden files (e.g. downloads in progress) are not served.
+func FileServer(root string) http.Handler {
+	fileServer := http.FileServer(http.Dir(root))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, element := range strings.Split(r.URL.Path, "/") {
+			if strings.HasPrefix(element, ".") {
+				http.NotFound(w, r)
+
+				return
+			}
+		}
+
+		fileServer.ServeHTTP(w, r)
+	})
+}
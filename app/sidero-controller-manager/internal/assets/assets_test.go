@@ -0,0 +1,127 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package assets_test
+
+import (
+	"context"
+	"crypto/sha512"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metalv1 "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/internal/assets"
+)
+
+func digest(contents string) string {
+	sum := sha512.Sum512([]byte(contents))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func TestDownload(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		io.WriteString(w, strings.TrimPrefix(r.URL.Path, "/")) //nolint:errcheck
+	}))
+	t.Cleanup(srv.Close)
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "vmlinuz")
+	ctx := context.Background()
+
+	// a leftover of an interrupted download is cleaned up
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".vmlinuz.123.part"), []byte("partial"), 0o644))
+
+	require.NoError(t, assets.Download(ctx, metalv1.Asset{URL: srv.URL + "/long-contents", SHA512: digest("long-contents")}, file))
+	assertContents(t, file, "long-contents")
+
+	// a shorter asset replaces the previous one entirely
+	require.NoError(t, assets.Download(ctx, metalv1.Asset{URL: srv.URL + "/short", SHA512: strings.ToUpper(digest("short"))}, file))
+	assertContents(t, file, "short")
+
+	// the digest is optional
+	require.NoError(t, assets.Download(ctx, metalv1.Asset{URL: srv.URL + "/unverified"}, file))
+	assertContents(t, file, "unverified")
+
+	// a mismatch keeps the previous asset
+	err := assets.Download(ctx, metalv1.Asset{URL: srv.URL + "/tampered", SHA512: digest("original")}, file)
+	require.ErrorIs(t, err, assets.ErrChecksumMismatch)
+	assertContents(t, file, "unverified")
+
+	require.Error(t, assets.Download(ctx, metalv1.Asset{URL: srv.URL + "/missing"}, file))
+	assertContents(t, file, "unverified")
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	info, err := entries[0].Info()
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o644), info.Mode().Perm())
+}
+
+func TestFileServer(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "default"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "default", "vmlinuz"), []byte("kernel"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "default", ".vmlinuz.123.part"), []byte("kern"), 0o644))
+
+	srv := httptest.NewServer(http.StripPrefix("/env/", assets.FileServer(dir)))
+	t.Cleanup(srv.Close)
+
+	for _, test := range []struct {
+		path     string
+		status   int
+		contents string
+	}{
+		{path: "/env/default/vmlinuz", status: http.StatusOK, contents: "kernel"},
+		{path: "/env/default/.vmlinuz.123.part", status: http.StatusNotFound},
+		{path: "/env/default/initramfs.xz", status: http.StatusNotFound},
+	} {
+		t.Run(test.path, func(t *testing.T) {
+			t.Parallel()
+
+			resp, err := http.Get(srv.URL + test.path) //nolint:noctx
+			require.NoError(t, err)
+
+			defer resp.Body.Close() //nolint:errcheck
+
+			assert.Equal(t, test.status, resp.StatusCode)
+
+			if test.contents != "" {
+				body, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+				assert.Equal(t, test.contents, string(body))
+			}
+		})
+	}
+}
+
+func assertContents(t *testing.T, file, expected string) {
+	t.Helper()
+
+	contents, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(contents))
+}
@@ -0,0 +1,352 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package assets
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+)
+
+// fatFS is a read-only FAT16/FAT32 file system, as used by the EFI system partitions.
+type fatFS struct {
+	r io.ReaderAt
+
+	clusterSize int64
+	clusters    uint32
+	fatOffset   int64
+	dataOffset  int64
+	fat32       bool
+
+	// FAT16 root directory region
+	rootOffset int64
+	rootSize   int64
+
+	// FAT32 root directory cluster
+	rootCluster uint32
+}
+
+// fatEntry is a directory entry.
+type fatEntry struct {
+	name    string
+	dir     bool
+	cluster uint32
+	size    int64
+}
+
+// openFAT reads the boot sector of the FAT file system.
+func openFAT(r io.ReaderAt) (*fatFS, error) {
+	bootSector := make([]byte, sectorSize)
+
+	if _, err := r.ReadAt(bootSector, 0); err != nil {
+		return nil, fmt.Errorf("failed to read FAT boot sector: %w", err)
+	}
+
+	bytesPerSector := int64(binary.LittleEndian.Uint16(bootSector[11:]))
+	sectorsPerCluster := int64(bootSector[13])
+	reservedSectors := int64(binary.LittleEndian.Uint16(bootSector[14:]))
+	numFATs := int64(bootSector[16])
+	rootEntries := int64(binary.LittleEndian.Uint16(bootSector[17:]))
+
+	totalSectors := int64(binary.LittleEndian.Uint16(bootSector[19:]))
+	if totalSectors == 0 {
+		totalSectors = int64(binary.LittleEndian.Uint32(bootSector[32:]))
+	}
+
+	fatSectors := int64(binary.LittleEndian.Uint16(bootSector[22:]))
+	if fatSectors == 0 {
+		fatSectors = int64(binary.LittleEndian.Uint32(bootSector[36:]))
+	}
+
+	if bytesPerSector == 0 || sectorsPerCluster == 0 || numFATs == 0 || bootSector[510] != 0x55 || bootSector[511] != 0xaa {
+		return nil, errors.New("invalid FAT boot sector")
+	}
+
+	rootSectors := (rootEntries*32 + bytesPerSector - 1) / bytesPerSector
+	dataSectors := totalSectors - reservedSectors - numFATs*fatSectors - rootSectors
+
+	fs := &fatFS{
+		r:           r,
+		clusterSize: bytesPerSector * sectorsPerCluster,
+		clusters:    uint32(dataSectors / sectorsPerCluster),
+		fatOffset:   reservedSectors * bytesPerSector,
+		rootOffset:  (reservedSectors + numFATs*fatSectors) * bytesPerSector,
+		rootSize:    rootSectors * bytesPerSector,
+	}
+
+	fs.dataOffset = fs.rootOffset + fs.rootSize
+
+	switch {
+	case fs.clusters < 4085:
+		return nil, errors.New("FAT12 is not supported")
+	case fs.clusters >= 65525:
+		fs.fat32 = true
+		fs.rootCluster = binary.LittleEndian.Uint32(bootSector[44:])
+	}
+
+	return fs, nil
+}
+
+// lookup returns the entry at the path, the lookup is case-insensitive.
+func (fs *fatFS) lookup(path string) (fatEntry, error) {
+	dir := fatEntry{name: "/", dir: true}
+
+	for _, element := range strings.Split(strings.Trim(path, "/"), "/") {
+		if !dir.dir {
+			return fatEntry{}, fmt.Errorf("%q is not a directory", dir.name)
+		}
+
+		entries, err := fs.readDir(dir)
+		if err != nil {
+			return fatEntry{}, err
+		}
+
+		found := false
+
+		for _, entry := range entries {
+			if strings.EqualFold(entry.name, element) {
+				dir, found = entry, true
+
+				break
+			}
+		}
+
+		if !found {
+			return fatEntry{}, fmt.Errorf("%q not found", path)
+		}
+	}
+
+	return dir, nil
+}
+
+// readDir lists the directory, the root directory is the entry without a cluster.
+func (fs *fatFS) readDir(dir fatEntry) ([]fatEntry, error) {
+	var (
+		data []byte
+		err  error
+	)
+
+	switch {
+	case dir.cluster == 0 && !fs.fat32:
+		data = make([]byte, fs.rootSize)
+		_, err = fs.r.ReadAt(data, fs.rootOffset)
+	case dir.cluster == 0:
+		data, err = io.ReadAll(fs.open(fatEntry{cluster: fs.rootCluster, size: -1}))
+	default:
+		data, err = io.ReadAll(fs.open(fatEntry{cluster: dir.cluster, size: -1}))
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory %q: %w", dir.name, err)
+	}
+
+	return parseDir(data), nil
+}
+
+// open returns the contents of the file, the whole cluster chain is read if the size is negative.
+func (fs *fatFS) open(entry fatEntry) io.Reader {
+	if entry.size == 0 {
+		return strings.NewReader("")
+	}
+
+	runs, err := fs.chain(entry.cluster)
+	if err != nil {
+		return &errReader{err}
+	}
+
+	readers := make([]io.Reader, 0, len(runs))
+
+	for _, run := range runs {
+		readers = append(readers, io.NewSectionReader(fs.r, fs.dataOffset+int64(run.first-2)*fs.clusterSize, int64(run.length)*fs.clusterSize))
+	}
+
+	r := io.MultiReader(readers...)
+
+	if entry.size < 0 {
+		return r
+	}
+
+	return io.LimitReader(r, entry.size)
+}
+
+// clusterRun is a run of contiguous clusters.
+type clusterRun struct {
+	first  uint32
+	length uint32
+}
+
+// chain follows the cluster chain in the FAT.
+func (fs *fatFS) chain(cluster uint32) ([]clusterRun, error) {
+	var (
+		runs  []clusterRun
+		entry = make([]byte, 4)
+		count uint32
+	)
+
+	for {
+		if cluster < 2 || cluster >= fs.clusters+2 {
+			return nil, fmt.Errorf("invalid cluster %d", cluster)
+		}
+
+		if count++; count > fs.clusters {
+			return nil, errors.New("cluster chain loop")
+		}
+
+		if last := len(runs) - 1; last >= 0 && runs[last].first+runs[last].length == cluster {
+			runs[last].length++
+		} else {
+			runs = append(runs, clusterRun{first: cluster, length: 1})
+		}
+
+		var next uint32
+
+		if fs.fat32 {
+			if _, err := fs.r.ReadAt(entry, fs.fatOffset+int64(cluster)*4); err != nil {
+				return nil, err
+			}
+
+			next = binary.LittleEndian.Uint32(entry) & 0x0fffffff
+
+			if next >= 0x0ffffff8 {
+				return runs, nil
+			}
+		} else {
+			if _, err := fs.r.ReadAt(entry[:2], fs.fatOffset+int64(cluster)*2); err != nil {
+				return nil, err
+			}
+
+			next = uint32(binary.LittleEndian.Uint16(entry))
+
+			if next >= 0xfff8 {
+				return runs, nil
+			}
+		}
+
+		cluster = next
+	}
+}
+
+// longNameOffsets are the offsets of the UCS-2 characters in a long file name entry.
+var longNameOffsets = []int{1, 3, 5, 7, 9, 14, 16, 18, 20, 22, 24, 28, 30}
+
+// parseDir parses the directory entries, long file names are used when present.
+func parseDir(data []byte) []fatEntry {
+	var (
+		entries      []fatEntry
+		longName     []uint16
+		longChecksum byte
+	)
+
+	for offset := 0; offset+32 <= len(data); offset += 32 {
+		raw := data[offset : offset+32]
+
+		switch {
+		case raw[0] == 0x00:
+			return entries
+		case raw[0] == 0xe5:
+			longName = nil
+
+			continue
+		case raw[11] == 0x0f:
+			if raw[0]&0x40 != 0 {
+				longName = nil
+			}
+
+			chars := make([]uint16, 0, len(longNameOffsets))
+
+			for _, off := range longNameOffsets {
+				chars = append(chars, binary.LittleEndian.Uint16(raw[off:]))
+			}
+
+			// long name entries are stored in reverse order
+			longName = append(chars, longName...)
+			longChecksum = raw[13]
+
+			continue
+		case raw[11]&0x08 != 0:
+			longName = nil
+
+			continue
+		}
+
+		name := shortName(raw)
+
+		if longName != nil && longChecksum == shortNameChecksum(raw[:11]) {
+			name = decodeLongName(longName)
+		}
+
+		longName = nil
+
+		if name == "." || name == ".." {
+			continue
+		}
+
+		entries = append(entries, fatEntry{
+			name:    name,
+			dir:     raw[11]&0x10 != 0,
+			cluster: uint32(binary.LittleEndian.Uint16(raw[20:]))<<16 | uint32(binary.LittleEndian.Uint16(raw[26:])),
+			size:    int64(binary.LittleEndian.Uint32(raw[28:])),
+		})
+	}
+
+	return entries
+}
+
+func shortName(raw []byte) string {
+	base := strings.TrimRight(string(raw[:8]), " ")
+	ext := strings.TrimRight(string(raw[8:11]), " ")
+
+	if raw[0] == 0x05 {
+		base = "\xe5" + base[1:]
+	}
+
+	// Windows NT lowercase flags
+	if raw[12]&0x08 != 0 {
+		base = strings.ToLower(base)
+	}
+
+	if raw[12]&0x10 != 0 {
+		ext = strings.ToLower(ext)
+	}
+
+	if ext == "" {
+		return base
+	}
+
+	return base + "." + ext
+}
+
+func shortNameChecksum(name []byte) byte {
+	var sum byte
+
+	for _, c := range name {
+		sum = (sum&1)<<7 + sum>>1 + c
+	}
+
+	return sum
+}
+
+func decodeLongName(chars []uint16) string {
+	for i, c := range chars {
+		if c == 0x0000 || c == 0xffff {
+			chars = chars[:i]
+
+			break
+		}
+	}
+
+	return string(utf16.Decode(chars))
+}
+
+type errReader struct {
+	err error
+}
+
+func (r *errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,104 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package assets
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// sectorSize is the logical sector size of the disk images.
+const sectorSize = 512
+
+// espTypeGUID is the EFI system partition type GUID (C12A7328-F81F-11D2-BA4B-00A0C93EC93B) in its on-disk encoding.
+var espTypeGUID = []byte{0x28, 0x73, 0x2a, 0xc1, 0x1f, 0xf8, 0xd2, 0x11, 0xba, 0x4b, 0x00, 0xa0, 0xc9, 0x3e, 0xc9, 0x3b}
+
+// ErrNoESP is returned when the disk image doesn't have an EFI system partition.
+var ErrNoESP = errors.New("no EFI system partition found")
+
+// sequentialReader reads a stream (e.g. a decompressed disk image) at increasing offsets.
+type sequentialReader struct {
+	r      io.Reader
+	offset int64
+}
+
+// Read implements io.Reader.
+func (s *sequentialReader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	s.offset += int64(n)
+
+	return n, err
+}
+
+// skip discards the data up to the offset.
+func (s *sequentialReader) skip(offset int64) error {
+	if offset < s.offset {
+		return fmt.Errorf("can't read backwards from %d to %d", s.offset, offset)
+	}
+
+	_, err := io.CopyN(io.Discard, s, offset-s.offset)
+
+	return err
+}
+
+// readAt reads len(p) bytes at the offset.
+func (s *sequentialReader) readAt(p []byte, offset int64) error {
+	if err := s.skip(offset); err != nil {
+		return err
+	}
+
+	_, err := io.ReadFull(s, p)
+
+	return err
+}
+
+// findESP reads the GPT of the disk, and returns the offset and the size of the EFI system partition.
+func findESP(disk *sequentialReader) (int64, int64, error) {
+	header := make([]byte, 92)
+
+	if err := disk.readAt(header, sectorSize); err != nil {
+		return 0, 0, fmt.Errorf("failed to read GPT header: %w", err)
+	}
+
+	if !bytes.Equal(header[:8], []byte("EFI PART")) {
+		return 0, 0, errors.New("disk image doesn't have a GPT")
+	}
+
+	entriesLBA := binary.LittleEndian.Uint64(header[72:])
+	numEntries := binary.LittleEndian.Uint32(header[80:])
+	entrySize := binary.LittleEndian.Uint32(header[84:])
+
+	if entrySize < 128 || numEntries > 1024 {
+		return 0, 0, fmt.Errorf("invalid GPT partition entries: %d x %d bytes", numEntries, entrySize)
+	}
+
+	entries := make([]byte, int(numEntries)*int(entrySize))
+
+	if err := disk.readAt(entries, int64(entriesLBA)*sectorSize); err != nil {
+		return 0, 0, fmt.Errorf("failed to read GPT partition entries: %w", err)
+	}
+
+	for i := 0; i < int(numEntries); i++ {
+		entry := entries[i*int(entrySize):]
+
+		if !bytes.Equal(entry[:16], espTypeGUID) {
+			continue
+		}
+
+		firstLBA := binary.LittleEndian.Uint64(entry[32:])
+		lastLBA := binary.LittleEndian.Uint64(entry[40:])
+
+		if lastLBA < firstLBA {
+			return 0, 0, fmt.Errorf("invalid EFI system partition LBA range %d-%d", firstLBA, lastLBA)
+		}
+
+		return int64(firstLBA) * sectorSize, int64(lastLBA-firstLBA+1) * sectorSize, nil
+	}
+
+	return 0, 0, ErrNoESP
+}
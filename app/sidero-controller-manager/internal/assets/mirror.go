@@ -0,0 +1,117 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package assets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	metalv1 "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
+)
+
+// ErrHostNotAllowed is returned when an air-gapped asset would be downloaded from a host outside the allowlist.
+var ErrHostNotAllowed = errors.New("host not allowed")
+
+// maxRedirects is the number of redirects followed by a download, the same as the default HTTP client.
+const maxRedirects = 10
+
+const (
+	// releasesHost and releasesPrefix locate the Talos release assets on GitHub.
+	releasesHost   = "github.com"
+	releasesPrefix = "/siderolabs/talos/releases/download/"
+
+	// factoryHost and factoryPrefix locate the Image Factory boot assets.
+	factoryHost   = "factory.talos.dev"
+	factoryPrefix = "/image/"
+)
+
+// Mirror rewrites the upstream asset URLs onto an air-gap asset mirror, and restricts the hosts assets are
+// downloaded from.
+//
+// The mirror layout follows the upstream paths:
+//
+//	https://github.com/siderolabs/talos/releases/download/<version>/<file> -> <mirror>/<version>/<file>
+//	https://factory.talos.dev/image/<schematic>/<version>/<file>          -> <mirror>/image/<schematic>/<version>/<file>
+//
+// Other URLs are kept as is.
+type Mirror struct {
+	// URL is the base URL of the mirror, e.g. https://artifacts.local/talos, URLs are not rewritten if empty.
+	URL string
+
+	// AllowedHosts are the hosts assets can be downloaded from besides the mirror, e.g. "artifacts.local" or
+	// "artifacts.local:8443".
+	AllowedHosts []string
+}
+
+// Resolve returns the URL the asset is downloaded from, and whether it's on the mirror.
+func (m *Mirror) Resolve(assetURL string) (string, bool, error) {
+	u, err := url.Parse(assetURL)
+	if err != nil {
+		return "", false, err
+	}
+
+	var mirror *url.URL
+
+	if m.URL != "" {
+		if mirror, err = url.Parse(strings.TrimSuffix(m.URL, "/")); err != nil {
+			return "", false, fmt.Errorf("invalid asset mirror: %w", err)
+		}
+
+		switch {
+		case strings.EqualFold(u.Host, releasesHost) && strings.HasPrefix(u.Path, releasesPrefix):
+			u = mirror.JoinPath(strings.TrimPrefix(u.Path, releasesPrefix))
+		case strings.EqualFold(u.Host, factoryHost) && strings.HasPrefix(u.Path, factoryPrefix):
+			u = mirror.JoinPath(u.Path)
+		}
+	}
+
+	onMirror := mirror != nil && strings.EqualFold(u.Host, mirror.Host)
+
+	if !onMirror && !m.allowed(u) {
+		return "", false, fmt.Errorf("%w: %q is not the asset mirror or in the allowed hosts", ErrHostNotAllowed, u.Host)
+	}
+
+	return u.String(), onMirror, nil
+}
+
+// Download downloads the asset to the file like Download, the redirects are resolved like the asset URLs.
+//
+// A redirect to a host outside the mirror and the allowed hosts fails the download with ErrHostNotAllowed.
+func (m *Mirror) Download(ctx context.Context, asset metalv1.Asset, file string) error {
+	client := &http.Client{
+		CheckRedirect: m.checkRedirect,
+	}
+
+	return download(ctx, client, asset, file)
+}
+
+func (m *Mirror) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+
+	resolved, _, err := m.Resolve(req.URL.String())
+	if err != nil {
+		return err
+	}
+
+	req.URL, err = url.Parse(resolved)
+
+	return err
+}
+
+func (m *Mirror) allowed(u *url.URL) bool {
+	for _, host := range m.AllowedHosts {
+		if strings.EqualFold(host, u.Host) || strings.EqualFold(host, u.Hostname()) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,140 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package assets_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metalv1 "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/internal/assets"
+)
+
+func TestMirrorResolve(t *testing.T) {
+	t.Parallel()
+
+	mirror := &assets.Mirror{
+		URL:          "https://artifacts.local:8443/talos/",
+		AllowedHosts: []string{"images.local"},
+	}
+
+	for _, test := range []struct {
+		name     string
+		mirror   *assets.Mirror
+		url      string
+		expected string
+		onMirror bool
+		err      error
+	}{
+		{
+			name:     "release",
+			mirror:   mirror,
+			url:      "https://github.com/siderolabs/talos/releases/download/v1.11.5/vmlinuz-amd64",
+			expected: "https://artifacts.local:8443/talos/v1.11.5/vmlinuz-amd64",
+			onMirror: true,
+		},
+		{
+			name:     "factory",
+			mirror:   mirror,
+			url:      "https://factory.talos.dev/image/376567988ad370138ad8b2698212367b8edcb69b5fd68c80be1f2ec7d603b4ba/v1.11.5/metal-amd64.raw.xz",
+			expected: "https://artifacts.local:8443/talos/image/376567988ad370138ad8b2698212367b8edcb69b5fd68c80be1f2ec7d603b4ba/v1.11.5/metal-amd64.raw.xz",
+			onMirror: true,
+		},
+		{
+			name:     "already on the mirror",
+			mirror:   mirror,
+			url:      "https://artifacts.local:8443/custom/uki.raw.xz",
+			expected: "https://artifacts.local:8443/custom/uki.raw.xz",
+			onMirror: true,
+		},
+		{
+			name:     "allowed host",
+			mirror:   mirror,
+			url:      "http://images.local:8080/talos/initramfs-amd64.xz",
+			expected: "http://images.local:8080/talos/initramfs-amd64.xz",
+		},
+		{
+			name:   "other GitHub repository",
+			mirror: mirror,
+			url:    "https://github.com/example/talos-fork/releases/download/v1.11.5/vmlinuz-amd64",
+			err:    assets.ErrHostNotAllowed,
+		},
+		{
+			name:   "no mirror",
+			mirror: &assets.Mirror{AllowedHosts: []string{"images.local"}},
+			url:    "https://github.com/siderolabs/talos/releases/download/v1.11.5/vmlinuz-amd64",
+			err:    assets.ErrHostNotAllowed,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			resolved, onMirror, err := test.mirror.Resolve(test.url)
+
+			if test.err != nil {
+				require.ErrorIs(t, err, test.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, resolved)
+			assert.Equal(t, test.onMirror, onMirror)
+		})
+	}
+}
+
+func TestMirrorDownloadRedirect(t *testing.T) {
+	t.Parallel()
+
+	disallowed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "disallowed") //nolint:errcheck
+	}))
+	t.Cleanup(disallowed.Close)
+
+	allowed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/disallowed":
+			http.Redirect(w, r, disallowed.URL+"/vmlinuz-amd64", http.StatusFound)
+		case "/allowed":
+			http.Redirect(w, r, "/vmlinuz-amd64", http.StatusFound)
+		default:
+			io.WriteString(w, "allowed") //nolint:errcheck
+		}
+	}))
+	t.Cleanup(allowed.Close)
+
+	u, err := url.Parse(allowed.URL)
+	require.NoError(t, err)
+
+	mirror := &assets.Mirror{
+		AllowedHosts: []string{u.Host},
+	}
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "vmlinuz")
+	ctx := context.Background()
+
+	require.NoError(t, mirror.Download(ctx, metalv1.Asset{URL: allowed.URL + "/allowed"}, file))
+	assertContents(t, file, "allowed")
+
+	// an allowed host can't redirect the download to another host
+	err = mirror.Download(ctx, metalv1.Asset{URL: allowed.URL + "/disallowed"}, file)
+	require.ErrorIs(t, err, assets.ErrHostNotAllowed)
+	assertContents(t, file, "allowed")
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
@@ -0,0 +1,118 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package assets
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ulikunitz/xz"
+)
+
+// ukiDir is the directory of the Unified Kernel Images in the EFI system partition.
+const ukiDir = "EFI/Linux"
+
+// ErrNoUKI is returned when the EFI system partition doesn't contain a Unified Kernel Image.
+var ErrNoUKI = errors.New("no Unified Kernel Image found")
+
+// ExtractUKI extracts the Unified Kernel Image from the xz-compressed raw disk image to the file.
+//
+// It returns the name of the UKI in the EFI system partition.
+func ExtractUKI(image, file string) (string, error) {
+	f, err := os.Open(image)
+	if err != nil {
+		return "", err
+	}
+
+	defer f.Close() //nolint:errcheck
+
+	r, err := xz.NewReader(bufio.NewReader(f))
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress %q: %w", filepath.Base(image), err)
+	}
+
+	return ExtractUKIFromDisk(r, file)
+}
+
+// ExtractUKIFromDisk extracts the Unified Kernel Image from the raw disk image to the file.
+//
+// The disk is read sequentially up to the end of the EFI system partition, which is copied next to the file to be
+// read, so the decompressed image is never stored. The UKI embeds systemd-stub, so it's booted directly by the UEFI
+// firmware and systemd-boot is not needed.
+func ExtractUKIFromDisk(disk io.Reader, file string) (string, error) {
+	r := &sequentialReader{r: disk}
+
+	offset, size, err := findESP(r)
+	if err != nil {
+		return "", err
+	}
+
+	if err = r.skip(offset); err != nil {
+		return "", fmt.Errorf("failed to read EFI system partition: %w", err)
+	}
+
+	dir, base := filepath.Split(file)
+
+	removePartial(dir, base+"-esp")
+
+	esp, err := os.CreateTemp(dir, "."+base+"-esp.*"+partialSuffix)
+	if err != nil {
+		return "", err
+	}
+
+	defer os.Remove(esp.Name()) //nolint:errcheck
+	defer esp.Close()           //nolint:errcheck
+
+	if _, err = io.CopyN(esp, r, size); err != nil {
+		return "", fmt.Errorf("failed to read EFI system partition: %w", err)
+	}
+
+	fs, err := openFAT(esp)
+	if err != nil {
+		return "", err
+	}
+
+	uki, err := findUKI(fs)
+	if err != nil {
+		return "", err
+	}
+
+	return uki.name, Save(fs.open(uki), "", file)
+}
+
+// findUKI returns the UKI in the EFI system partition, the last one by name if there are several.
+func findUKI(fs *fatFS) (fatEntry, error) {
+	dir, err := fs.lookup(ukiDir)
+	if err != nil || !dir.dir {
+		return fatEntry{}, ErrNoUKI
+	}
+
+	entries, err := fs.readDir(dir)
+	if err != nil {
+		return fatEntry{}, err
+	}
+
+	var ukis []fatEntry
+
+	for _, entry := range entries {
+		if !entry.dir && strings.EqualFold(filepath.Ext(entry.name), ".efi") {
+			ukis = append(ukis, entry)
+		}
+	}
+
+	if len(ukis) == 0 {
+		return fatEntry{}, ErrNoUKI
+	}
+
+	sort.Slice(ukis, func(i, j int) bool { return ukis[i].name < ukis[j].name })
+
+	return ukis[len(ukis)-1], nil
+}
@@ -0,0 +1,374 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package assets_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ulikunitz/xz"
+
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/internal/assets"
+)
+
+func TestExtractUKI(t *testing.T) {
+	t.Parallel()
+
+	uki := strings.Repeat("MZ unified kernel image ", 100)
+
+	for _, test := range []struct {
+		name     string
+		fat32    bool
+		xz       bool
+		files    map[string]string
+		expected string
+		err      error
+	}{
+		{
+			name:  "FAT16 compressed",
+			fat32: false,
+			xz:    true,
+			files: map[string]string{
+				"EFI/boot/BOOTX64.efi":        "systemd-boot",
+				"EFI/Linux/Talos-v1.7.0.efi":  "old",
+				"EFI/Linux/Talos-v1.8.0.efi":  uki,
+				"EFI/Linux/README.txt":        "not an image",
+				"loader/loader.conf":          "timeout 10",
+				"loader/entries/default.conf": "",
+			},
+			expected: "Talos-v1.8.0.efi",
+		},
+		{
+			name:  "FAT32",
+			fat32: true,
+			files: map[string]string{
+				"EFI/boot/BOOTX64.efi":                 "systemd-boot",
+				"EFI/Linux/Talos-a-long-file-name.EFI": uki,
+			},
+			expected: "Talos-a-long-file-name.EFI",
+		},
+		{
+			name: "no UKI",
+			files: map[string]string{
+				"EFI/boot/BOOTX64.efi": "systemd-boot",
+			},
+			err: assets.ErrNoUKI,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			file := filepath.Join(dir, "uki.efi")
+			disk := gptDisk(fatImage(t, test.fat32, test.files))
+
+			var (
+				name string
+				err  error
+			)
+
+			if test.xz {
+				image := filepath.Join(dir, "boot.raw.xz")
+
+				require.NoError(t, os.WriteFile(image, compress(t, disk), 0o644))
+
+				name, err = assets.ExtractUKI(image, file)
+			} else {
+				name, err = assets.ExtractUKIFromDisk(bytes.NewReader(disk), file)
+			}
+
+			if test.err != nil {
+				require.ErrorIs(t, err, test.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, name)
+			assertContents(t, file, uki)
+
+			// temporary files are cleaned up
+			entries, err := os.ReadDir(dir)
+			require.NoError(t, err)
+
+			for _, entry := range entries {
+				assert.False(t, strings.HasPrefix(entry.Name(), "."), entry.Name())
+			}
+		})
+	}
+}
+
+func TestExtractUKINoESP(t *testing.T) {
+	t.Parallel()
+
+	_, err := assets.ExtractUKIFromDisk(bytes.NewReader(gptDisk(nil)), filepath.Join(t.TempDir(), "uki.efi"))
+	require.ErrorIs(t, err, assets.ErrNoESP)
+}
+
+func compress(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	var compressed bytes.Buffer
+
+	w, err := xz.NewWriter(&compressed)
+	require.NoError(t, err)
+
+	_, err = w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	return compressed.Bytes()
+}
+
+// gptDisk builds a GPT disk with a BIOS boot partition followed by the EFI system partition (if any).
+func gptDisk(esp []byte) []byte {
+	const (
+		sector   = 512
+		firstLBA = 2048
+	)
+
+	disk := make([]byte, firstLBA*sector+len(esp)+34*sector)
+
+	header := disk[sector:]
+	copy(header, "EFI PART")
+	binary.LittleEndian.PutUint32(header[12:], 92)
+	binary.LittleEndian.PutUint64(header[72:], 2)
+	binary.LittleEndian.PutUint32(header[80:], 128)
+	binary.LittleEndian.PutUint32(header[84:], 128)
+
+	entries := disk[2*sector:]
+
+	// BIOS boot partition 21686148-6449-6E6F-744E-656564454649
+	copy(entries, []byte{0x48, 0x61, 0x68, 0x21, 0x49, 0x64, 0x6f, 0x6e, 0x74, 0x4e, 0x65, 0x65, 0x64, 0x45, 0x46, 0x49})
+	binary.LittleEndian.PutUint64(entries[32:], 34)
+	binary.LittleEndian.PutUint64(entries[40:], firstLBA-1)
+
+	if esp != nil {
+		entry := entries[128:]
+
+		copy(entry, []byte{0x28, 0x73, 0x2a, 0xc1, 0x1f, 0xf8, 0xd2, 0x11, 0xba, 0x4b, 0x00, 0xa0, 0xc9, 0x3e, 0xc9, 0x3b})
+		binary.LittleEndian.PutUint64(entry[32:], firstLBA)
+		binary.LittleEndian.PutUint64(entry[40:], uint64(firstLBA+len(esp)/sector-1))
+
+		copy(disk[firstLBA*sector:], esp)
+	}
+
+	return disk
+}
+
+type fatNode struct {
+	children map[string]*fatNode
+	contents []byte
+}
+
+// fatImage builds a FAT16 or FAT32 file system with single-sector clusters, all names are stored as long file names.
+func fatImage(t *testing.T, fat32 bool, files map[string]string) []byte {
+	t.Helper()
+
+	const sector = 512
+
+	clusters, entrySize, reserved, rootEntries, eoc := 5000, 2, 1, 512, uint32(0xffff)
+	if fat32 {
+		clusters, entrySize, reserved, rootEntries, eoc = 66000, 4, 32, 0, 0x0fffffff
+	}
+
+	rootSectors := rootEntries * 32 / sector
+	fatSectors := ((clusters+2)*entrySize + sector - 1) / sector
+	total := reserved + fatSectors + rootSectors + clusters
+
+	image := make([]byte, total*sector)
+
+	copy(image, []byte{0xeb, 0x3c, 0x90})
+	binary.LittleEndian.PutUint16(image[11:], sector)
+	image[13] = 1
+	binary.LittleEndian.PutUint16(image[14:], uint16(reserved))
+	image[16] = 1
+	binary.LittleEndian.PutUint16(image[17:], uint16(rootEntries))
+	image[21] = 0xf8
+
+	if fat32 {
+		binary.LittleEndian.PutUint32(image[32:], uint32(total))
+		binary.LittleEndian.PutUint32(image[36:], uint32(fatSectors))
+		binary.LittleEndian.PutUint32(image[44:], 2)
+	} else {
+		binary.LittleEndian.PutUint16(image[19:], uint16(total))
+		binary.LittleEndian.PutUint16(image[22:], uint16(fatSectors))
+	}
+
+	image[510], image[511] = 0x55, 0xaa
+
+	fatOffset := reserved * sector
+	rootOffset := fatOffset + fatSectors*sector
+	dataOffset := rootOffset + rootSectors*sector
+
+	setFAT := func(cluster, value uint32) {
+		if fat32 {
+			binary.LittleEndian.PutUint32(image[fatOffset+int(cluster)*4:], value)
+		} else {
+			binary.LittleEndian.PutUint16(image[fatOffset+int(cluster)*2:], uint16(value))
+		}
+	}
+
+	next := uint32(2)
+
+	// the FAT32 root directory is a single cluster at cluster 2
+	if fat32 {
+		setFAT(2, eoc)
+
+		next++
+	}
+
+	alloc := func(data []byte) uint32 {
+		if len(data) == 0 {
+			return 0
+		}
+
+		n := uint32((len(data) + sector - 1) / sector)
+		first := next
+
+		for i := uint32(0); i < n; i++ {
+			if i == n-1 {
+				setFAT(first+i, eoc)
+			} else {
+				setFAT(first+i, first+i+1)
+			}
+		}
+
+		copy(image[dataOffset+int(first-2)*sector:], data)
+		next += n
+
+		return first
+	}
+
+	root := &fatNode{children: map[string]*fatNode{}}
+
+	for path, contents := range files {
+		node := root
+		elements := strings.Split(path, "/")
+
+		for _, element := range elements[:len(elements)-1] {
+			if node.children[element] == nil {
+				node.children[element] = &fatNode{children: map[string]*fatNode{}}
+			}
+
+			node = node.children[element]
+		}
+
+		node.children[elements[len(elements)-1]] = &fatNode{contents: []byte(contents)}
+	}
+
+	shortNames := 0
+
+	var writeDir func(node *fatNode) []byte
+
+	writeDir = func(node *fatNode) []byte {
+		names := make([]string, 0, len(node.children))
+
+		for name := range node.children {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		var entries []byte
+
+		for _, name := range names {
+			child := node.children[name]
+
+			short := []byte(fmt.Sprintf("N%07d   ", shortNames))
+			shortNames++
+
+			entry := make([]byte, 32)
+			copy(entry, short)
+
+			var size int
+
+			if child.children != nil {
+				entry[11] = 0x10
+
+				cluster := alloc(writeDir(child))
+				binary.LittleEndian.PutUint16(entry[20:], uint16(cluster>>16))
+				binary.LittleEndian.PutUint16(entry[26:], uint16(cluster))
+			} else {
+				entry[11] = 0x20
+				size = len(child.contents)
+
+				cluster := alloc(child.contents)
+				binary.LittleEndian.PutUint16(entry[20:], uint16(cluster>>16))
+				binary.LittleEndian.PutUint16(entry[26:], uint16(cluster))
+			}
+
+			binary.LittleEndian.PutUint32(entry[28:], uint32(size))
+
+			entries = append(entries, longNameEntries(name, short)...)
+			entries = append(entries, entry...)
+		}
+
+		return entries
+	}
+
+	rootEntriesData := writeDir(root)
+
+	if fat32 {
+		require.LessOrEqual(t, len(rootEntriesData), sector)
+		copy(image[dataOffset:], rootEntriesData)
+	} else {
+		require.LessOrEqual(t, len(rootEntriesData), rootSectors*sector)
+		copy(image[rootOffset:], rootEntriesData)
+	}
+
+	return image
+}
+
+func longNameEntries(name string, short []byte) []byte {
+	chars := utf16.Encode([]rune(name))
+
+	if len(chars)%13 != 0 {
+		chars = append(chars, 0)
+	}
+
+	for len(chars)%13 != 0 {
+		chars = append(chars, 0xffff)
+	}
+
+	var checksum byte
+
+	for _, c := range short[:11] {
+		checksum = (checksum&1)<<7 + checksum>>1 + c
+	}
+
+	n := len(chars) / 13
+
+	var entries []byte
+
+	for i := n; i >= 1; i-- {
+		entry := make([]byte, 32)
+
+		entry[0] = byte(i)
+		if i == n {
+			entry[0] |= 0x40
+		}
+
+		entry[11] = 0x0f
+		entry[13] = checksum
+
+		for j, offset := range []int{1, 3, 5, 7, 9, 14, 16, 18, 20, 22, 24, 28, 30} {
+			binary.LittleEndian.PutUint16(entry[offset:], chars[(i-1)*13+j])
+		}
+
+		entries = append(entries, entry...)
+	}
+
+	return entries
+}
@@ -0,0 +1,300 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package backup stores etcd snapshots in local directories and S3-compatible buckets.
+package backup
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned by sinks when the object doesn't exist.
+var ErrNotFound = errors.New("object not found")
+
+// Object describes an object stored in a sink.
+type Object struct {
+	Key  string
+	Size int64
+}
+
+// Sink stores the snapshots.
+//
+// Keys are slash-separated paths.
+type Sink interface {
+	// Put stores the object, replacing the existing one.
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	// Get opens the object, ErrNotFound is returned if it doesn't exist.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// List returns the objects with the key prefix.
+	List(ctx context.Context, prefix string) ([]Object, error)
+	// Delete removes the object, it's not an error if it doesn't exist.
+	Delete(ctx context.Context, key string) error
+}
+
+// Snapshot objects are stored as <cluster>/<cluster>-<timestamp>.db, and the checksum of each snapshot
+// is stored next to it as a sha256sum compatible <cluster>/<cluster>-<timestamp>.db.sha256 file.
+const (
+	snapshotExt     = ".db"
+	checksumExt     = ".sha256"
+	timestampFormat = "20060102T150405Z"
+)
+
+// Snapshot describes a stored snapshot.
+type Snapshot struct {
+	Name         string
+	Size         int64
+	SHA256       string
+	CreationTime time.Time
+}
+
+// SnapshotName returns the name of the cluster snapshot taken at the time.
+func SnapshotName(cluster string, t time.Time) string {
+	return path.Join(cluster, cluster+"-"+t.UTC().Format(timestampFormat)+snapshotExt)
+}
+
+// Save takes a snapshot with the take function, and stores it with its checksum.
+//
+// The snapshot is buffered in a temporary file, so that a partial snapshot is never stored,
+// and the size of the upload is known in advance.
+func Save(ctx context.Context, sink Sink, cluster string, now time.Time, take func(w io.Writer) error) (Snapshot, error) {
+	if err := validateCluster(cluster); err != nil {
+		return Snapshot{}, err
+	}
+
+	tmp, err := os.CreateTemp("", "etcd-snapshot-*")
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("error creating temporary file: %w", err)
+	}
+
+	defer os.Remove(tmp.Name()) //nolint:errcheck
+	defer tmp.Close()           //nolint:errcheck
+
+	hash := sha256.New()
+
+	if err = take(io.MultiWriter(tmp, hash)); err != nil {
+		return Snapshot{}, err
+	}
+
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("error reading snapshot size: %w", err)
+	}
+
+	if size == 0 {
+		return Snapshot{}, errors.New("snapshot is empty")
+	}
+
+	if _, err = tmp.Seek(0, io.SeekStart); err != nil {
+		return Snapshot{}, fmt.Errorf("error rewinding snapshot: %w", err)
+	}
+
+	snapshot := Snapshot{
+		Name:         SnapshotName(cluster, now),
+		Size:         size,
+		SHA256:       hex.EncodeToString(hash.Sum(nil)),
+		CreationTime: now.UTC().Truncate(time.Second),
+	}
+
+	if err = sink.Put(ctx, snapshot.Name, tmp, size); err != nil {
+		return Snapshot{}, fmt.Errorf("error storing snapshot %q: %w", snapshot.Name, err)
+	}
+
+	// the checksum is stored last, so a snapshot without the checksum is known to be incomplete
+	checksum := []byte(snapshot.SHA256 + "  " + path.Base(snapshot.Name) + "\n")
+
+	if err = sink.Put(ctx, snapshot.Name+checksumExt, bytes.NewReader(checksum), int64(len(checksum))); err != nil {
+		return Snapshot{}, fmt.Errorf("error storing checksum of snapshot %q: %w", snapshot.Name, err)
+	}
+
+	return snapshot, nil
+}
+
+// List returns the complete snapshots of the cluster, newest first.
+func List(ctx context.Context, sink Sink, cluster string) ([]Snapshot, error) {
+	if err := validateCluster(cluster); err != nil {
+		return nil, err
+	}
+
+	objects, err := sink.List(ctx, cluster+"/"+cluster+"-")
+	if err != nil {
+		return nil, fmt.Errorf("error listing snapshots of cluster %q: %w", cluster, err)
+	}
+
+	checksums := map[string]struct{}{}
+
+	for _, object := range objects {
+		if strings.HasSuffix(object.Key, snapshotExt+checksumExt) {
+			checksums[strings.TrimSuffix(object.Key, checksumExt)] = struct{}{}
+		}
+	}
+
+	var snapshots []Snapshot
+
+	for _, object := range objects {
+		if !strings.HasSuffix(object.Key, snapshotExt) {
+			continue
+		}
+
+		timestamp := strings.TrimSuffix(strings.TrimPrefix(object.Key, cluster+"/"+cluster+"-"), snapshotExt)
+
+		creationTime, err := time.Parse(timestampFormat, timestamp)
+		if err != nil {
+			// not a snapshot, e.g. a file copied to the sink by hand
+			continue
+		}
+
+		if _, ok := checksums[object.Key]; !ok {
+			continue
+		}
+
+		checksum, err := readChecksum(ctx, sink, object.Key)
+		if err != nil {
+			return nil, err
+		}
+
+		snapshots = append(snapshots, Snapshot{
+			Name:         object.Key,
+			Size:         object.Size,
+			SHA256:       checksum,
+			CreationTime: creationTime,
+		})
+	}
+
+	slices.SortFunc(snapshots, func(a, b Snapshot) int {
+		return b.CreationTime.Compare(a.CreationTime)
+	})
+
+	return snapshots, nil
+}
+
+// Prune removes the oldest snapshots of the cluster, keeping the given number of snapshots.
+//
+// The retained snapshots are returned, newest first.
+func Prune(ctx context.Context, sink Sink, cluster string, keep int) ([]Snapshot, error) {
+	snapshots, err := List(ctx, sink, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(snapshots) <= keep {
+		return snapshots, nil
+	}
+
+	for _, snapshot := range snapshots[keep:] {
+		// the checksum is removed first, so an interrupted removal leaves an incomplete snapshot
+		for _, key := range []string{snapshot.Name + checksumExt, snapshot.Name} {
+			if err = sink.Delete(ctx, key); err != nil {
+				return nil, fmt.Errorf("error removing snapshot %q: %w", snapshot.Name, err)
+			}
+		}
+	}
+
+	return snapshots[:keep], nil
+}
+
+// Open downloads the snapshot and verifies its checksum.
+//
+// The snapshot is buffered in a temporary file, which is removed when the returned reader is closed.
+func Open(ctx context.Context, sink Sink, name string) (io.ReadCloser, error) {
+	if !strings.HasSuffix(name, snapshotExt) {
+		return nil, fmt.Errorf("invalid snapshot name %q", name)
+	}
+
+	expected, err := readChecksum(ctx, sink, name)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := sink.Get(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("error reading snapshot %q: %w", name, err)
+	}
+
+	defer r.Close() //nolint:errcheck
+
+	tmp, err := os.CreateTemp("", "etcd-snapshot-*")
+	if err != nil {
+		return nil, fmt.Errorf("error creating temporary file: %w", err)
+	}
+
+	snapshot := &tempFile{tmp}
+
+	hash := sha256.New()
+
+	if _, err = io.Copy(io.MultiWriter(tmp, hash), r); err != nil {
+		snapshot.Close() //nolint:errcheck
+
+		return nil, fmt.Errorf("error reading snapshot %q: %w", name, err)
+	}
+
+	if actual := hex.EncodeToString(hash.Sum(nil)); actual != expected {
+		snapshot.Close() //nolint:errcheck
+
+		return nil, fmt.Errorf("checksum mismatch for snapshot %q: expected %s, got %s", name, expected, actual)
+	}
+
+	if _, err = tmp.Seek(0, io.SeekStart); err != nil {
+		snapshot.Close() //nolint:errcheck
+
+		return nil, fmt.Errorf("error rewinding snapshot: %w", err)
+	}
+
+	return snapshot, nil
+}
+
+func readChecksum(ctx context.Context, sink Sink, name string) (string, error) {
+	r, err := sink.Get(ctx, name+checksumExt)
+	if err != nil {
+		return "", fmt.Errorf("error reading checksum of snapshot %q: %w", name, err)
+	}
+
+	defer r.Close() //nolint:errcheck
+
+	data, err := io.ReadAll(io.LimitReader(r, 1024))
+	if err != nil {
+		return "", fmt.Errorf("error reading checksum of snapshot %q: %w", name, err)
+	}
+
+	checksum, _, _ := strings.Cut(string(data), " ")
+
+	if decoded, err := hex.DecodeString(checksum); err != nil || len(decoded) != sha256.Size {
+		return "", fmt.Errorf("invalid checksum of snapshot %q", name)
+	}
+
+	return checksum, nil
+}
+
+func validateCluster(cluster string) error {
+	if cluster == "" || cluster == "." || cluster == ".." || strings.ContainsAny(cluster, "/\\") {
+		return fmt.Errorf("invalid cluster name %q", cluster)
+	}
+
+	return nil
+}
+
+// tempFile removes the file on close.
+type tempFile struct {
+	*os.File
+}
+
+func (f *tempFile) Close() error {
+	err := f.File.Close()
+
+	if removeErr := os.Remove(f.File.Name()); removeErr != nil && err == nil {
+		err = removeErr
+	}
+
+	return err
+}
@@ -0,0 +1,295 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package backup_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/internal/backup"
+)
+
+// fakeS3 is an in-memory S3-compatible server supporting the path-style object requests used by the sink.
+type fakeS3 struct {
+	mu      sync.Mutex
+	bucket  string
+	objects map[string][]byte
+	// pageSize limits the number of objects in a list response to test pagination
+	pageSize int
+}
+
+func (s *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=access/") {
+		w.WriteHeader(http.StatusForbidden)
+
+		return
+	}
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != s.bucket {
+		w.WriteHeader(http.StatusNotFound)
+		io.WriteString(w, "<Error><Code>NoSuchBucket</Code><Message>The specified bucket does not exist</Message></Error>") //nolint:errcheck
+
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodGet && key == "":
+		s.list(w, r)
+	case r.Method == http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err != nil || int64(len(data)) != r.ContentLength {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		s.objects[key] = data
+	case r.Method == http.MethodGet:
+		data, ok := s.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		w.Write(data) //nolint:errcheck
+	case r.Method == http.MethodDelete:
+		delete(s.objects, key)
+
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *fakeS3) list(w http.ResponseWriter, r *http.Request) {
+	type content struct {
+		Key  string `xml:"Key"`
+		Size int64  `xml:"Size"`
+	}
+
+	var result struct {
+		XMLName               xml.Name  `xml:"ListBucketResult"`
+		Contents              []content `xml:"Contents"`
+		IsTruncated           bool      `xml:"IsTruncated"`
+		NextContinuationToken string    `xml:"NextContinuationToken,omitempty"`
+	}
+
+	keys := make([]string, 0, len(s.objects))
+
+	for key := range s.objects {
+		if strings.HasPrefix(key, r.URL.Query().Get("prefix")) {
+			keys = append(keys, key)
+		}
+	}
+
+	slices.Sort(keys)
+
+	start, _ := strconv.Atoi(r.URL.Query().Get("continuation-token")) //nolint:errcheck
+	keys = keys[start:]
+
+	if len(keys) > s.pageSize {
+		keys = keys[:s.pageSize]
+		result.IsTruncated = true
+		result.NextContinuationToken = strconv.Itoa(start + s.pageSize)
+	}
+
+	for _, key := range keys {
+		result.Contents = append(result.Contents, content{Key: key, Size: int64(len(s.objects[key]))})
+	}
+
+	xml.NewEncoder(w).Encode(result) //nolint:errcheck
+}
+
+func newS3Sink(t *testing.T) (*backup.S3Sink, *fakeS3) {
+	t.Helper()
+
+	s3 := &fakeS3{bucket: "backups", objects: map[string][]byte{}, pageSize: 2}
+
+	srv := httptest.NewServer(s3)
+	t.Cleanup(srv.Close)
+
+	sink, err := backup.NewS3Sink(backup.S3Config{
+		Endpoint:        srv.URL,
+		Bucket:          "backups",
+		Prefix:          "/etcd/",
+		AccessKeyID:     "access",
+		SecretAccessKey: "secret",
+	})
+	require.NoError(t, err)
+
+	return sink, s3
+}
+
+func TestSnapshots(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name    string
+		newSink func(t *testing.T) backup.Sink
+	}{
+		{
+			name: "local",
+			newSink: func(t *testing.T) backup.Sink {
+				return &backup.LocalSink{Root: filepath.Join(t.TempDir(), "spokane")}
+			},
+		},
+		{
+			name: "s3",
+			newSink: func(t *testing.T) backup.Sink {
+				sink, _ := newS3Sink(t)
+
+				return sink
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			sink := tt.newSink(t)
+
+			start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+			var saved []backup.Snapshot
+
+			for i := range 4 {
+				data := []byte(strings.Repeat("snapshot-"+strconv.Itoa(i), 100))
+
+				snapshot, err := backup.Save(ctx, sink, "production", start.Add(time.Duration(i)*time.Hour), func(w io.Writer) error {
+					_, err := w.Write(data)
+
+					return err
+				})
+				require.NoError(t, err)
+
+				sum := sha256.Sum256(data)
+
+				assert.Equal(t, hex.EncodeToString(sum[:]), snapshot.SHA256)
+				assert.EqualValues(t, len(data), snapshot.Size)
+
+				saved = append(saved, snapshot)
+			}
+
+			assert.Equal(t, "production/production-20240101T030000Z.db", saved[3].Name)
+
+			// snapshots of other clusters are not listed
+			_, err := backup.Save(ctx, sink, "production-east", start, func(w io.Writer) error {
+				_, err := w.Write([]byte("east"))
+
+				return err
+			})
+			require.NoError(t, err)
+
+			snapshots, err := backup.List(ctx, sink, "production")
+			require.NoError(t, err)
+			assert.Equal(t, []backup.Snapshot{saved[3], saved[2], saved[1], saved[0]}, snapshots)
+
+			retained, err := backup.Prune(ctx, sink, "production", 2)
+			require.NoError(t, err)
+			assert.Equal(t, []backup.Snapshot{saved[3], saved[2]}, retained)
+
+			snapshots, err = backup.List(ctx, sink, "production")
+			require.NoError(t, err)
+			assert.Equal(t, retained, snapshots)
+
+			r, err := backup.Open(ctx, sink, saved[3].Name)
+			require.NoError(t, err)
+
+			data, err := io.ReadAll(r)
+			require.NoError(t, err)
+			require.NoError(t, r.Close())
+
+			assert.Equal(t, []byte(strings.Repeat("snapshot-3", 100)), data)
+
+			_, err = backup.Open(ctx, sink, saved[0].Name)
+			require.ErrorIs(t, err, backup.ErrNotFound)
+		})
+	}
+}
+
+func TestSaveFailure(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	sink := &backup.LocalSink{Root: t.TempDir()}
+
+	_, err := backup.Save(ctx, sink, "production", time.Now(), func(w io.Writer) error {
+		w.Write([]byte("partial")) //nolint:errcheck
+
+		return io.ErrUnexpectedEOF
+	})
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	_, err = backup.Save(ctx, sink, "../production", time.Now(), func(io.Writer) error { return nil })
+	require.EqualError(t, err, `invalid cluster name "../production"`)
+
+	objects, err := sink.List(ctx, "")
+	require.NoError(t, err)
+	assert.Empty(t, objects)
+}
+
+func TestOpenChecksumMismatch(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	sink, s3 := newS3Sink(t)
+
+	snapshot, err := backup.Save(ctx, sink, "production", time.Now(), func(w io.Writer) error {
+		_, err := w.Write([]byte("snapshot"))
+
+		return err
+	})
+	require.NoError(t, err)
+
+	s3.mu.Lock()
+	s3.objects["etcd/"+snapshot.Name] = []byte("corrupted")
+	s3.mu.Unlock()
+
+	_, err = backup.Open(ctx, sink, snapshot.Name)
+	require.ErrorContains(t, err, "checksum mismatch")
+}
+
+func TestLocalSinkAtomicPut(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	root := t.TempDir()
+	sink := &backup.LocalSink{Root: root}
+
+	require.NoError(t, sink.Put(ctx, "a/b.db", bytes.NewReader([]byte("data")), 4))
+
+	// a failed write leaves neither the object nor the temporary file behind
+	require.Error(t, sink.Put(ctx, "a/c.db", iotest.ErrReader(io.ErrUnexpectedEOF), 4))
+
+	entries, err := os.ReadDir(filepath.Join(root, "a"))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "b.db", entries[0].Name())
+
+	require.EqualError(t, sink.Put(ctx, "../escape", bytes.NewReader(nil), 0), `invalid key "../escape"`)
+}
@@ -0,0 +1,375 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package backup
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DefaultS3Region is the region used to sign the requests when no region is set.
+const DefaultS3Region = "us-east-1"
+
+// unsignedPayload skips signing of the request body, which is accepted by S3 and S3-compatible servers.
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// S3Config configures S3Sink.
+type S3Config struct {
+	// Endpoint is the URL of the S3 endpoint.
+	Endpoint string
+	Bucket   string
+	// Prefix is prepended to the keys.
+	Prefix string
+	Region string
+
+	AccessKeyID     string
+	SecretAccessKey string
+
+	InsecureSkipVerify bool
+}
+
+// S3Sink stores the objects in an S3-compatible bucket.
+//
+// Path-style requests are used, so the sink works with S3-compatible servers like MinIO
+// without DNS set up for the bucket.
+type S3Sink struct {
+	endpoint *url.URL
+	client   *http.Client
+	cfg      S3Config
+
+	// now is overridden in tests
+	now func() time.Time
+}
+
+// NewS3Sink creates a new S3Sink.
+func NewS3Sink(cfg S3Config) (*S3Sink, error) {
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3 endpoint: %w", err)
+	}
+
+	if endpoint.Scheme != "http" && endpoint.Scheme != "https" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q: scheme should be either http or https", cfg.Endpoint)
+	}
+
+	if cfg.Bucket == "" {
+		return nil, errors.New("S3 bucket is not set")
+	}
+
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, errors.New("S3 credentials are not set")
+	}
+
+	if cfg.Region == "" {
+		cfg.Region = DefaultS3Region
+	}
+
+	cfg.Prefix = strings.Trim(cfg.Prefix, "/")
+
+	transport := http.DefaultTransport.(*http.Transport).Clone() //nolint:forcetypeassert
+
+	if cfg.InsecureSkipVerify {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec
+	}
+
+	return &S3Sink{
+		endpoint: endpoint,
+		client:   &http.Client{Transport: transport},
+		cfg:      cfg,
+		now:      time.Now,
+	}, nil
+}
+
+// Put implements Sink.
+func (sink *S3Sink) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	resp, err := sink.do(ctx, http.MethodPut, sink.objectKey(key), nil, io.NopCloser(r), size)
+	if err != nil {
+		return err
+	}
+
+	return resp.Body.Close()
+}
+
+// Get implements Sink.
+func (sink *S3Sink) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := sink.do(ctx, http.MethodGet, sink.objectKey(key), nil, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+// List implements Sink.
+func (sink *S3Sink) List(ctx context.Context, prefix string) ([]Object, error) {
+	var (
+		objects []Object
+		token   string
+	)
+
+	for {
+		query := url.Values{
+			"list-type": {"2"},
+			"prefix":    {sink.objectKey(prefix)},
+		}
+
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		resp, err := sink.do(ctx, http.MethodGet, "", query, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		var result struct {
+			Contents []struct {
+				Key  string `xml:"Key"`
+				Size int64  `xml:"Size"`
+			} `xml:"Contents"`
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+		}
+
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close() //nolint:errcheck
+
+		if err != nil {
+			return nil, fmt.Errorf("error decoding the list of objects: %w", err)
+		}
+
+		for _, object := range result.Contents {
+			key := object.Key
+
+			if sink.cfg.Prefix != "" {
+				key = strings.TrimPrefix(key, sink.cfg.Prefix+"/")
+			}
+
+			objects = append(objects, Object{Key: key, Size: object.Size})
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+
+		token = result.NextContinuationToken
+	}
+}
+
+// Delete implements Sink.
+func (sink *S3Sink) Delete(ctx context.Context, key string) error {
+	resp, err := sink.do(ctx, http.MethodDelete, sink.objectKey(key), nil, nil, 0)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+
+		return err
+	}
+
+	return resp.Body.Close()
+}
+
+func (sink *S3Sink) objectKey(key string) string {
+	if sink.cfg.Prefix == "" {
+		return key
+	}
+
+	return sink.cfg.Prefix + "/" + key
+}
+
+// do sends the signed request for the object key (or the bucket if the key is empty), and checks the response status.
+func (sink *S3Sink) do(ctx context.Context, method, key string, query url.Values, body io.ReadCloser, size int64) (*http.Response, error) {
+	u := *sink.endpoint
+
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + sink.cfg.Bucket
+	if key != "" {
+		u.Path += "/" + key
+	}
+
+	u.RawPath = s3Escape(u.Path, false)
+	u.RawQuery = canonicalQuery(query)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	if body != nil {
+		req.ContentLength = size
+	}
+
+	signV4(req, unsignedPayload, sink.cfg.AccessKeyID, sink.cfg.SecretAccessKey, sink.cfg.Region, sink.now())
+
+	resp, err := sink.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		return resp, nil
+	}
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode == http.StatusNotFound && key != "" {
+		return nil, ErrNotFound
+	}
+
+	var s3Err struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+
+	if err = xml.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&s3Err); err == nil && s3Err.Code != "" {
+		return nil, fmt.Errorf("S3 request %s %s failed: %s: %s", method, u.Path, s3Err.Code, s3Err.Message)
+	}
+
+	return nil, fmt.Errorf("S3 request %s %s failed: %s", method, u.Path, resp.Status)
+}
+
+// signV4 signs the request with AWS Signature Version 4.
+//
+// The host and all headers set on the request are signed.
+func signV4(req *http.Request, payloadHash, accessKeyID, secretAccessKey, region string, now time.Time) {
+	const (
+		algorithm = "AWS4-HMAC-SHA256"
+		service   = "s3"
+	)
+
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	headers := map[string]string{"host": host}
+
+	for name, values := range req.Header {
+		trimmed := make([]string, 0, len(values))
+
+		for _, value := range values {
+			trimmed = append(trimmed, strings.Join(strings.Fields(value), " "))
+		}
+
+		headers[strings.ToLower(name)] = strings.Join(trimmed, ",")
+	}
+
+	names := make([]string, 0, len(headers))
+
+	for name := range headers {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	var canonicalHeaders strings.Builder
+
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s3Escape(req.URL.Path, false),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+
+	stringToSign := strings.Join([]string{
+		algorithm,
+		amzDate,
+		scope,
+		hashHex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := []byte("AWS4" + secretAccessKey)
+
+	for _, part := range []string{date, region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s", algorithm, accessKeyID, scope, signedHeaders, signature))
+}
+
+// canonicalQuery encodes the query sorted by keys, as required by the signature.
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+
+	for key := range query {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	var parts []string
+
+	for _, key := range keys {
+		values := slices.Clone(query[key])
+		slices.Sort(values)
+
+		for _, value := range values {
+			parts = append(parts, s3Escape(key, true)+"="+s3Escape(value, true))
+		}
+	}
+
+	return strings.Join(parts, "&")
+}
+
+// s3Escape percent-encodes everything except the unreserved characters, and the slashes if encodeSlash is false.
+func s3Escape(s string, encodeSlash bool) string {
+	var b strings.Builder
+
+	for _, c := range []byte(s) {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+
+	return mac.Sum(nil)
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:])
+}
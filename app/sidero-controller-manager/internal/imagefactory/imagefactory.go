@@ -0,0 +1,159 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package imagefactory creates Talos Image Factory schematics and derives the boot asset URLs from them.
+package imagefactory
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultEndpoint is the public Image Factory.
+const DefaultEndpoint = "https://factory.talos.dev"
+
+const requestTimeout = 30 * time.Second
+
+// Schematic is the Image Factory schematic customization.
+//
+// The body is sent as JSON, which the Image Factory parses as YAML.
+type Schematic struct {
+	Customization Customization `json:"customization"`
+}
+
+// Customization of the Talos boot assets.
+type Customization struct {
+	ExtraKernelArgs  []string         `json:"extraKernelArgs,omitempty"`
+	Meta             []MetaValue      `json:"meta,omitempty"`
+	SystemExtensions SystemExtensions `json:"systemExtensions,omitempty"`
+}
+
+// MetaValue is a Talos META partition value.
+type MetaValue struct {
+	Key   uint8  `json:"key"`
+	Value string `json:"value"`
+}
+
+// SystemExtensions baked into the boot assets.
+type SystemExtensions struct {
+	OfficialExtensions []string `json:"officialExtensions,omitempty"`
+}
+
+// Client is an Image Factory API client.
+type Client struct {
+	endpoint   *url.URL
+	registry   string
+	httpClient *http.Client
+}
+
+// NewClient creates a client of the Image Factory endpoint.
+//
+// The registry serves the installer images, it defaults to the endpoint host.
+func NewClient(endpoint, registry string, insecureSkipVerify bool) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Image Factory endpoint: %w", err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid Image Factory endpoint scheme %q", u.Scheme)
+	}
+
+	if registry == "" {
+		registry = u.Host
+	}
+
+	httpClient := &http.Client{Timeout: requestTimeout}
+
+	if insecureSkipVerify {
+		transport := http.DefaultTransport.(*http.Transport).Clone()      //nolint:forcetypeassert
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec
+
+		httpClient.Transport = transport
+	}
+
+	return &Client{
+		endpoint:   u,
+		registry:   registry,
+		httpClient: httpClient,
+	}, nil
+}
+
+// CreateSchematic creates the schematic, and returns its ID.
+//
+// Schematic IDs are derived from their contents, so creating the same schematic again returns the same ID.
+func (c *Client) CreateSchematic(ctx context.Context, schematic Schematic) (string, error) {
+	body, err := json.Marshal(schematic)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint.JoinPath("schematics").String(), bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Content-Type", "application/yaml")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return "", err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("failed to create schematic: %d %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	var created struct {
+		ID string `json:"id"`
+	}
+
+	if err = json.Unmarshal(respBody, &created); err != nil {
+		return "", fmt.Errorf("failed to decode schematic response: %w", err)
+	}
+
+	if created.ID == "" {
+		return "", errors.New("empty schematic ID")
+	}
+
+	return created.ID, nil
+}
+
+// Assets are the boot assets of a schematic.
+type Assets struct {
+	Kernel    string
+	Initrd    string
+	BootAsset string
+	UKI       string
+	Installer string
+}
+
+// Assets returns the boot asset URLs and the installer image of the schematic.
+func (c *Client) Assets(schematicID, talosVersion, arch string) Assets {
+	image := c.endpoint.JoinPath("image", schematicID, talosVersion)
+
+	return Assets{
+		Kernel:    image.JoinPath("kernel-" + arch).String(),
+		Initrd:    image.JoinPath("initramfs-" + arch + ".xz").String(),
+		BootAsset: image.JoinPath("metal-" + arch + ".raw.xz").String(),
+		UKI:       image.JoinPath("metal-" + arch + "-uki.efi").String(),
+		Installer: fmt.Sprintf("%s/installer/%s:%s", c.registry, schematicID, talosVersion),
+	}
+}
@@ -0,0 +1,151 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package imagefactory_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/internal/imagefactory"
+)
+
+// factory is a stand-in for the Image Factory schematics API.
+func factory(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/schematics" {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		// the Image Factory parses the schematic as YAML
+		var schematic struct {
+			Customization struct {
+				ExtraKernelArgs []string `yaml:"extraKernelArgs"`
+				Meta            []struct {
+					Key   uint8  `yaml:"key"`
+					Value string `yaml:"value"`
+				} `yaml:"meta"`
+				SystemExtensions struct {
+					OfficialExtensions []string `yaml:"officialExtensions"`
+				} `yaml:"systemExtensions"`
+			} `yaml:"customization"`
+		}
+
+		if err = yaml.Unmarshal(body, &schematic); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		for _, extension := range schematic.Customization.SystemExtensions.OfficialExtensions {
+			if !strings.HasPrefix(extension, "siderolabs/") {
+				w.WriteHeader(http.StatusBadRequest)
+				io.WriteString(w, "unknown extension "+extension) //nolint:errcheck
+
+				return
+			}
+		}
+
+		canonical, err := yaml.Marshal(schematic)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		sum := sha256.Sum256(canonical)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, `{"id":"`+hex.EncodeToString(sum[:])+`"}`) //nolint:errcheck
+	}))
+
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestCreateSchematic(t *testing.T) {
+	t.Parallel()
+
+	srv := factory(t)
+
+	client, err := imagefactory.NewClient(srv.URL+"/", "", false)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	schematic := imagefactory.Schematic{
+		Customization: imagefactory.Customization{
+			ExtraKernelArgs: []string{"console=ttyS1"},
+			Meta:            []imagefactory.MetaValue{{Key: 0xa, Value: "{}"}},
+			SystemExtensions: imagefactory.SystemExtensions{
+				OfficialExtensions: []string{"siderolabs/iscsi-tools"},
+			},
+		},
+	}
+
+	id, err := client.CreateSchematic(ctx, schematic)
+	require.NoError(t, err)
+	assert.Len(t, id, 64)
+
+	// the ID is stable
+	again, err := client.CreateSchematic(ctx, schematic)
+	require.NoError(t, err)
+	assert.Equal(t, id, again)
+
+	// and depends on the customization
+	other, err := client.CreateSchematic(ctx, imagefactory.Schematic{})
+	require.NoError(t, err)
+	assert.NotEqual(t, id, other)
+
+	schematic.Customization.SystemExtensions.OfficialExtensions = []string{"example/unknown"}
+
+	_, err = client.CreateSchematic(ctx, schematic)
+	require.ErrorContains(t, err, "unknown extension example/unknown")
+}
+
+func TestAssets(t *testing.T) {
+	t.Parallel()
+
+	client, err := imagefactory.NewClient("https://factory.local/", "registry.local:5000", true)
+	require.NoError(t, err)
+
+	assert.Equal(t, imagefactory.Assets{
+		Kernel:    "https://factory.local/image/abcd/v1.11.5/kernel-arm64",
+		Initrd:    "https://factory.local/image/abcd/v1.11.5/initramfs-arm64.xz",
+		BootAsset: "https://factory.local/image/abcd/v1.11.5/metal-arm64.raw.xz",
+		UKI:       "https://factory.local/image/abcd/v1.11.5/metal-arm64-uki.efi",
+		Installer: "registry.local:5000/installer/abcd:v1.11.5",
+	}, client.Assets("abcd", "v1.11.5", "arm64"))
+
+	client, err = imagefactory.NewClient(imagefactory.DefaultEndpoint, "", false)
+	require.NoError(t, err)
+
+	assert.Equal(t, "factory.talos.dev/installer/abcd:v1.11.5", client.Assets("abcd", "v1.11.5", "amd64").Installer)
+
+	_, err = imagefactory.NewClient("factory.local", "", false)
+	require.Error(t, err)
+}
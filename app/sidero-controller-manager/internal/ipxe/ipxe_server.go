@@ -13,6 +13,7 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"text/template"
@@ -31,6 +32,7 @@ import (
 
 	infrav1 "github.com/siderolabs/sidero/app/caps-controller-manager/api/v1alpha3"
 	metalv1 "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/internal/assets"
 	"github.com/siderolabs/sidero/app/sidero-controller-manager/internal/siderolink"
 	"github.com/siderolabs/sidero/app/sidero-controller-manager/pkg/constants"
 	siderotypes "github.com/siderolabs/sidero/app/sidero-controller-manager/pkg/types"
@@ -96,21 +98,16 @@ set x:int32 0
 `))
 
 // ipxeTemplate is returned as response to `chain` request from the bootFile/bootTemplate to boot actual OS (or Sidero agent).
-// Updated to support both BootAsset (Talos 1.10+ with UKI/systemd-boot) and legacy kernel/initrd boot.
+// Supports both the UKI extracted from the BootAsset (Talos 1.10+, UEFI only) and legacy kernel/initrd boot.
 var ipxeTemplate = template.Must(template.New("iPXE config").Parse(`#!ipxe
-{{- if .UseBootAsset }}
-{{/* Talos 1.10+ Boot Asset (metal-amd64.raw.xz or custom UKI) */}}
-{{/* Boot asset is a disk image, use sanboot to boot it */}}
-echo Downloading boot asset from /env/{{ .Env.Name }}/{{ .BootAsset }}
-imgfetch /env/{{ .Env.Name }}/{{ .BootAsset }} || goto failed
-echo Booting from boot asset...
-sanboot --no-describe --drive 0x00 || goto failed
-goto failed
-
-:failed
-echo
-echo Failed to boot from boot asset
-echo Falling back to legacy boot...
+{{- if .UseUKI }}
+{{/* The UKI embeds systemd-stub, UEFI firmware boots it directly, the arguments override the embedded ones */}}
+iseq ${platform} efi || goto legacy
+echo Booting UKI from /env/{{ .Env.Name }}/{{ .UKIAsset }}
+chain /env/{{ .Env.Name }}/{{ .UKIAsset }} {{range $arg := .Env.Spec.Kernel.Args}} {{$arg}}{{end}} || goto legacy
+
+:legacy
+echo Falling back to kernel/initrd boot...
 {{- end }}
 {{/* Legacy kernel/initrd boot (BIOS or fallback) */}}
 kernel /env/{{ .Env.Name }}/{{ .KernelAsset }} {{range $arg := .Env.Spec.Kernel.Args}} {{$arg}}{{end}}
@@ -227,28 +224,28 @@ func ipxeHandler(w http.ResponseWriter, r *http.Request) {
 		log.Printf("Using %q environment", env.Name)
 	}
 
-	// Determine if we should use boot asset (Talos 1.10+ with UKI/systemd-boot)
-	// or fall back to legacy kernel/initrd boot
-	useBootAsset := env.Spec.BootAsset != nil && env.Spec.BootAsset.URL != ""
+	// UEFI servers chain-load the UKI extracted from the boot asset (Talos 1.10+),
+	// other servers fall back to legacy kernel/initrd boot
+	useUKI := env.Status.BootPath == metalv1.BootPathUKI
 
-	if useBootAsset {
-		log.Printf("Environment %q configured for boot asset (Talos 1.10+ UKI)", env.Name)
+	if useUKI {
+		log.Printf("Environment %q configured for UKI boot", env.Name)
 	} else {
 		log.Printf("Environment %q using legacy kernel/initrd boot", env.Name)
 	}
 
 	args := struct {
-		Env          *metalv1.Environment
-		UseBootAsset bool
-		BootAsset    string
-		KernelAsset  string
-		InitrdAsset  string
+		Env         *metalv1.Environment
+		UseUKI      bool
+		UKIAsset    string
+		KernelAsset string
+		InitrdAsset string
 	}{
-		Env:          env,
-		UseBootAsset: useBootAsset,
-		BootAsset:    constants.BootAsset,
-		KernelAsset:  constants.KernelAsset,
-		InitrdAsset:  constants.InitrdAsset,
+		Env:         env,
+		UseUKI:      useUKI,
+		UKIAsset:    constants.UKIAsset,
+		KernelAsset: constants.KernelAsset,
+		InitrdAsset: constants.InitrdAsset,
 	}
 
 	var buf bytes.Buffer
@@ -318,7 +315,7 @@ func RegisterIPXE(mux *http.ServeMux, endpoint string, port int, args string, bo
 
 	mux.Handle("/boot.ipxe", logRequest(http.HandlerFunc(bootFileHandler)))
 	mux.Handle("/ipxe", logRequest(http.HandlerFunc(ipxeHandler)))
-	mux.Handle("/env/", logRequest(http.StripPrefix("/env/", http.FileServer(http.Dir("/var/lib/sidero/env")))))
+	mux.Handle("/env/", logRequest(http.StripPrefix("/env/", assets.FileServer("/var/lib/sidero/env"))))
 	mux.Handle("/tftp/", logRequest(http.StripPrefix("/tftp/", http.FileServer(http.Dir("/var/lib/sidero/tftp")))))
 
 	return nil
@@ -511,6 +508,15 @@ func newEnvironmentFromServerClass(ctx context.Context, serverBinding *infrav1.S
 }
 
 func appendTalosArguments(env *metalv1.Environment) {
+	// the schematic kernel args are baked into the UKI, but the args passed by iPXE replace them
+	if env.Spec.Schematic != nil {
+		for _, arg := range env.Spec.Schematic.KernelArgs {
+			if !slices.Contains(env.Spec.Kernel.Args, arg) {
+				env.Spec.Kernel.Args = append(env.Spec.Kernel.Args, arg)
+			}
+		}
+	}
+
 	args := env.Spec.Kernel.Args
 
 	talosConfigPrefix := talosconstants.KernelParamConfig + "="
@@ -28,6 +28,10 @@ func fixture() []client.Object {
 		fixture4,
 		fixture5,
 		fixture6,
+		fixture7,
+		fixture8,
+		fixture9,
+		fixture10,
 	} {
 		objects = append(objects, fixture()...)
 	}
@@ -293,6 +297,163 @@ machine:
 	}
 }
 
+// fixture7 creates a server promoted from an adopted server.
+func fixture7() []client.Object {
+	objects := fixtureSimple("7777-8888-9999", 7, `
+version: v1alpha1
+machine:
+  kubelet: {}
+`)
+
+	for _, obj := range objects {
+		if server, ok := obj.(*metalv1.Server); ok {
+			server.Annotations = map[string]string{
+				metalv1.PromotedFromAnnotation: "adopted-7",
+			}
+		}
+	}
+
+	return objects
+}
+
+// fixture8 creates a server booted from an air-gapped environment with an image cache.
+func fixture8() []client.Object {
+	objects := fixtureSimple("8888-9999-0000", 8, `
+version: v1alpha1
+machine:
+  kubelet: {}
+`)
+
+	for _, obj := range objects {
+		if server, ok := obj.(*metalv1.Server); ok {
+			server.Spec.EnvironmentRef = &corev1.ObjectReference{
+				Name: "airgap",
+			}
+		}
+	}
+
+	return append(objects, &metalv1.Environment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "airgap",
+		},
+		Spec: metalv1.EnvironmentSpec{
+			AirGap: &metalv1.AirGapConfig{
+				Enabled: true,
+				RegistryMirrors: map[string]metalv1.RegistryMirror{
+					"docker.io": {
+						Endpoints: []string{"https://registry.local:5000"},
+					},
+				},
+				ImageCacheURL: "https://artifacts.local/talos/v1.11.5/image-cache.oci",
+			},
+		},
+	})
+}
+
+// fixture9 creates a server booted from an air-gapped environment with registry credentials and a private CA.
+func fixture9() []client.Object {
+	objects := fixtureSimple("9999-0000-1111", 9, `
+version: v1alpha1
+machine:
+  kubelet: {}
+`)
+
+	for _, obj := range objects {
+		if server, ok := obj.(*metalv1.Server); ok {
+			server.Spec.EnvironmentRef = &corev1.ObjectReference{
+				Name: "airgap-auth",
+			}
+		}
+	}
+
+	secretKeyRef := func(key string) *metalv1.CredentialSource {
+		return &metalv1.CredentialSource{
+			SecretKeyRef: &metalv1.SecretKeyRef{
+				Namespace: "default",
+				Name:      "registry-credentials",
+				Key:       key,
+			},
+		}
+	}
+
+	return append(objects,
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      "registry-credentials",
+			},
+			Data: map[string][]byte{
+				"username": []byte("sidero"),
+				"password": []byte("hunter2"),
+				"token":    []byte("identity-token"),
+				"ca":       []byte("-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"),
+			},
+		},
+		&metalv1.Environment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "airgap-auth",
+			},
+			Spec: metalv1.EnvironmentSpec{
+				AirGap: &metalv1.AirGapConfig{
+					Enabled: true,
+					RegistryMirrors: map[string]metalv1.RegistryMirror{
+						"docker.io": {
+							Endpoints:    []string{"https://registry.local:5000"},
+							UsernameFrom: secretKeyRef("username"),
+							PasswordFrom: secretKeyRef("password"),
+							CAFrom:       secretKeyRef("ca"),
+						},
+						"ghcr.io": {
+							Endpoints:  []string{"https://ghcr.local"},
+							TokenFrom:  secretKeyRef("token"),
+							SkipVerify: true,
+						},
+					},
+				},
+			},
+		},
+	)
+}
+
+// fixture10 creates a server booted from an air-gapped environment with conflicting registry credentials.
+func fixture10() []client.Object {
+	objects := fixtureSimple("0000-1111-3333", 10, `
+version: v1alpha1
+machine:
+  kubelet: {}
+`)
+
+	for _, obj := range objects {
+		if server, ok := obj.(*metalv1.Server); ok {
+			server.Spec.EnvironmentRef = &corev1.ObjectReference{
+				Name: "airgap-conflict",
+			}
+		}
+	}
+
+	return append(objects, &metalv1.Environment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "airgap-conflict",
+		},
+		Spec: metalv1.EnvironmentSpec{
+			AirGap: &metalv1.AirGapConfig{
+				Enabled: true,
+				RegistryMirrors: map[string]metalv1.RegistryMirror{
+					"docker.io": {
+						Endpoints: []string{"https://registry.local:5000"},
+						UsernameFrom: &metalv1.CredentialSource{
+							SecretKeyRef: &metalv1.SecretKeyRef{Namespace: "default", Name: "registry-credentials", Key: "username"},
+						},
+						TokenFrom: &metalv1.CredentialSource{
+							SecretKeyRef: &metalv1.SecretKeyRef{Namespace: "default", Name: "registry-credentials", Key: "token"},
+						},
+					},
+				},
+			},
+		},
+	})
+}
+
 func fixtureSimple(uuid string, index int, config string) []client.Object {
 	return []client.Object{
 		&infrav1.ServerBinding{
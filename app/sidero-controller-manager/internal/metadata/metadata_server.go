@@ -6,10 +6,15 @@ package metadata
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"log"
+	"maps"
 	"net/http"
+	"net/url"
+	"slices"
+	"strings"
 
 	"github.com/siderolabs/talos/pkg/machinery/config/configpatcher"
 	"gopkg.in/yaml.v3"
@@ -165,6 +170,20 @@ func (m *metadataConfigs) FetchConfig(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Servers promoted from adopted servers are already installed, and the bootstrap data is the configuration
+	// captured from the running node, so it is returned as is.
+	if _, promoted := serverObj.Annotations[metalv1.PromotedFromAnnotation]; promoted {
+		if _, err = w.Write(decodedData); err != nil {
+			log.Printf("failed to write data: %v", err)
+
+			return
+		}
+
+		log.Printf("successfully returned metadata for promoted server %q", uuid)
+
+		return
+	}
+
 	// Given a server object, see if it came from a serverclass (it will have an ownerref)
 	// If so, fetch the serverclass so we can use configPatches from it.
 	serverClassObj := &metalv1.ServerClass{}
@@ -227,8 +246,18 @@ func (m *metadataConfigs) FetchConfig(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	env, ewc := m.findEnvironment(ctx, serverObj, serverClassObj)
+	if ewc.errorObj != nil {
+		throwError(
+			w,
+			ewc,
+		)
+
+		return
+	}
+
 	// Inject registry mirrors for air-gap deployments (Talos 1.9+)
-	decodedData, ewc = m.injectRegistryMirrors(ctx, decodedData, serverObj, serverClassObj, serverBinding)
+	decodedData, ewc = m.injectRegistryMirrors(ctx, decodedData, env)
 	if ewc.errorObj != nil {
 		throwError(
 			w,
@@ -247,6 +276,16 @@ func (m *metadataConfigs) FetchConfig(w http.ResponseWriter, r *http.Request) {
 	log.Printf("successfully returned metadata for %q", uuid)
 }
 
+// ApplyPatches applies rfc6902 and strategic merge patches to the machine configuration.
+func ApplyPatches(config []byte, patches []metalv1.ConfigPatches, strategicPatches []string) ([]byte, error) {
+	patched, ewc := handlePatches(config, patches, strategicPatches)
+	if ewc.errorObj != nil {
+		return nil, ewc.errorObj
+	}
+
+	return patched, nil
+}
+
 // this function is responsible for applying rfc6902 and a strategic merge patch to bootstrap data.
 func handlePatches(decodedData []byte, patches []metalv1.ConfigPatches, strategicPatches []string) ([]byte, errorWithCode) {
 	var ewc errorWithCode
@@ -416,10 +455,8 @@ func (m *metadataConfigs) findMetalMachineServerBinding(ctx context.Context, ser
 	return metalMachine, serverBinding, errorWithCode{}
 }
 
-// injectRegistryMirrors injects container registry mirror configuration for air-gap deployments.
-// This supports Talos 1.9+ air-gap features by reading the Environment's AirGap configuration
-// and injecting registry mirrors into the machine config.
-func (m *metadataConfigs) injectRegistryMirrors(ctx context.Context, decodedData []byte, serverObj *metalv1.Server, serverClassObj *metalv1.ServerClass, serverBinding *infrav1.ServerBinding) ([]byte, errorWithCode) {
+// findEnvironment returns the Environment of the server, it's nil if the server has none and there's no default one.
+func (m *metadataConfigs) findEnvironment(ctx context.Context, serverObj *metalv1.Server, serverClassObj *metalv1.ServerClass) (*metalv1.Environment, errorWithCode) {
 	// Determine which Environment to use (same precedence as iPXE server)
 	var env *metalv1.Environment
 
@@ -458,9 +495,9 @@ func (m *metadataConfigs) injectRegistryMirrors(ctx context.Context, decodedData
 		}, env)
 		if err != nil {
 			if apierrors.IsNotFound(err) {
-				// No default environment, skip registry mirror injection
-				log.Printf("no default environment found, skipping registry mirror injection")
-				return decodedData, errorWithCode{}
+				// No default environment, skip air-gap injection
+				log.Printf("no default environment found, skipping air-gap injection")
+				return nil, errorWithCode{}
 			}
 
 			return nil, errorWithCode{
@@ -470,8 +507,18 @@ func (m *metadataConfigs) injectRegistryMirrors(ctx context.Context, decodedData
 		}
 	}
 
+	return env, errorWithCode{}
+}
+
+// injectRegistryMirrors injects container registry mirror configuration for air-gap deployments.
+// This supports Talos 1.9+ air-gap features by reading the Environment's AirGap configuration
+// and injecting registry mirrors into the machine config.
+//
+// Mirror credentials and CA bundles are resolved from their Secrets on every request, so the secret material
+// is only ever rendered into the machine config, and never stored on the Environment.
+func (m *metadataConfigs) injectRegistryMirrors(ctx context.Context, decodedData []byte, env *metalv1.Environment) ([]byte, errorWithCode) {
 	// Check if Environment has air-gap configuration with registry mirrors
-	if env.Spec.AirGap == nil || !env.Spec.AirGap.Enabled || len(env.Spec.AirGap.RegistryMirrors) == 0 {
+	if env == nil || env.Spec.AirGap == nil || !env.Spec.AirGap.Enabled || len(env.Spec.AirGap.RegistryMirrors) == 0 {
 		// No air-gap configuration or no registry mirrors, skip injection
 		return decodedData, errorWithCode{}
 	}
@@ -486,28 +533,75 @@ func (m *metadataConfigs) injectRegistryMirrors(ctx context.Context, decodedData
 	//       docker.io:
 	//         endpoints:
 	//           - https://registry.local:5000/docker.io
-	//         skipVerify: false
 	//         overridePath: false
-	mirrors := make(map[string]map[string]any)
-	for registry, config := range env.Spec.AirGap.RegistryMirrors {
+	//     config:
+	//       registry.local:5000:
+	//         auth:
+	//           username: sidero
+	//           password: secret
+	//         tls:
+	//           ca: <base64 PEM>
+	//           insecureSkipVerify: false
+	// ---
+	// apiVersion: v1alpha1
+	// kind: TrustedRootsConfig
+	// name: registry-docker.io
+	// certificates: <PEM>
+	registries := slices.Sorted(maps.Keys(env.Spec.AirGap.RegistryMirrors))
+
+	mirrors := make(map[string]map[string]any, len(registries))
+	configs := make(map[string]map[string]any)
+	trustedRoots := []map[string]any{}
+
+	for _, registry := range registries {
+		config := env.Spec.AirGap.RegistryMirrors[registry]
+
 		mirrorConfig := map[string]any{
 			"endpoints": config.Endpoints,
 		}
-		if config.SkipVerify {
-			mirrorConfig["skipVerify"] = true
-		}
 		if config.OverridePath {
 			mirrorConfig["overridePath"] = true
 		}
 		mirrors[registry] = mirrorConfig
+
+		registryConfig, ca, err := m.registryConfig(ctx, config)
+		if err != nil {
+			return nil, errorWithCode{
+				http.StatusInternalServerError,
+				fmt.Errorf("failure resolving registry mirror %q credentials for environment %q: %s", registry, env.Name, err),
+			}
+		}
+
+		if len(registryConfig) == 0 {
+			continue
+		}
+
+		// Talos looks up the auth and TLS configuration by the endpoint host
+		for _, endpoint := range config.Endpoints {
+			configs[endpointHost(endpoint)] = registryConfig
+		}
+
+		if ca != "" {
+			trustedRoots = append(trustedRoots, map[string]any{
+				"apiVersion":   "v1alpha1",
+				"kind":         "TrustedRootsConfig",
+				"name":         trustedRootsName(registry),
+				"certificates": ca,
+			})
+		}
+	}
+
+	registriesPatch := map[string]any{
+		"mirrors": mirrors,
+	}
+	if len(configs) > 0 {
+		registriesPatch["config"] = configs
 	}
 
 	// Create strategic merge patch
 	patchData := map[string]any{
 		"machine": map[string]any{
-			"registries": map[string]any{
-				"mirrors": mirrors,
-			},
+			"registries": registriesPatch,
 		},
 	}
 
@@ -519,12 +613,107 @@ func (m *metadataConfigs) injectRegistryMirrors(ctx context.Context, decodedData
 		}
 	}
 
+	// The trusted roots are appended as separate documents of the same patch
+	for _, trustedRoot := range trustedRoots {
+		docBytes, err := yaml.Marshal(trustedRoot)
+		if err != nil {
+			return nil, errorWithCode{
+				http.StatusInternalServerError,
+				fmt.Errorf("failure marshaling trusted roots patch: %s", err),
+			}
+		}
+
+		patchBytes = append(append(patchBytes, "---\n"...), docBytes...)
+	}
+
 	log.Printf("applying registry mirror patch for %d registries", len(mirrors))
 
 	// Apply the patch using the same patchConfig function used for other patches
 	return patchConfig(decodedData, patchBytes)
 }
 
+// registryConfig builds the Talos auth and TLS configuration of the mirror, and returns the resolved CA bundle.
+//
+// Errors never include the resolved values.
+func (m *metadataConfigs) registryConfig(ctx context.Context, mirror metalv1.RegistryMirror) (map[string]any, string, error) {
+	if mirror.TokenFrom != nil && (mirror.UsernameFrom != nil || mirror.PasswordFrom != nil) {
+		return nil, "", fmt.Errorf("tokenFrom cannot be used with usernameFrom and passwordFrom")
+	}
+
+	username, err := mirror.UsernameFrom.Resolve(ctx, m.client)
+	if err != nil {
+		return nil, "", err
+	}
+
+	password, err := mirror.PasswordFrom.Resolve(ctx, m.client)
+	if err != nil {
+		return nil, "", err
+	}
+
+	token, err := mirror.TokenFrom.Resolve(ctx, m.client)
+	if err != nil {
+		return nil, "", err
+	}
+
+	ca, err := mirror.CAFrom.Resolve(ctx, m.client)
+	if err != nil {
+		return nil, "", err
+	}
+
+	registryConfig := map[string]any{}
+
+	auth := map[string]any{}
+	if username != "" {
+		auth["username"] = username
+	}
+	if password != "" {
+		auth["password"] = password
+	}
+	if token != "" {
+		auth["identityToken"] = token
+	}
+	if len(auth) > 0 {
+		registryConfig["auth"] = auth
+	}
+
+	tls := map[string]any{}
+	if ca != "" {
+		tls["ca"] = base64.StdEncoding.EncodeToString([]byte(ca))
+	}
+	if mirror.SkipVerify {
+		tls["insecureSkipVerify"] = true
+	}
+	if len(tls) > 0 {
+		registryConfig["tls"] = tls
+	}
+
+	return registryConfig, ca, nil
+}
+
+// endpointHost returns the host (and port) of the mirror endpoint.
+func endpointHost(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return endpoint
+	}
+
+	return u.Host
+}
+
+// trustedRootsName returns the TrustedRootsConfig document name of the registry CA bundle.
+func trustedRootsName(registry string) string {
+	return "registry-" + strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '.':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		default:
+			return '-'
+		}
+	}, registry)
+}
+
 // fetchBootstrapSecret is responsible for fetching a secret that contains the bootstrap data created by our bootstrap provider.
 func (m *metadataConfigs) fetchBootstrapSecret(ctx context.Context, secretNSN types.NamespacedName) ([]byte, errorWithCode) {
 	bootstrapSecretData := &v1.Secret{}
@@ -13,6 +13,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	apiextensions "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	capiv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -108,6 +109,34 @@ func TestMetadataService(t *testing.T) {
 			expectedCode: http.StatusOK,
 			expectedBody: "cluster: null\nmachine:\n  certSANs: []\n  kubelet:\n    extraArgs:\n      node-labels: foo=bar,metal.sidero.dev/uuid=6666-7777-8888\n  network:\n    hostname: example6\n  token: \"\"\n  type: \"\"\nversion: v1alpha1\n",
 		},
+		{
+			name: "promoted server",
+			path: "/configdata?uuid=7777-8888-9999",
+
+			expectedCode: http.StatusOK,
+			expectedBody: "\nversion: v1alpha1\nmachine:\n  kubelet: {}\n",
+		},
+		{
+			name: "air-gapped environment",
+			path: "/configdata?uuid=8888-9999-0000",
+
+			expectedCode: http.StatusOK,
+			expectedBody: "version: v1alpha1\nmachine:\n    type: \"\"\n    token: \"\"\n    certSANs: []\n    kubelet:\n        extraArgs:\n            node-labels: metal.sidero.dev/uuid=8888-9999-0000\n    registries:\n        mirrors:\n            docker.io:\n                endpoints:\n                    - https://registry.local:5000\ncluster: null\n",
+		},
+		{
+			name: "air-gapped environment with registry credentials",
+			path: "/configdata?uuid=9999-0000-1111",
+
+			expectedCode: http.StatusOK,
+			expectedBody: "version: v1alpha1\nmachine:\n    type: \"\"\n    token: \"\"\n    certSANs: []\n    kubelet:\n        extraArgs:\n            node-labels: metal.sidero.dev/uuid=9999-0000-1111\n    registries:\n        mirrors:\n            docker.io:\n                endpoints:\n                    - https://registry.local:5000\n            ghcr.io:\n                endpoints:\n                    - https://ghcr.local\n        config:\n            ghcr.local:\n                tls:\n                    insecureSkipVerify: true\n                auth:\n                    identityToken: identity-token\n            registry.local:5000:\n                tls:\n                    ca: LS0tLS1CRUdJTiBDRVJUSUZJQ0FURS0tLS0tCk1JSUIKLS0tLS1FTkQgQ0VSVElGSUNBVEUtLS0tLQo=\n                auth:\n                    username: sidero\n                    password: hunter2\ncluster: null\n---\napiVersion: v1alpha1\nkind: TrustedRootsConfig\nname: registry-docker.io\ncertificates: |\n    -----BEGIN CERTIFICATE-----\n    MIIB\n    -----END CERTIFICATE-----\n",
+		},
+		{
+			name: "air-gapped environment with conflicting registry credentials",
+			path: "/configdata?uuid=0000-1111-3333",
+
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: "failure resolving registry mirror \"docker.io\" credentials for environment \"airgap-conflict\": tokenFrom cannot be used with usernameFrom and passwordFrom\n",
+		},
 	} {
 		test := test
 
@@ -127,3 +156,27 @@ func TestMetadataService(t *testing.T) {
 		})
 	}
 }
+
+func TestApplyPatches(t *testing.T) {
+	t.Parallel()
+
+	config := []byte("version: v1alpha1\nmachine:\n  type: worker\n  network:\n    hostname: node-1\ncluster:\n  clusterName: spokane\n")
+
+	patched, err := metadata.ApplyPatches(config,
+		[]metalv1.ConfigPatches{
+			{
+				Op:    "replace",
+				Path:  "/machine/network/hostname",
+				Value: apiextensions.JSON{Raw: []byte(`"node-2"`)},
+			},
+		},
+		[]string{"cluster:\n  clusterName: seattle\n"},
+	)
+	require.NoError(t, err)
+
+	assert.Contains(t, string(patched), "hostname: node-2")
+	assert.Contains(t, string(patched), "clusterName: seattle")
+
+	_, err = metadata.ApplyPatches(config, []metalv1.ConfigPatches{{Op: "remove", Path: "/machine/missing"}}, nil)
+	require.Error(t, err)
+}
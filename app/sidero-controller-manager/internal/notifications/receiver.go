@@ -0,0 +1,352 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package notifications implements the receiver of the change notifications sent by the Management API.
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	metalv1 "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/pkg/managementapi"
+)
+
+// Path is the path of the receiver on the HTTP mux.
+const Path = "/management-api/notifications"
+
+// SecretKey is the key of the shared HMAC secret in the receiver secret.
+const SecretKey = "webhook-secret"
+
+const maxBodySize = 1 << 20
+
+// errNotFound is returned when the notification doesn't match any object.
+var errNotFound = errors.New("not found")
+
+// Receiver verifies the notifications and turns them into reconcile requests.
+type Receiver struct {
+	client    client.Client
+	secretRef types.NamespacedName
+	events    chan<- event.GenericEvent
+	now       func() time.Time
+
+	// received are the signatures of the notifications accepted recently, by expiration time.
+	receivedMu sync.Mutex
+	received   map[string]time.Time
+}
+
+// RegisterReceiver registers the receiver on the mux.
+//
+// The shared HMAC secret is read from the secret on each notification, so it can be rotated.
+// Reconcile requests of the AdoptedServers are sent to the events channel.
+func RegisterReceiver(mux *http.ServeMux, k8sClient client.Client, secretRef types.NamespacedName, events chan<- event.GenericEvent) error {
+	if secretRef.Name == "" {
+		return fmt.Errorf("secret name is required")
+	}
+
+	receiver := &Receiver{
+		client:    k8sClient,
+		secretRef: secretRef,
+		events:    events,
+		now:       time.Now,
+		received:  map[string]time.Time{},
+	}
+
+	mux.HandleFunc(Path, receiver.ServeHTTP)
+
+	return nil
+}
+
+// ServeHTTP implements http.Handler.
+func (receiver *Receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+
+		return
+	}
+
+	ctx := r.Context()
+
+	secret, err := receiver.secret(ctx)
+	if err != nil {
+		log.Printf("management API notification rejected: %s", err)
+		http.Error(w, "receiver is not configured", http.StatusServiceUnavailable)
+
+		return
+	}
+
+	if err = managementapi.VerifyNotification(secret, r.Header.Get(managementapi.SignatureHeader), r.Header.Get(managementapi.TimestampHeader), body, receiver.now()); err != nil {
+		log.Printf("management API notification rejected: %s", err)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+
+		return
+	}
+
+	signature := r.Header.Get(managementapi.SignatureHeader)
+
+	if !receiver.receive(signature) {
+		log.Printf("management API notification rejected: already received")
+		http.Error(w, "notification was already received", http.StatusConflict)
+
+		return
+	}
+
+	var notification managementapi.Notification
+
+	if err = json.Unmarshal(body, &notification); err != nil {
+		receiver.forget(signature)
+		http.Error(w, fmt.Sprintf("failed to decode notification: %s", err), http.StatusBadRequest)
+
+		return
+	}
+
+	target, err := receiver.handle(ctx, &notification)
+	if err != nil {
+		// the notification wasn't applied, so that it can be sent again
+		receiver.forget(signature)
+	}
+
+	switch {
+	case err == nil:
+	case errors.Is(err, errNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+
+		return
+	case apierrors.IsBadRequest(err):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	default:
+		log.Printf("management API notification %q failed: %s", notification.Event, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+
+	json.NewEncoder(w).Encode(map[string]string{"target": target}) //nolint:errcheck
+}
+
+// receive records the signature of the notification, and returns false if it was already received.
+//
+// The signature covers the timestamp, so a notification can't be replayed with the same signature after
+// its timestamp is out of the allowed window: the signatures are kept only as long.
+func (receiver *Receiver) receive(signature string) bool {
+	receiver.receivedMu.Lock()
+	defer receiver.receivedMu.Unlock()
+
+	now := receiver.now()
+
+	for received, expires := range receiver.received {
+		if now.After(expires) {
+			delete(receiver.received, received)
+		}
+	}
+
+	if _, ok := receiver.received[signature]; ok {
+		return false
+	}
+
+	// the timestamp is at most MaxNotificationAge in the future
+	receiver.received[signature] = now.Add(2 * managementapi.MaxNotificationAge)
+
+	return true
+}
+
+// forget removes the signature of a notification which failed, so that it's accepted again.
+func (receiver *Receiver) forget(signature string) {
+	receiver.receivedMu.Lock()
+	defer receiver.receivedMu.Unlock()
+
+	delete(receiver.received, signature)
+}
+
+func (receiver *Receiver) secret(ctx context.Context) ([]byte, error) {
+	var secret corev1.Secret
+
+	if err := receiver.client.Get(ctx, receiver.secretRef, &secret); err != nil {
+		return nil, fmt.Errorf("failed to get secret %s: %w", receiver.secretRef, err)
+	}
+
+	key := secret.Data[SecretKey]
+	if len(key) == 0 {
+		return nil, fmt.Errorf("secret %s has no %q key", receiver.secretRef, SecretKey)
+	}
+
+	return key, nil
+}
+
+// handle applies the notification, and returns the name of the reconciled object.
+//
+//   - adopt clears the RemovedUpstream condition of the AdoptedServer, or requests a sync of the ManagementAPIInventories
+//     of the cluster if there is no AdoptedServer yet;
+//   - labels merges the labels into the AdoptedServer labels, unless Sidero is their source of truth;
+//   - remove sets the RemovedUpstream condition, the AdoptedServer is never deleted;
+//   - sync requests a sync of the AdoptedServer, which is synced on the next reconcile even if the sync is batched.
+func (receiver *Receiver) handle(ctx context.Context, notification *managementapi.Notification) (string, error) {
+	switch notification.Event {
+	case managementapi.EventAdopt, managementapi.EventLabels, managementapi.EventRemove, managementapi.EventSync:
+	default:
+		return "", apierrors.NewBadRequest(fmt.Sprintf("unsupported event %q", notification.Event))
+	}
+
+	if notification.Server == "" && notification.NodeID == "" {
+		return "", apierrors.NewBadRequest("either server or node_id is required")
+	}
+
+	as, err := receiver.findAdoptedServer(ctx, notification)
+	if err != nil {
+		if errors.Is(err, errNotFound) && notification.Event == managementapi.EventAdopt {
+			return receiver.requestInventorySync(ctx, notification)
+		}
+
+		return "", err
+	}
+
+	patchHelper, err := patch.NewHelper(as, receiver.client)
+	if err != nil {
+		return "", err
+	}
+
+	switch notification.Event {
+	case managementapi.EventAdopt:
+		conditions.Delete(as, metalv1.ConditionRemovedUpstream)
+	case managementapi.EventLabels:
+		managementapi.ApplyServerInfo(as, &managementapi.ServerInfo{Labels: notification.Labels}, receiver.sourceOfTruth(ctx, as))
+	case managementapi.EventRemove:
+		conditions.Set(as, &clusterv1.Condition{
+			Type:    metalv1.ConditionRemovedUpstream,
+			Status:  corev1.ConditionTrue,
+			Message: "Server was removed from the Management API",
+		})
+	case managementapi.EventSync:
+		metav1.SetMetaDataAnnotation(&as.ObjectMeta, metalv1.SyncRequestedAnnotation, receiver.now().UTC().Format(time.RFC3339))
+	}
+
+	if err = patchHelper.Patch(ctx, as, patch.WithOwnedConditions{
+		Conditions: []clusterv1.ConditionType{metalv1.ConditionRemovedUpstream},
+	}); err != nil {
+		return "", fmt.Errorf("failed to update adopted server %q: %w", as.Name, err)
+	}
+
+	select {
+	case receiver.events <- event.GenericEvent{Object: as}:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+
+	return as.Name, nil
+}
+
+func (receiver *Receiver) findAdoptedServer(ctx context.Context, notification *managementapi.Notification) (*metalv1.AdoptedServer, error) {
+	if notification.Server != "" {
+		var as metalv1.AdoptedServer
+
+		if err := receiver.client.Get(ctx, types.NamespacedName{Name: notification.Server}, &as); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, fmt.Errorf("adopted server %q %w", notification.Server, errNotFound)
+			}
+
+			return nil, err
+		}
+
+		return &as, nil
+	}
+
+	var list metalv1.AdoptedServerList
+
+	if err := receiver.client.List(ctx, &list); err != nil {
+		return nil, err
+	}
+
+	for i := range list.Items {
+		if status := list.Items[i].Status.ManagementAPIStatus; status != nil && status.NodeID == notification.NodeID {
+			return &list.Items[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("adopted server with node ID %q %w", notification.NodeID, errNotFound)
+}
+
+// sourceOfTruth returns the policy of the ManagementAPIInventory which created the AdoptedServer.
+func (receiver *Receiver) sourceOfTruth(ctx context.Context, as *metalv1.AdoptedServer) metalv1.InventorySourceOfTruth {
+	name := as.Labels[metalv1.ManagementAPIInventoryLabel]
+	if name == "" {
+		return metalv1.InventorySourceOfTruth{}
+	}
+
+	var inventory metalv1.ManagementAPIInventory
+
+	if err := receiver.client.Get(ctx, types.NamespacedName{Name: name}, &inventory); err != nil {
+		return metalv1.InventorySourceOfTruth{}
+	}
+
+	return inventory.Spec.SourceOfTruth
+}
+
+// requestInventorySync annotates the ManagementAPIInventories of the cluster, so that the adopted server is created.
+func (receiver *Receiver) requestInventorySync(ctx context.Context, notification *managementapi.Notification) (string, error) {
+	var list metalv1.ManagementAPIInventoryList
+
+	if err := receiver.client.List(ctx, &list); err != nil {
+		return "", err
+	}
+
+	requested := receiver.now().UTC().Format(time.RFC3339)
+
+	var target string
+
+	for i := range list.Items {
+		inventory := &list.Items[i]
+
+		if notification.ClusterName == "" || inventory.Spec.ClusterName != notification.ClusterName {
+			continue
+		}
+
+		patchHelper, err := patch.NewHelper(inventory, receiver.client)
+		if err != nil {
+			return "", err
+		}
+
+		metav1.SetMetaDataAnnotation(&inventory.ObjectMeta, metalv1.SyncRequestedAnnotation, requested)
+
+		if err = patchHelper.Patch(ctx, inventory); err != nil {
+			return "", fmt.Errorf("failed to update inventory %q: %w", inventory.Name, err)
+		}
+
+		target = inventory.Name
+	}
+
+	if target == "" {
+		return "", fmt.Errorf("adopted server or inventory of cluster %q %w", notification.ClusterName, errNotFound)
+	}
+
+	return target, nil
+}
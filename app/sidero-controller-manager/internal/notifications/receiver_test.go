@@ -0,0 +1,226 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package notifications_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	metalv1 "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/internal/notifications"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/pkg/managementapi"
+)
+
+var secret = []byte("s3cr3t")
+
+func setup(t *testing.T) (client.Client, *httptest.Server, <-chan event.GenericEvent) {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, metalv1.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&metalv1.AdoptedServer{}, &metalv1.ManagementAPIInventory{}).
+		WithObjects(
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "management-api-webhook", Namespace: "sidero-system"},
+				Data:       map[string][]byte{notifications.SecretKey: secret},
+			},
+			&metalv1.AdoptedServer{
+				ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+				Spec: metalv1.AdoptedServerSpec{
+					Labels: map[string]string{"zone": "a"},
+				},
+			},
+			&metalv1.AdoptedServer{
+				ObjectMeta: metav1.ObjectMeta{Name: "node-2"},
+				Status: metalv1.AdoptedServerStatus{
+					ManagementAPIStatus: &metalv1.ManagementAPIStatus{NodeID: "n-2"},
+				},
+			},
+			&metalv1.ManagementAPIInventory{
+				ObjectMeta: metav1.ObjectMeta{Name: "prod"},
+				Spec:       metalv1.ManagementAPIInventorySpec{ClusterName: "prod"},
+			},
+		).
+		Build()
+
+	events := make(chan event.GenericEvent, 10)
+
+	mux := http.NewServeMux()
+
+	require.Error(t, notifications.RegisterReceiver(mux, fakeClient, types.NamespacedName{}, events))
+	require.NoError(t, notifications.RegisterReceiver(mux, fakeClient, types.NamespacedName{Namespace: "sidero-system", Name: "management-api-webhook"}, events))
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return fakeClient, srv, events
+}
+
+func send(t *testing.T, srv *httptest.Server, key []byte, timestamp time.Time, notification managementapi.Notification) int {
+	t.Helper()
+
+	body, err := json.Marshal(notification)
+	require.NoError(t, err)
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodPost, srv.URL+notifications.Path, bytes.NewReader(body))
+	require.NoError(t, err)
+
+	req.Header.Set(managementapi.SignatureHeader, managementapi.SignNotification(key, timestamp, body))
+	req.Header.Set(managementapi.TimestampHeader, strconv.FormatInt(timestamp.Unix(), 10))
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+
+	require.NoError(t, resp.Body.Close())
+
+	return resp.StatusCode
+}
+
+func TestReceiverAuthentication(t *testing.T) {
+	t.Parallel()
+
+	fakeClient, srv, events := setup(t)
+
+	sync := managementapi.Notification{Event: managementapi.EventSync, Server: "node-1"}
+	now := time.Now()
+
+	for _, test := range []struct {
+		name      string
+		key       []byte
+		timestamp time.Time
+
+		expectedCode int
+	}{
+		{
+			name:      "valid",
+			key:       secret,
+			timestamp: now,
+
+			expectedCode: http.StatusAccepted,
+		},
+		{
+			name:      "replayed signature",
+			key:       secret,
+			timestamp: now,
+
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name:      "wrong secret",
+			key:       []byte("wrong"),
+			timestamp: time.Now(),
+
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:      "replayed",
+			key:       secret,
+			timestamp: time.Now().Add(-time.Hour),
+
+			expectedCode: http.StatusUnauthorized,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expectedCode, send(t, srv, test.key, test.timestamp, sync))
+		})
+	}
+
+	require.Len(t, events, 1)
+	assert.Equal(t, "node-1", (<-events).Object.GetName())
+
+	// the sync is requested, so that it's not skipped by the batched sync
+	var as metalv1.AdoptedServer
+
+	require.NoError(t, fakeClient.Get(context.Background(), types.NamespacedName{Name: "node-1"}, &as))
+	assert.NotEmpty(t, as.Annotations[metalv1.SyncRequestedAnnotation])
+
+	resp, err := http.Get(srv.URL + notifications.Path) //nolint:noctx
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+func TestReceiverEvents(t *testing.T) {
+	t.Parallel()
+
+	fakeClient, srv, events := setup(t)
+
+	ctx := context.Background()
+
+	getAdoptedServer := func(name string) *metalv1.AdoptedServer {
+		var as metalv1.AdoptedServer
+
+		require.NoError(t, fakeClient.Get(ctx, types.NamespacedName{Name: name}, &as))
+
+		return &as
+	}
+
+	// remove by node ID
+	require.Equal(t, http.StatusAccepted, send(t, srv, secret, time.Now(), managementapi.Notification{Event: managementapi.EventRemove, NodeID: "n-2"}))
+	assert.Equal(t, "node-2", (<-events).Object.GetName())
+	assert.True(t, conditions.IsTrue(getAdoptedServer("node-2"), metalv1.ConditionRemovedUpstream))
+
+	// adopt again
+	require.Equal(t, http.StatusAccepted, send(t, srv, secret, time.Now(), managementapi.Notification{Event: managementapi.EventAdopt, Server: "node-2"}))
+	assert.Equal(t, "node-2", (<-events).Object.GetName())
+	assert.False(t, conditions.Has(getAdoptedServer("node-2"), metalv1.ConditionRemovedUpstream))
+
+	// labels are merged
+	require.Equal(t, http.StatusAccepted, send(t, srv, secret, time.Now(), managementapi.Notification{
+		Event:  managementapi.EventLabels,
+		Server: "node-1",
+		Labels: map[string]string{"rack": "r1"},
+	}))
+	assert.Equal(t, "node-1", (<-events).Object.GetName())
+	assert.Equal(t, map[string]string{"zone": "a", "rack": "r1"}, getAdoptedServer("node-1").Spec.Labels)
+
+	// adopt of an unknown server requests an inventory sync
+	require.Equal(t, http.StatusAccepted, send(t, srv, secret, time.Now(), managementapi.Notification{
+		Event:       managementapi.EventAdopt,
+		Server:      "node-3",
+		ClusterName: "prod",
+	}))
+
+	var inventory metalv1.ManagementAPIInventory
+
+	require.NoError(t, fakeClient.Get(ctx, types.NamespacedName{Name: "prod"}, &inventory))
+	assert.NotEmpty(t, inventory.Annotations[metalv1.SyncRequestedAnnotation])
+
+	// errors
+	assert.Equal(t, http.StatusNotFound, send(t, srv, secret, time.Now(), managementapi.Notification{Event: managementapi.EventAdopt, Server: "node-3", ClusterName: "dev"}))
+	assert.Equal(t, http.StatusNotFound, send(t, srv, secret, time.Now(), managementapi.Notification{Event: managementapi.EventSync, NodeID: "n-3"}))
+	assert.Equal(t, http.StatusBadRequest, send(t, srv, secret, time.Now(), managementapi.Notification{Event: "reboot", Server: "node-1"}))
+	assert.Equal(t, http.StatusBadRequest, send(t, srv, secret, time.Now(), managementapi.Notification{Event: managementapi.EventSync}))
+
+	// notifications which failed can be sent again
+	sent := time.Now()
+
+	assert.Equal(t, http.StatusNotFound, send(t, srv, secret, sent, managementapi.Notification{Event: managementapi.EventSync, Server: "node-4"}))
+	assert.Equal(t, http.StatusNotFound, send(t, srv, secret, sent, managementapi.Notification{Event: managementapi.EventSync, Server: "node-4"}))
+
+	assert.Empty(t, events)
+}
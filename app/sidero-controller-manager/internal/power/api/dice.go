@@ -6,6 +6,7 @@ package api
 
 import (
 	"math/rand"
+	"sync"
 	"time"
 )
 
@@ -19,7 +20,9 @@ const (
 	NoFailure
 )
 
+// FailureDice decides the simulated failure mode, it's safe for concurrent use.
 type FailureDice struct {
+	mu   sync.Mutex
 	rand *rand.Rand
 
 	failureRates []float64
@@ -35,7 +38,9 @@ func NewFailureDice(explicitFailureProbability, silentFailureProbability float64
 
 // Roll the dice to get the expected failure mode.
 func (dice *FailureDice) Roll() SimulatedFailure {
+	dice.mu.Lock()
 	val := dice.rand.Float64()
+	dice.mu.Unlock()
 
 	for failure, rate := range dice.failureRates {
 		if val < rate {
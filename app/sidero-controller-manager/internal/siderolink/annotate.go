@@ -24,6 +24,7 @@ import (
 	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 
 	sidero "github.com/siderolabs/sidero/app/caps-controller-manager/api/v1alpha3"
+	metalv1 "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
 )
 
 // Annotation describes the source server by SideroLink IP address.
@@ -33,6 +34,9 @@ type Annotation struct {
 	MetalMachineName string
 	MachineName      string
 	ClusterName      string
+
+	// AdoptedServerName is set for nodes adopted into Sidero, which have no ServerBinding.
+	AdoptedServerName string
 }
 
 // Annotator keeps a cache of annotations per SideroLink IP address.
@@ -55,7 +59,7 @@ func NewAnnotator(metalClient runtimeclient.Client, kubeconfig *rest.Config, log
 	}
 }
 
-// Run the watch loop on ServerBindings to build the annotation database.
+// Run the watch loop on ServerBindings and AdoptedServers to build the annotation database.
 //
 //nolint:dupl
 func (a *Annotator) Run(ctx context.Context) error {
@@ -67,16 +71,27 @@ func (a *Annotator) Run(ctx context.Context) error {
 	// Create a factory object that can generate informers for resource types
 	factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(dc, 10*time.Minute, "", nil)
 
-	informerFactory := factory.ForResource(sidero.GroupVersion.WithResource("serverbindings"))
-	informer := informerFactory.Informer()
+	serverBindings := factory.ForResource(sidero.GroupVersion.WithResource("serverbindings")).Informer()
 
-	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+	serverBindings.AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc:    func(new interface{}) { a.notify(nil, new) },
 		UpdateFunc: a.notify,
 		DeleteFunc: func(old interface{}) { a.notify(old, nil) },
 	})
 
-	informer.Run(ctx.Done())
+	adoptedServers := factory.ForResource(metalv1.GroupVersion.WithResource("adoptedservers")).Informer()
+
+	adoptedServers.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(new interface{}) { a.notifyAdoptedServer(nil, new) },
+		UpdateFunc: a.notifyAdoptedServer,
+		DeleteFunc: func(old interface{}) { a.notifyAdoptedServer(old, nil) },
+	})
+
+	factory.Start(ctx.Done())
+
+	<-ctx.Done()
+
+	factory.Shutdown()
 
 	return nil
 }
@@ -191,3 +206,72 @@ func (a *Annotator) buildAnnotation(serverBinding *sidero.ServerBinding) (annota
 
 	return annotation, nil
 }
+
+func (a *Annotator) notifyAdoptedServer(old, new interface{}) {
+	var oldAdoptedServer, newAdoptedServer *metalv1.AdoptedServer
+
+	if old != nil {
+		oldAdoptedServer = &metalv1.AdoptedServer{}
+
+		err := runtime.DefaultUnstructuredConverter.
+			FromUnstructured(old.(*unstructured.Unstructured).UnstructuredContent(), oldAdoptedServer)
+		if err != nil {
+			a.logger.Error("failed converting old event object", zap.Error(err))
+
+			return
+		}
+	}
+
+	if new != nil {
+		newAdoptedServer = &metalv1.AdoptedServer{}
+
+		err := runtime.DefaultUnstructuredConverter.
+			FromUnstructured(new.(*unstructured.Unstructured).UnstructuredContent(), newAdoptedServer)
+		if err != nil {
+			a.logger.Error("failed converting new event object", zap.Error(err))
+
+			return
+		}
+	}
+
+	oldAddress, newAddress := adoptedServerAddress(oldAdoptedServer), adoptedServerAddress(newAdoptedServer)
+
+	a.nodesMu.Lock()
+	defer a.nodesMu.Unlock()
+
+	if oldAddress != "" && oldAddress != newAddress {
+		delete(a.nodes, oldAddress)
+	}
+
+	if newAddress == "" {
+		return
+	}
+
+	annotation := Annotation{
+		AdoptedServerName: newAdoptedServer.Name,
+	}
+
+	if nodeInfo := newAdoptedServer.Status.NodeInfo; nodeInfo != nil {
+		annotation.ServerUUID = nodeInfo.UUID
+		annotation.ClusterName = nodeInfo.ClusterName
+	}
+
+	a.nodes[newAddress] = annotation
+
+	a.logger.Debug("new node mapping", zap.String("ip", newAddress), zap.Any("annotation", annotation))
+}
+
+// adoptedServerAddress returns the SideroLink IP address of the adopted server, if enabled.
+func adoptedServerAddress(adoptedServer *metalv1.AdoptedServer) string {
+	peer := adoptedServerPeer(adoptedServer)
+	if peer.Address == "" {
+		return ""
+	}
+
+	ipPrefix, err := netip.ParsePrefix(peer.Address)
+	if err != nil {
+		return ""
+	}
+
+	return ipPrefix.Addr().String()
+}
@@ -19,10 +19,11 @@ import (
 	"k8s.io/client-go/tools/cache"
 
 	sidero "github.com/siderolabs/sidero/app/caps-controller-manager/api/v1alpha3"
+	metalv1 "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
 	"github.com/siderolabs/siderolink/pkg/wireguard"
 )
 
-// PeerState syncs data from Kubernetes ServerBinding as peer state.
+// PeerState syncs data from Kubernetes ServerBindings and AdoptedServers as peer state.
 type PeerState struct {
 	kubeconfig *rest.Config
 	logger     *zap.Logger
@@ -51,16 +52,27 @@ func (peers *PeerState) Run(ctx context.Context) error {
 	// Create a factory object that can generate informers for resource types
 	factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(dc, 10*time.Minute, "", nil)
 
-	informerFactory := factory.ForResource(sidero.GroupVersion.WithResource("serverbindings"))
-	informer := informerFactory.Informer()
+	serverBindings := factory.ForResource(sidero.GroupVersion.WithResource("serverbindings")).Informer()
 
-	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+	serverBindings.AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc:    func(new interface{}) { peers.notify(nil, new) },
 		UpdateFunc: peers.notify,
 		DeleteFunc: func(old interface{}) { peers.notify(old, nil) },
 	})
 
-	informer.Run(ctx.Done())
+	adoptedServers := factory.ForResource(metalv1.GroupVersion.WithResource("adoptedservers")).Informer()
+
+	adoptedServers.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(new interface{}) { peers.notifyAdoptedServer(nil, new) },
+		UpdateFunc: peers.notifyAdoptedServer,
+		DeleteFunc: func(old interface{}) { peers.notifyAdoptedServer(old, nil) },
+	})
+
+	factory.Start(ctx.Done())
+
+	<-ctx.Done()
+
+	factory.Shutdown()
 
 	return nil
 }
@@ -109,21 +121,77 @@ func (peers *PeerState) notify(old, new interface{}) {
 }
 
 func (peers *PeerState) buildEvent(serverBinding *sidero.ServerBinding, deleted bool) {
-	if serverBinding.Spec.SideroLink.NodePublicKey == "" || serverBinding.Spec.SideroLink.NodeAddress == "" {
+	peers.sendEvent(serverBinding.Name, serverBinding.Spec.SideroLink.NodeAddress, serverBinding.Spec.SideroLink.NodePublicKey, deleted)
+}
+
+func (peers *PeerState) notifyAdoptedServer(old, new interface{}) {
+	var oldAdoptedServer, newAdoptedServer *metalv1.AdoptedServer
+
+	if old != nil {
+		oldAdoptedServer = &metalv1.AdoptedServer{}
+
+		err := runtime.DefaultUnstructuredConverter.
+			FromUnstructured(old.(*unstructured.Unstructured).UnstructuredContent(), oldAdoptedServer)
+		if err != nil {
+			peers.logger.Error("failed converting old event object", zap.Error(err))
+
+			return
+		}
+	}
+
+	if new != nil {
+		newAdoptedServer = &metalv1.AdoptedServer{}
+
+		err := runtime.DefaultUnstructuredConverter.
+			FromUnstructured(new.(*unstructured.Unstructured).UnstructuredContent(), newAdoptedServer)
+		if err != nil {
+			peers.logger.Error("failed converting new event object", zap.Error(err))
+
+			return
+		}
+	}
+
+	oldPeer, newPeer := adoptedServerPeer(oldAdoptedServer), adoptedServerPeer(newAdoptedServer)
+
+	if oldPeer == newPeer {
+		// no change to SideroLink, skip it
+		return
+	}
+
+	if oldAdoptedServer != nil {
+		peers.sendEvent(oldAdoptedServer.Name, oldPeer.Address, oldPeer.PublicKey, true)
+	}
+
+	if newAdoptedServer != nil {
+		peers.sendEvent(newAdoptedServer.Name, newPeer.Address, newPeer.PublicKey, false)
+	}
+}
+
+// adoptedServerPeer returns the SideroLink peer configuration of the adopted server, if enabled.
+func adoptedServerPeer(adoptedServer *metalv1.AdoptedServer) metalv1.SideroLinkConfig {
+	if adoptedServer == nil || adoptedServer.Spec.SideroLink == nil || !adoptedServer.Spec.SideroLink.Enabled {
+		return metalv1.SideroLinkConfig{}
+	}
+
+	return *adoptedServer.Spec.SideroLink
+}
+
+func (peers *PeerState) sendEvent(name, nodeAddress, nodePublicKey string, deleted bool) {
+	if nodePublicKey == "" || nodeAddress == "" {
 		// no SideroLink information
 		return
 	}
 
-	address, err := netip.ParsePrefix(serverBinding.Spec.SideroLink.NodeAddress)
+	address, err := netip.ParsePrefix(nodeAddress)
 	if err != nil {
-		peers.logger.Error("error parsing node address", zap.Error(err), zap.String("uuid", serverBinding.Name))
+		peers.logger.Error("error parsing node address", zap.Error(err), zap.String("name", name))
 
 		return
 	}
 
-	pubKey, err := wgtypes.ParseKey(serverBinding.Spec.SideroLink.NodePublicKey)
+	pubKey, err := wgtypes.ParseKey(nodePublicKey)
 	if err != nil {
-		peers.logger.Error("error parsing public key", zap.Error(err), zap.String("uuid", serverBinding.Name))
+		peers.logger.Error("error parsing public key", zap.Error(err), zap.String("name", name))
 
 		return
 	}
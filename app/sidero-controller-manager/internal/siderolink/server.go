@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"io"
 	"net/netip"
+	"strings"
 
 	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
 	"google.golang.org/grpc/codes"
@@ -22,6 +23,7 @@ import (
 	pb "github.com/siderolabs/siderolink/api/siderolink"
 
 	sidero "github.com/siderolabs/sidero/app/caps-controller-manager/api/v1alpha3"
+	metalv1 "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
 )
 
 // Server implements gRPC API.
@@ -40,13 +42,27 @@ func NewServer(cfg *Config, metalClient runtimeclient.Client) *Server {
 	}
 }
 
+// GenerateNodeAddress picks a random node address in the SideroLink subnet.
+func GenerateNodeAddress(subnet netip.Prefix) (netip.Prefix, error) {
+	raw := subnet.Addr().As16()
+	salt := make([]byte, 8)
+
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return netip.Prefix{}, err
+	}
+
+	copy(raw[8:], salt)
+
+	return netip.PrefixFrom(netip.AddrFrom16(raw), subnet.Bits()), nil
+}
+
 // Provision the SideroLink for the server by UUID.
 func (srv *Server) Provision(ctx context.Context, req *pb.ProvisionRequest) (*pb.ProvisionResponse, error) {
 	var serverbinding sidero.ServerBinding
 
 	if err := srv.metalClient.Get(ctx, types.NamespacedName{Name: req.NodeUuid}, &serverbinding); err != nil {
 		if apierrors.IsNotFound(err) {
-			return nil, status.Error(codes.NotFound, fmt.Sprintf("server binding %q not found", req.NodeUuid))
+			return srv.provisionAdoptedServer(ctx, req)
 		}
 
 		return nil, err
@@ -66,19 +82,11 @@ func (srv *Server) Provision(ctx context.Context, req *pb.ProvisionRequest) (*pb
 			return nil, err
 		}
 	} else {
-		// generate random address for the node
-		raw := srv.cfg.Subnet.Addr().As16()
-		salt := make([]byte, 8)
-
-		_, err := io.ReadFull(rand.Reader, salt)
+		nodeAddress, err = GenerateNodeAddress(srv.cfg.Subnet)
 		if err != nil {
 			return nil, err
 		}
 
-		copy(raw[8:], salt)
-
-		nodeAddress = netip.PrefixFrom(netip.AddrFrom16(raw), srv.cfg.Subnet.Bits())
-
 		serverbinding.Spec.SideroLink.NodeAddress = nodeAddress.String()
 	}
 
@@ -93,10 +101,62 @@ func (srv *Server) Provision(ctx context.Context, req *pb.ProvisionRequest) (*pb
 		return nil, err
 	}
 
+	return srv.response(nodeAddress), nil
+}
+
+// provisionAdoptedServer provisions the SideroLink for the adopted server with matching node UUID.
+//
+// Adopted servers get the node address assigned by the AdoptedServer controller, so only the public key is recorded.
+func (srv *Server) provisionAdoptedServer(ctx context.Context, req *pb.ProvisionRequest) (*pb.ProvisionResponse, error) {
+	var adoptedServers metalv1.AdoptedServerList
+
+	if err := srv.metalClient.List(ctx, &adoptedServers); err != nil {
+		return nil, err
+	}
+
+	for i := range adoptedServers.Items {
+		adoptedServer := &adoptedServers.Items[i]
+
+		if adoptedServer.Status.NodeInfo == nil || !strings.EqualFold(adoptedServer.Status.NodeInfo.UUID, req.NodeUuid) {
+			continue
+		}
+
+		if adoptedServer.Spec.SideroLink == nil || !adoptedServer.Spec.SideroLink.Enabled || adoptedServer.Spec.SideroLink.Address == "" {
+			return nil, status.Error(codes.FailedPrecondition, fmt.Sprintf("SideroLink is not enabled for adopted server %q", adoptedServer.Name))
+		}
+
+		nodeAddress, err := netip.ParsePrefix(adoptedServer.Spec.SideroLink.Address)
+		if err != nil {
+			return nil, err
+		}
+
+		pubKey, err := wgtypes.ParseKey(req.NodePublicKey)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("error parsing Wireguard key: %s", err))
+		}
+
+		patchHelper, err := patch.NewHelper(adoptedServer, srv.metalClient)
+		if err != nil {
+			return nil, err
+		}
+
+		adoptedServer.Spec.SideroLink.PublicKey = pubKey.String()
+
+		if err = patchHelper.Patch(ctx, adoptedServer); err != nil {
+			return nil, err
+		}
+
+		return srv.response(nodeAddress), nil
+	}
+
+	return nil, status.Error(codes.NotFound, fmt.Sprintf("server binding %q not found", req.NodeUuid))
+}
+
+func (srv *Server) response(nodeAddress netip.Prefix) *pb.ProvisionResponse {
 	return &pb.ProvisionResponse{
 		ServerEndpoint:    []string{srv.cfg.WireguardEndpoint},
 		ServerPublicKey:   srv.cfg.PublicKey.String(),
 		ServerAddress:     srv.cfg.ServerAddress.Addr().String(),
 		NodeAddressPrefix: nodeAddress.String(),
-	}, nil
+	}
 }
@@ -0,0 +1,80 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package talos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cosi-project/runtime/pkg/safe"
+	"github.com/siderolabs/talos/pkg/machinery/api/machine"
+	talosclient "github.com/siderolabs/talos/pkg/machinery/client"
+	"github.com/siderolabs/talos/pkg/machinery/config/config"
+	"github.com/siderolabs/talos/pkg/machinery/config/container"
+	"github.com/siderolabs/talos/pkg/machinery/config/encoder"
+	talosconfig "github.com/siderolabs/talos/pkg/machinery/resources/config"
+)
+
+// ReadConfigDocuments reads the documents of the active machine configuration of the node.
+func ReadConfigDocuments(ctx context.Context, c *talosclient.Client) ([]config.Document, error) {
+	mc, err := safe.StateGetByID[*talosconfig.MachineConfig](ctx, c.COSI, talosconfig.ActiveID)
+	if err != nil {
+		return nil, fmt.Errorf("error reading machine config: %w", err)
+	}
+
+	return mc.Container().Documents(), nil
+}
+
+// ReadConfig reads the active machine configuration of the node as YAML.
+func ReadConfig(ctx context.Context, c *talosclient.Client) ([]byte, error) {
+	mc, err := safe.StateGetByID[*talosconfig.MachineConfig](ctx, c.COSI, talosconfig.ActiveID)
+	if err != nil {
+		return nil, fmt.Errorf("error reading machine config: %w", err)
+	}
+
+	data, err := mc.Container().EncodeBytes(encoder.WithComments(encoder.CommentsDisabled))
+	if err != nil {
+		return nil, fmt.Errorf("error encoding machine config: %w", err)
+	}
+
+	return data, nil
+}
+
+// ApplyDocuments encodes the documents as a machine configuration and applies it to the node.
+func ApplyDocuments(ctx context.Context, c *talosclient.Client, docs []config.Document, mode machine.ApplyConfigurationRequest_Mode) error {
+	cfg, err := container.New(docs...)
+	if err != nil {
+		return fmt.Errorf("error building machine config: %w", err)
+	}
+
+	data, err := cfg.EncodeBytes(encoder.WithComments(encoder.CommentsDisabled))
+	if err != nil {
+		return fmt.Errorf("error encoding machine config: %w", err)
+	}
+
+	_, err = ApplyConfig(ctx, c, data, mode, false)
+
+	return err
+}
+
+// ApplyConfig applies the machine configuration to the node.
+//
+// With dryRun set the configuration is only validated, and the changes are described in the returned mode details.
+func ApplyConfig(ctx context.Context, c *talosclient.Client, data []byte, mode machine.ApplyConfigurationRequest_Mode, dryRun bool) (string, error) {
+	resp, err := c.ApplyConfiguration(ctx, &machine.ApplyConfigurationRequest{
+		Data:   data,
+		Mode:   mode,
+		DryRun: dryRun,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error applying machine config: %w", err)
+	}
+
+	if len(resp.Messages) == 0 {
+		return "", nil
+	}
+
+	return resp.Messages[0].ModeDetails, nil
+}
@@ -0,0 +1,65 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package talos_test
+
+import (
+	"context"
+	"crypto/x509"
+	"testing"
+
+	"github.com/cosi-project/runtime/pkg/state"
+	"github.com/cosi-project/runtime/pkg/state/impl/inmem"
+	"github.com/cosi-project/runtime/pkg/state/impl/namespaced"
+	"github.com/siderolabs/talos/pkg/machinery/api/machine"
+	"github.com/siderolabs/talos/pkg/machinery/config/configloader"
+	talosconfig "github.com/siderolabs/talos/pkg/machinery/resources/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/internal/talos"
+)
+
+func TestApplyConfig(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	ca := newCA(t, 1)
+	client := newLeaf(t, ca, 3, x509.ExtKeyUsageClientAuth)
+
+	cfg, err := configloader.NewFromBytes([]byte("version: v1alpha1\nmachine:\n  type: worker\n  network:\n    hostname: node-1\n"))
+	require.NoError(t, err)
+
+	resources := state.WrapCore(namespaced.NewState(inmem.Build))
+	require.NoError(t, resources.Create(ctx, talosconfig.NewMachineConfig(cfg)))
+
+	svc := &fakeMachineService{resources: resources}
+	endpoint := startMachineService(t, ca, svc)
+
+	c, err := talos.NewClient(ctx, endpoint, credentialsSecret(ca, client))
+	require.NoError(t, err)
+
+	t.Cleanup(func() { c.Close() }) //nolint:errcheck
+
+	patched := []byte("version: v1alpha1\nmachine:\n  type: worker\n  network:\n    hostname: node-2\n")
+
+	// dry run doesn't change the config
+	details, err := talos.ApplyConfig(ctx, c, patched, machine.ApplyConfigurationRequest_AUTO, true)
+	require.NoError(t, err)
+	assert.Equal(t, "Dry run summary", details)
+
+	current, err := talos.ReadConfig(ctx, c)
+	require.NoError(t, err)
+	assert.Contains(t, string(current), "hostname: node-1")
+
+	_, err = talos.ApplyConfig(ctx, c, patched, machine.ApplyConfigurationRequest_NO_REBOOT, false)
+	require.NoError(t, err)
+
+	current, err = talos.ReadConfig(ctx, c)
+	require.NoError(t, err)
+	assert.Contains(t, string(current), "hostname: node-2")
+
+	assert.Equal(t, []machine.ApplyConfigurationRequest_Mode{machine.ApplyConfigurationRequest_NO_REBOOT}, svc.appliedModes())
+}
@@ -0,0 +1,284 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package talos
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cosi-project/runtime/pkg/safe"
+	"github.com/cosi-project/runtime/pkg/state"
+	talosclient "github.com/siderolabs/talos/pkg/machinery/client"
+	"github.com/siderolabs/talos/pkg/machinery/resources/cluster"
+	"github.com/siderolabs/talos/pkg/machinery/resources/config"
+	"github.com/siderolabs/talos/pkg/machinery/resources/network"
+
+	metalv1 "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
+)
+
+// DefaultPort is the default port of the Talos API.
+const DefaultPort = metalv1.DefaultTalosAPIPort
+
+// MaxScanAddresses is the maximum number of addresses probed by a single CIDR scan.
+const MaxScanAddresses = 4096
+
+const (
+	scanConcurrency = 64
+	connectTimeout  = 2 * time.Second
+)
+
+// Dialer creates a Talos API client for the endpoint.
+type Dialer func(ctx context.Context, endpoint string) (*talosclient.Client, error)
+
+// DiscoveredNode describes a Talos node found by the discovery.
+type DiscoveredNode struct {
+	Endpoint     string
+	MachineID    string
+	Hostname     string
+	MachineType  string
+	TalosVersion string
+}
+
+// UnreachableEndpoint describes an endpoint which was expected to serve the Talos API, but didn't respond.
+type UnreachableEndpoint struct {
+	Endpoint string
+	Error    string
+}
+
+// DiscoveryResult is the result of the discovery.
+//
+// Nodes are deduplicated by machine ID, and sorted by endpoint.
+type DiscoveryResult struct {
+	Nodes       []DiscoveredNode
+	Unreachable []UnreachableEndpoint
+}
+
+// DiscoverCIDRs probes the Talos API on every address in the prefixes.
+//
+// Addresses which don't accept connections on the port are skipped, as they are not Talos nodes.
+// Addresses which accept connections, but fail the Talos API call are reported as unreachable.
+func DiscoverCIDRs(ctx context.Context, dial Dialer, prefixes []netip.Prefix, port int, timeout time.Duration) (DiscoveryResult, error) {
+	var endpoints []string
+
+	for _, prefix := range prefixes {
+		prefix = prefix.Masked()
+
+		for addr := prefix.Addr(); prefix.Contains(addr); addr = addr.Next() {
+			if len(endpoints) == MaxScanAddresses {
+				return DiscoveryResult{}, fmt.Errorf("too many addresses to scan, the limit is %d", MaxScanAddresses)
+			}
+
+			endpoints = append(endpoints, net.JoinHostPort(addr.String(), strconv.Itoa(port)))
+		}
+	}
+
+	return discover(ctx, dial, endpoints, timeout), nil
+}
+
+// DiscoverMembers lists cluster members known to the seed node, and probes the Talos API of each member.
+//
+// Each member is probed on its addresses in order until one of them responds; members which
+// don't respond on any address are reported as unreachable.
+func DiscoverMembers(ctx context.Context, dial Dialer, seed string, port int, timeout time.Duration) (DiscoveryResult, error) {
+	c, err := dial(ctx, seed)
+	if err != nil {
+		return DiscoveryResult{}, fmt.Errorf("error connecting to the seed endpoint: %w", err)
+	}
+
+	defer c.Close() //nolint:errcheck
+
+	listCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	members, err := safe.StateListAll[*cluster.Member](listCtx, c.COSI)
+	if err != nil {
+		return DiscoveryResult{}, fmt.Errorf("error listing cluster members: %w", err)
+	}
+
+	var (
+		result DiscoveryResult
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+	)
+
+	sem := make(chan struct{}, scanConcurrency)
+
+	for member := range members.All() {
+		spec := member.TypedSpec()
+
+		endpoints := make([]string, 0, len(spec.Addresses))
+
+		for _, addr := range spec.Addresses {
+			endpoints = append(endpoints, net.JoinHostPort(addr.String(), strconv.Itoa(port)))
+		}
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			var unreachable []UnreachableEndpoint
+
+			for _, endpoint := range endpoints {
+				node, err := probeNode(ctx, dial, endpoint, timeout)
+				if err == nil {
+					mu.Lock()
+					result.Nodes = append(result.Nodes, node)
+					mu.Unlock()
+
+					return
+				}
+
+				unreachable = append(unreachable, UnreachableEndpoint{Endpoint: endpoint, Error: err.Error()})
+			}
+
+			mu.Lock()
+			result.Unreachable = append(result.Unreachable, unreachable...)
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+
+	return result.normalize(), nil
+}
+
+func discover(ctx context.Context, dial Dialer, endpoints []string, timeout time.Duration) DiscoveryResult {
+	var (
+		result DiscoveryResult
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+	)
+
+	sem := make(chan struct{}, scanConcurrency)
+
+	for _, endpoint := range endpoints {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			if !isOpen(ctx, endpoint) {
+				return
+			}
+
+			node, err := probeNode(ctx, dial, endpoint, timeout)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				result.Unreachable = append(result.Unreachable, UnreachableEndpoint{Endpoint: endpoint, Error: err.Error()})
+
+				return
+			}
+
+			result.Nodes = append(result.Nodes, node)
+		}()
+	}
+
+	wg.Wait()
+
+	return result.normalize()
+}
+
+func isOpen(ctx context.Context, endpoint string) bool {
+	dialer := net.Dialer{Timeout: connectTimeout}
+
+	conn, err := dialer.DialContext(ctx, "tcp", endpoint)
+	if err != nil {
+		return false
+	}
+
+	conn.Close() //nolint:errcheck
+
+	return true
+}
+
+// probeNode reads the identity of the node serving the Talos API on the endpoint.
+func probeNode(ctx context.Context, dial Dialer, endpoint string, timeout time.Duration) (DiscoveredNode, error) {
+	node := DiscoveredNode{
+		Endpoint: endpoint,
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	c, err := dial(ctx, endpoint)
+	if err != nil {
+		return node, err
+	}
+
+	defer c.Close() //nolint:errcheck
+
+	probe, err := Probe(ctx, c, timeout)
+	if err != nil {
+		return node, err
+	}
+
+	node.TalosVersion = probe.Version
+
+	identity, err := safe.StateGetByID[*cluster.Identity](ctx, c.COSI, cluster.LocalIdentity)
+	if err != nil {
+		return node, fmt.Errorf("error getting node identity: %w", err)
+	}
+
+	node.MachineID = identity.TypedSpec().NodeID
+
+	hostname, err := safe.StateGetByID[*network.HostnameStatus](ctx, c.COSI, network.HostnameID)
+	if err != nil {
+		if !state.IsNotFoundError(err) {
+			return node, fmt.Errorf("error getting hostname: %w", err)
+		}
+	} else {
+		node.Hostname = hostname.TypedSpec().FQDN()
+	}
+
+	machineType, err := safe.StateGetByID[*config.MachineType](ctx, c.COSI, config.MachineTypeID)
+	if err != nil {
+		if !state.IsNotFoundError(err) {
+			return node, fmt.Errorf("error getting machine type: %w", err)
+		}
+	} else if machineType.MachineType().IsControlPlane() {
+		node.MachineType = "controlplane"
+	} else {
+		node.MachineType = machineType.MachineType().String()
+	}
+
+	return node, nil
+}
+
+func (result DiscoveryResult) normalize() DiscoveryResult {
+	slices.SortFunc(result.Nodes, func(a, b DiscoveredNode) int { return strings.Compare(a.Endpoint, b.Endpoint) })
+	slices.SortFunc(result.Unreachable, func(a, b UnreachableEndpoint) int { return strings.Compare(a.Endpoint, b.Endpoint) })
+
+	// a node might answer on several addresses, keep the first one
+	seen := map[string]struct{}{}
+
+	result.Nodes = slices.DeleteFunc(result.Nodes, func(node DiscoveredNode) bool {
+		if _, ok := seen[node.MachineID]; ok {
+			return true
+		}
+
+		seen[node.MachineID] = struct{}{}
+
+		return false
+	})
+
+	return result
+}
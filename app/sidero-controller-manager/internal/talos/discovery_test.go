@@ -0,0 +1,127 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package talos_test
+
+import (
+	"context"
+	"crypto/x509"
+	"net"
+	"net/netip"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/cosi-project/runtime/pkg/resource"
+	"github.com/cosi-project/runtime/pkg/state"
+	"github.com/cosi-project/runtime/pkg/state/impl/inmem"
+	"github.com/cosi-project/runtime/pkg/state/impl/namespaced"
+	talosclient "github.com/siderolabs/talos/pkg/machinery/client"
+	"github.com/siderolabs/talos/pkg/machinery/config/machine"
+	"github.com/siderolabs/talos/pkg/machinery/resources/cluster"
+	"github.com/siderolabs/talos/pkg/machinery/resources/config"
+	"github.com/siderolabs/talos/pkg/machinery/resources/network"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/internal/talos"
+)
+
+const machineID = "7x1SuC8Ege5BGXdAfTEff5iQnlWZLfv9h1LGMxA2pYkC"
+
+func startDiscoverableNode(t *testing.T, ctx context.Context) (talos.Dialer, int) {
+	t.Helper()
+
+	ca := newCA(t, 1)
+	client := newLeaf(t, ca, 3, x509.ExtKeyUsageClientAuth)
+
+	resources := state.WrapCore(namespaced.NewState(inmem.Build))
+
+	hostname := network.NewHostnameStatus(network.NamespaceName, network.HostnameID)
+	hostname.TypedSpec().Hostname = "spokane-cp-1"
+
+	identity := cluster.NewIdentity(cluster.NamespaceName, cluster.LocalIdentity)
+	identity.TypedSpec().NodeID = machineID
+
+	machineType := config.NewMachineType()
+	machineType.SetMachineType(machine.TypeControlPlane)
+
+	self := cluster.NewMember(cluster.NamespaceName, "spokane-cp-1")
+	self.TypedSpec().NodeID = machineID
+	self.TypedSpec().Addresses = []netip.Addr{netip.MustParseAddr("127.0.0.2"), netip.MustParseAddr("127.0.0.1")}
+
+	gone := cluster.NewMember(cluster.NamespaceName, "spokane-w-1")
+	gone.TypedSpec().NodeID = "cLNb3XTk8EKbjYn9SdpbBtByhU1WwJfjzQ9ZkJPa2Kk8"
+	gone.TypedSpec().Addresses = []netip.Addr{netip.MustParseAddr("127.0.0.3")}
+
+	for _, r := range []resource.Resource{hostname, identity, machineType, self, gone} {
+		require.NoError(t, resources.Create(ctx, r))
+	}
+
+	endpoint := startMachineService(t, ca, &fakeMachineService{resources: resources})
+
+	_, portStr, err := net.SplitHostPort(endpoint)
+	require.NoError(t, err)
+
+	port, err := strconv.Atoi(portStr)
+	require.NoError(t, err)
+
+	secret := credentialsSecret(ca, client)
+
+	return func(ctx context.Context, endpoint string) (*talosclient.Client, error) {
+		return talos.NewClient(ctx, endpoint, secret)
+	}, port
+}
+
+func TestDiscoverMembers(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	dial, port := startDiscoverableNode(t, ctx)
+
+	result, err := talos.DiscoverMembers(ctx, dial, net.JoinHostPort("127.0.0.1", strconv.Itoa(port)), port, 2*time.Second)
+	require.NoError(t, err)
+
+	endpoint := net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+
+	assert.Equal(t, []talos.DiscoveredNode{
+		{
+			Endpoint:     endpoint,
+			MachineID:    machineID,
+			Hostname:     "spokane-cp-1",
+			MachineType:  "controlplane",
+			TalosVersion: "v1.11.5",
+		},
+	}, result.Nodes)
+
+	require.Len(t, result.Unreachable, 1)
+	assert.Equal(t, net.JoinHostPort("127.0.0.3", strconv.Itoa(port)), result.Unreachable[0].Endpoint)
+	assert.NotEmpty(t, result.Unreachable[0].Error)
+}
+
+func TestDiscoverCIDRs(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	dial, port := startDiscoverableNode(t, ctx)
+
+	result, err := talos.DiscoverCIDRs(ctx, dial, []netip.Prefix{netip.MustParsePrefix("127.0.0.0/30")}, port, 2*time.Second)
+	require.NoError(t, err)
+
+	assert.Equal(t, []talos.DiscoveredNode{
+		{
+			Endpoint:     net.JoinHostPort("127.0.0.1", strconv.Itoa(port)),
+			MachineID:    machineID,
+			Hostname:     "spokane-cp-1",
+			MachineType:  "controlplane",
+			TalosVersion: "v1.11.5",
+		},
+	}, result.Nodes)
+	assert.Empty(t, result.Unreachable)
+
+	_, err = talos.DiscoverCIDRs(ctx, dial, []netip.Prefix{netip.MustParsePrefix("10.0.0.0/16")}, port, time.Second)
+	require.Error(t, err)
+}
@@ -0,0 +1,49 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package talos
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/siderolabs/talos/pkg/machinery/api/machine"
+	talosclient "github.com/siderolabs/talos/pkg/machinery/client"
+)
+
+// EtcdSnapshot streams the etcd snapshot of the control plane node to w.
+func EtcdSnapshot(ctx context.Context, c *talosclient.Client, w io.Writer) (int64, error) {
+	r, err := c.EtcdSnapshot(ctx, &machine.EtcdSnapshotRequest{})
+	if err != nil {
+		return 0, fmt.Errorf("error starting etcd snapshot: %w", err)
+	}
+
+	defer r.Close() //nolint:errcheck
+
+	n, err := io.Copy(w, r)
+	if err != nil {
+		return n, fmt.Errorf("error reading etcd snapshot: %w", err)
+	}
+
+	return n, nil
+}
+
+// RecoverEtcd uploads the etcd snapshot to the control plane node, and bootstraps etcd from it.
+//
+// The node should have etcd not bootstrapped yet, e.g. after a reset.
+func RecoverEtcd(ctx context.Context, c *talosclient.Client, snapshot io.Reader, skipHashCheck bool) error {
+	if _, err := c.EtcdRecover(ctx, snapshot); err != nil {
+		return fmt.Errorf("error uploading etcd snapshot: %w", err)
+	}
+
+	if err := c.Bootstrap(ctx, &machine.BootstrapRequest{
+		RecoverEtcd:          true,
+		RecoverSkipHashCheck: skipHashCheck,
+	}); err != nil {
+		return fmt.Errorf("error bootstrapping etcd from the snapshot: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package talos_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"errors"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/siderolabs/talos/pkg/machinery/api/common"
+	"github.com/siderolabs/talos/pkg/machinery/api/machine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/internal/talos"
+)
+
+func (s *fakeMachineService) EtcdSnapshot(_ *machine.EtcdSnapshotRequest, srv grpc.ServerStreamingServer[common.Data]) error {
+	for chunk := range slices.Chunk(s.etcdSnapshot, 1024) {
+		if err := srv.Send(&common.Data{Bytes: chunk}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *fakeMachineService) EtcdRecover(srv grpc.ClientStreamingServer[common.Data, machine.EtcdRecoverResponse]) error {
+	var buf bytes.Buffer
+
+	for {
+		msg, err := srv.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		buf.Write(msg.Bytes)
+	}
+
+	s.requestsMu.Lock()
+	s.recovered = buf.Bytes()
+	s.requestsMu.Unlock()
+
+	return srv.SendAndClose(&machine.EtcdRecoverResponse{})
+}
+
+func (s *fakeMachineService) Bootstrap(_ context.Context, req *machine.BootstrapRequest) (*machine.BootstrapResponse, error) {
+	s.record(req)
+
+	return &machine.BootstrapResponse{}, nil
+}
+
+func TestEtcdSnapshot(t *testing.T) {
+	t.Parallel()
+
+	ca := newCA(t, 1)
+	client := newLeaf(t, ca, 3, x509.ExtKeyUsageClientAuth)
+
+	ctx := context.Background()
+
+	snapshot := bytes.Repeat([]byte("etcd"), 4096)
+
+	svc := &fakeMachineService{etcdSnapshot: snapshot}
+	endpoint := startMachineService(t, ca, svc)
+
+	c, err := talos.NewClient(ctx, endpoint, credentialsSecret(ca, client))
+	require.NoError(t, err)
+
+	t.Cleanup(func() { c.Close() }) //nolint:errcheck
+
+	var buf bytes.Buffer
+
+	n, err := talos.EtcdSnapshot(ctx, c, &buf)
+	require.NoError(t, err)
+
+	assert.EqualValues(t, len(snapshot), n)
+	assert.Equal(t, snapshot, buf.Bytes())
+}
+
+func TestRecoverEtcd(t *testing.T) {
+	t.Parallel()
+
+	ca := newCA(t, 1)
+	client := newLeaf(t, ca, 3, x509.ExtKeyUsageClientAuth)
+
+	ctx := context.Background()
+
+	svc := &fakeMachineService{}
+	endpoint := startMachineService(t, ca, svc)
+
+	c, err := talos.NewClient(ctx, endpoint, credentialsSecret(ca, client))
+	require.NoError(t, err)
+
+	t.Cleanup(func() { c.Close() }) //nolint:errcheck
+
+	snapshot := bytes.Repeat([]byte("etcd"), 4096)
+
+	require.NoError(t, talos.RecoverEtcd(ctx, c, bytes.NewReader(snapshot), true))
+
+	svc.requestsMu.Lock()
+	defer svc.requestsMu.Unlock()
+
+	assert.Equal(t, snapshot, svc.recovered)
+	require.Len(t, svc.requests, 1)
+	assert.True(t, proto.Equal(&machine.BootstrapRequest{RecoverEtcd: true, RecoverSkipHashCheck: true}, svc.requests[0]), "unexpected request %v", svc.requests[0])
+}
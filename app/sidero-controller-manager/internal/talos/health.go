@@ -0,0 +1,246 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package talos
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/siderolabs/talos/pkg/machinery/api/machine"
+	talosclient "github.com/siderolabs/talos/pkg/machinery/client"
+
+	metalv1 "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
+)
+
+// Thresholds for the resource usage checks, in percent.
+const (
+	diskUsageWarning   = 80
+	diskUsageFailure   = 90
+	memoryUsageWarning = 90
+	memoryUsageFailure = 95
+)
+
+// ephemeralMountPoint is the mount point of the EPHEMERAL partition.
+const ephemeralMountPoint = "/var"
+
+// HealthCheck is a single health check run against the Talos API.
+type HealthCheck struct {
+	// Name is reported in the check result.
+	Name string
+	// ControlPlaneOnly checks are skipped on worker nodes.
+	ControlPlaneOnly bool
+	// Run performs the check.
+	Run func(ctx context.Context, c *talosclient.Client) (result, message string, err error)
+}
+
+// DefaultHealthChecks is the list of checks performed for adopted servers.
+//
+// New checks can be added here, the overall health status is derived from the individual results.
+var DefaultHealthChecks = []HealthCheck{
+	{Name: "service/apid", Run: serviceCheck("apid")},
+	{Name: "service/kubelet", Run: serviceCheck("kubelet")},
+	{Name: "service/etcd", ControlPlaneOnly: true, Run: serviceCheck("etcd")},
+	{Name: "etcd/members", ControlPlaneOnly: true, Run: etcdMembersCheck},
+	{Name: "disk", Run: diskCheck},
+	{Name: "memory", Run: memoryCheck},
+}
+
+// RunHealthChecks runs the checks against the node.
+//
+// Checks which fail to run are reported as Unknown.
+func RunHealthChecks(ctx context.Context, c *talosclient.Client, checks []HealthCheck, controlPlane bool) []metalv1.HealthCheckResult {
+	results := make([]metalv1.HealthCheckResult, 0, len(checks))
+
+	for _, check := range checks {
+		if check.ControlPlaneOnly && !controlPlane {
+			continue
+		}
+
+		result, message, err := check.Run(ctx, c)
+		if err != nil {
+			result, message = metalv1.HealthCheckUnknown, err.Error()
+		}
+
+		results = append(results, metalv1.HealthCheckResult{
+			Name:    check.Name,
+			Result:  result,
+			Message: message,
+		})
+	}
+
+	return results
+}
+
+// OverallHealth calculates the overall health status and a summary message from the check results.
+//
+// Any failed check makes the node unhealthy, warnings and checks which couldn't be run make it degraded.
+func OverallHealth(results []metalv1.HealthCheckResult) (status, message string) {
+	if len(results) == 0 {
+		return metalv1.HealthStatusUnknown, "no health checks were run"
+	}
+
+	status = metalv1.HealthStatusHealthy
+
+	var problems []string
+
+	for _, result := range results {
+		switch result.Result {
+		case metalv1.HealthCheckPassed:
+			continue
+		case metalv1.HealthCheckFailed:
+			status = metalv1.HealthStatusUnhealthy
+		default:
+			if status == metalv1.HealthStatusHealthy {
+				status = metalv1.HealthStatusDegraded
+			}
+		}
+
+		problems = append(problems, fmt.Sprintf("%s: %s", result.Name, result.Message))
+	}
+
+	if len(problems) == 0 {
+		return status, fmt.Sprintf("all %d checks passed", len(results))
+	}
+
+	return status, strings.Join(problems, "; ")
+}
+
+func serviceCheck(id string) func(ctx context.Context, c *talosclient.Client) (string, string, error) {
+	return func(ctx context.Context, c *talosclient.Client) (string, string, error) {
+		resp, err := c.ServiceList(ctx)
+		if err != nil {
+			return "", "", fmt.Errorf("error listing services: %w", err)
+		}
+
+		for _, msg := range resp.Messages {
+			for _, svc := range msg.Services {
+				if svc.Id != id {
+					continue
+				}
+
+				return serviceResult(svc)
+			}
+		}
+
+		return metalv1.HealthCheckFailed, "service is not registered", nil
+	}
+}
+
+func serviceResult(svc *machine.ServiceInfo) (string, string, error) {
+	if svc.State != "Running" {
+		return metalv1.HealthCheckFailed, fmt.Sprintf("service is %s", svc.State), nil
+	}
+
+	switch {
+	case svc.Health == nil || svc.Health.Unknown:
+		return metalv1.HealthCheckWarning, "service is running, health is unknown", nil
+	case !svc.Health.Healthy:
+		return metalv1.HealthCheckFailed, fmt.Sprintf("service is unhealthy: %s", svc.Health.LastMessage), nil
+	default:
+		return metalv1.HealthCheckPassed, "service is running and healthy", nil
+	}
+}
+
+func etcdMembersCheck(ctx context.Context, c *talosclient.Client) (string, string, error) {
+	resp, err := c.EtcdMemberList(ctx, &machine.EtcdMemberListRequest{})
+	if err != nil {
+		return "", "", fmt.Errorf("error listing etcd members: %w", err)
+	}
+
+	var members, learners int
+
+	for _, msg := range resp.Messages {
+		for _, member := range msg.Members {
+			members++
+
+			if member.IsLearner {
+				learners++
+			}
+		}
+	}
+
+	if members == 0 {
+		return metalv1.HealthCheckFailed, "no etcd members found", nil
+	}
+
+	alarms, err := c.EtcdAlarmList(ctx)
+	if err != nil {
+		return "", "", fmt.Errorf("error listing etcd alarms: %w", err)
+	}
+
+	var active []string
+
+	for _, msg := range alarms.Messages {
+		for _, alarm := range msg.MemberAlarms {
+			if alarm.Alarm != machine.EtcdMemberAlarm_NONE {
+				active = append(active, fmt.Sprintf("%s on member %x", alarm.Alarm, alarm.MemberId))
+			}
+		}
+	}
+
+	switch {
+	case len(active) > 0:
+		return metalv1.HealthCheckFailed, fmt.Sprintf("etcd alarms raised: %s", strings.Join(active, ", ")), nil
+	case learners > 0:
+		return metalv1.HealthCheckWarning, fmt.Sprintf("%d of %d etcd members are learners", learners, members), nil
+	default:
+		return metalv1.HealthCheckPassed, fmt.Sprintf("%d etcd members", members), nil
+	}
+}
+
+func diskCheck(ctx context.Context, c *talosclient.Client) (string, string, error) {
+	resp, err := c.Mounts(ctx)
+	if err != nil {
+		return "", "", fmt.Errorf("error getting mounts: %w", err)
+	}
+
+	for _, msg := range resp.Messages {
+		for _, stat := range msg.Stats {
+			if stat.MountedOn != ephemeralMountPoint || stat.Size == 0 {
+				continue
+			}
+
+			used := (stat.Size - stat.Available) * 100 / stat.Size
+			message := fmt.Sprintf("%s is %d%% full", ephemeralMountPoint, used)
+
+			switch {
+			case used >= diskUsageFailure:
+				return metalv1.HealthCheckFailed, message, nil
+			case used >= diskUsageWarning:
+				return metalv1.HealthCheckWarning, message, nil
+			default:
+				return metalv1.HealthCheckPassed, message, nil
+			}
+		}
+	}
+
+	return metalv1.HealthCheckUnknown, fmt.Sprintf("%s is not mounted", ephemeralMountPoint), nil
+}
+
+func memoryCheck(ctx context.Context, c *talosclient.Client) (string, string, error) {
+	resp, err := c.Memory(ctx)
+	if err != nil {
+		return "", "", fmt.Errorf("error getting memory usage: %w", err)
+	}
+
+	if len(resp.Messages) == 0 || resp.Messages[0].Meminfo == nil || resp.Messages[0].Meminfo.Memtotal == 0 {
+		return metalv1.HealthCheckUnknown, "memory information is not available", nil
+	}
+
+	info := resp.Messages[0].Meminfo
+
+	used := (info.Memtotal - info.Memavailable) * 100 / info.Memtotal
+	message := fmt.Sprintf("memory is %d%% used", used)
+
+	switch {
+	case used >= memoryUsageFailure:
+		return metalv1.HealthCheckFailed, message, nil
+	case used >= memoryUsageWarning:
+		return metalv1.HealthCheckWarning, message, nil
+	default:
+		return metalv1.HealthCheckPassed, message, nil
+	}
+}
@@ -0,0 +1,249 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package talos_test
+
+import (
+	"context"
+	"crypto/x509"
+	"testing"
+
+	"github.com/siderolabs/talos/pkg/machinery/api/machine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	metalv1 "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/internal/talos"
+)
+
+func (s *fakeMachineService) ServiceList(context.Context, *emptypb.Empty) (*machine.ServiceListResponse, error) {
+	return &machine.ServiceListResponse{
+		Messages: []*machine.ServiceList{{Services: s.services}},
+	}, nil
+}
+
+func (s *fakeMachineService) EtcdMemberList(context.Context, *machine.EtcdMemberListRequest) (*machine.EtcdMemberListResponse, error) {
+	return &machine.EtcdMemberListResponse{
+		Messages: []*machine.EtcdMembers{{Members: s.etcdMembers}},
+	}, nil
+}
+
+func (s *fakeMachineService) EtcdAlarmList(context.Context, *emptypb.Empty) (*machine.EtcdAlarmListResponse, error) {
+	return &machine.EtcdAlarmListResponse{
+		Messages: []*machine.EtcdAlarm{{MemberAlarms: s.etcdAlarms}},
+	}, nil
+}
+
+func (s *fakeMachineService) Mounts(context.Context, *emptypb.Empty) (*machine.MountsResponse, error) {
+	if s.mounts == nil {
+		return nil, status.Error(codes.Unimplemented, "mounts are not available")
+	}
+
+	return &machine.MountsResponse{
+		Messages: []*machine.Mounts{{Stats: s.mounts}},
+	}, nil
+}
+
+func (s *fakeMachineService) Memory(context.Context, *emptypb.Empty) (*machine.MemoryResponse, error) {
+	return &machine.MemoryResponse{
+		Messages: []*machine.Memory{{Meminfo: s.meminfo}},
+	}, nil
+}
+
+func healthyService(id string) *machine.ServiceInfo {
+	return &machine.ServiceInfo{
+		Id:     id,
+		State:  "Running",
+		Health: &machine.ServiceHealth{Healthy: true},
+	}
+}
+
+func TestRunHealthChecks(t *testing.T) {
+	t.Parallel()
+
+	ca := newCA(t, 1)
+	client := newLeaf(t, ca, 3, x509.ExtKeyUsageClientAuth)
+
+	for _, test := range []struct {
+		name         string
+		svc          *fakeMachineService
+		controlPlane bool
+
+		expectedResults []metalv1.HealthCheckResult
+		expectedStatus  string
+	}{
+		{
+			name: "healthy worker",
+			svc: &fakeMachineService{
+				services: []*machine.ServiceInfo{healthyService("apid"), healthyService("kubelet")},
+				mounts:   []*machine.MountStat{{MountedOn: "/var", Size: 100, Available: 60}},
+				meminfo:  &machine.MemInfo{Memtotal: 1000, Memavailable: 500},
+			},
+
+			expectedResults: []metalv1.HealthCheckResult{
+				{Name: "service/apid", Result: metalv1.HealthCheckPassed, Message: "service is running and healthy"},
+				{Name: "service/kubelet", Result: metalv1.HealthCheckPassed, Message: "service is running and healthy"},
+				{Name: "disk", Result: metalv1.HealthCheckPassed, Message: "/var is 40% full"},
+				{Name: "memory", Result: metalv1.HealthCheckPassed, Message: "memory is 50% used"},
+			},
+			expectedStatus: metalv1.HealthStatusHealthy,
+		},
+		{
+			name: "healthy control plane",
+			svc: &fakeMachineService{
+				services:    []*machine.ServiceInfo{healthyService("apid"), healthyService("etcd"), healthyService("kubelet")},
+				etcdMembers: []*machine.EtcdMember{{Id: 1}, {Id: 2}, {Id: 3}},
+				mounts:      []*machine.MountStat{{MountedOn: "/var", Size: 100, Available: 60}},
+				meminfo:     &machine.MemInfo{Memtotal: 1000, Memavailable: 500},
+			},
+			controlPlane: true,
+
+			expectedResults: []metalv1.HealthCheckResult{
+				{Name: "service/apid", Result: metalv1.HealthCheckPassed, Message: "service is running and healthy"},
+				{Name: "service/kubelet", Result: metalv1.HealthCheckPassed, Message: "service is running and healthy"},
+				{Name: "service/etcd", Result: metalv1.HealthCheckPassed, Message: "service is running and healthy"},
+				{Name: "etcd/members", Result: metalv1.HealthCheckPassed, Message: "3 etcd members"},
+				{Name: "disk", Result: metalv1.HealthCheckPassed, Message: "/var is 40% full"},
+				{Name: "memory", Result: metalv1.HealthCheckPassed, Message: "memory is 50% used"},
+			},
+			expectedStatus: metalv1.HealthStatusHealthy,
+		},
+		{
+			name: "degraded",
+			svc: &fakeMachineService{
+				services: []*machine.ServiceInfo{
+					healthyService("apid"),
+					{Id: "kubelet", State: "Running", Health: &machine.ServiceHealth{Unknown: true}},
+				},
+				meminfo: &machine.MemInfo{Memtotal: 1000, Memavailable: 80},
+			},
+
+			expectedResults: []metalv1.HealthCheckResult{
+				{Name: "service/apid", Result: metalv1.HealthCheckPassed, Message: "service is running and healthy"},
+				{Name: "service/kubelet", Result: metalv1.HealthCheckWarning, Message: "service is running, health is unknown"},
+				{
+					Name:    "disk",
+					Result:  metalv1.HealthCheckUnknown,
+					Message: "error getting mounts: rpc error: code = Unimplemented desc = mounts are not available",
+				},
+				{Name: "memory", Result: metalv1.HealthCheckWarning, Message: "memory is 92% used"},
+			},
+			expectedStatus: metalv1.HealthStatusDegraded,
+		},
+		{
+			name: "unhealthy control plane",
+			svc: &fakeMachineService{
+				services: []*machine.ServiceInfo{
+					healthyService("apid"),
+					healthyService("kubelet"),
+					{Id: "etcd", State: "Running", Health: &machine.ServiceHealth{LastMessage: "context deadline exceeded"}},
+				},
+				etcdMembers: []*machine.EtcdMember{{Id: 1}, {Id: 2, IsLearner: true}},
+				etcdAlarms:  []*machine.EtcdMemberAlarm{{MemberId: 0xa, Alarm: machine.EtcdMemberAlarm_NOSPACE}},
+				mounts:      []*machine.MountStat{{MountedOn: "/var", Size: 100, Available: 5}},
+				meminfo:     &machine.MemInfo{Memtotal: 1000, Memavailable: 500},
+			},
+			controlPlane: true,
+
+			expectedResults: []metalv1.HealthCheckResult{
+				{Name: "service/apid", Result: metalv1.HealthCheckPassed, Message: "service is running and healthy"},
+				{Name: "service/kubelet", Result: metalv1.HealthCheckPassed, Message: "service is running and healthy"},
+				{Name: "service/etcd", Result: metalv1.HealthCheckFailed, Message: "service is unhealthy: context deadline exceeded"},
+				{Name: "etcd/members", Result: metalv1.HealthCheckFailed, Message: "etcd alarms raised: NOSPACE on member a"},
+				{Name: "disk", Result: metalv1.HealthCheckFailed, Message: "/var is 95% full"},
+				{Name: "memory", Result: metalv1.HealthCheckPassed, Message: "memory is 50% used"},
+			},
+			expectedStatus: metalv1.HealthStatusUnhealthy,
+		},
+		{
+			name: "missing service",
+			svc: &fakeMachineService{
+				services: []*machine.ServiceInfo{healthyService("apid")},
+				mounts:   []*machine.MountStat{{MountedOn: "/var", Size: 100, Available: 60}},
+				meminfo:  &machine.MemInfo{Memtotal: 1000, Memavailable: 500},
+			},
+
+			expectedResults: []metalv1.HealthCheckResult{
+				{Name: "service/apid", Result: metalv1.HealthCheckPassed, Message: "service is running and healthy"},
+				{Name: "service/kubelet", Result: metalv1.HealthCheckFailed, Message: "service is not registered"},
+				{Name: "disk", Result: metalv1.HealthCheckPassed, Message: "/var is 40% full"},
+				{Name: "memory", Result: metalv1.HealthCheckPassed, Message: "memory is 50% used"},
+			},
+			expectedStatus: metalv1.HealthStatusUnhealthy,
+		},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+
+			endpoint := startMachineService(t, ca, test.svc)
+
+			c, err := talos.NewClient(ctx, endpoint, credentialsSecret(ca, client))
+			require.NoError(t, err)
+
+			t.Cleanup(func() { c.Close() }) //nolint:errcheck
+
+			results := talos.RunHealthChecks(ctx, c, talos.DefaultHealthChecks, test.controlPlane)
+			assert.Equal(t, test.expectedResults, results)
+
+			status, _ := talos.OverallHealth(results)
+			assert.Equal(t, test.expectedStatus, status)
+		})
+	}
+}
+
+func TestOverallHealth(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range []struct {
+		name    string
+		results []metalv1.HealthCheckResult
+
+		expectedStatus  string
+		expectedMessage string
+	}{
+		{
+			name: "no checks",
+
+			expectedStatus:  metalv1.HealthStatusUnknown,
+			expectedMessage: "no health checks were run",
+		},
+		{
+			name: "passed",
+			results: []metalv1.HealthCheckResult{
+				{Name: "disk", Result: metalv1.HealthCheckPassed},
+				{Name: "memory", Result: metalv1.HealthCheckPassed},
+			},
+
+			expectedStatus:  metalv1.HealthStatusHealthy,
+			expectedMessage: "all 2 checks passed",
+		},
+		{
+			name: "failed wins over warning",
+			results: []metalv1.HealthCheckResult{
+				{Name: "disk", Result: metalv1.HealthCheckFailed, Message: "/var is 95% full"},
+				{Name: "memory", Result: metalv1.HealthCheckWarning, Message: "memory is 92% used"},
+			},
+
+			expectedStatus:  metalv1.HealthStatusUnhealthy,
+			expectedMessage: "disk: /var is 95% full; memory: memory is 92% used",
+		},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			status, message := talos.OverallHealth(test.results)
+			assert.Equal(t, test.expectedStatus, status)
+			assert.Equal(t, test.expectedMessage, message)
+		})
+	}
+}
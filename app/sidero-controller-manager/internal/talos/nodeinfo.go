@@ -0,0 +1,149 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package talos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"strings"
+
+	"github.com/cosi-project/runtime/pkg/safe"
+	"github.com/cosi-project/runtime/pkg/state"
+	talosclient "github.com/siderolabs/talos/pkg/machinery/client"
+	"github.com/siderolabs/talos/pkg/machinery/resources/cluster"
+	"github.com/siderolabs/talos/pkg/machinery/resources/hardware"
+	"github.com/siderolabs/talos/pkg/machinery/resources/k8s"
+	"github.com/siderolabs/talos/pkg/machinery/resources/network"
+)
+
+// NodeInfo describes the node as reported by the Talos API.
+type NodeInfo struct {
+	Hostname          string
+	MachineID         string
+	UUID              string
+	Architecture      string
+	KernelVersion     string
+	TalosVersion      string
+	KubernetesVersion string
+	ClusterName       string
+	Addresses         []netip.Addr
+}
+
+// ReadNodeInfo reads node information from the machine API and Talos resources.
+//
+// Resources which don't exist yet (e.g. kubelet spec on a node which is not part of a cluster) are skipped.
+func ReadNodeInfo(ctx context.Context, c *talosclient.Client) (NodeInfo, error) {
+	var info NodeInfo
+
+	resp, err := c.Version(ctx)
+	if err != nil {
+		return info, fmt.Errorf("error getting version: %w", err)
+	}
+
+	if len(resp.Messages) == 0 || resp.Messages[0].Version == nil {
+		return info, errors.New("empty version response")
+	}
+
+	info.TalosVersion = resp.Messages[0].Version.Tag
+	info.Architecture = resp.Messages[0].Version.Arch
+
+	if info.KernelVersion, err = readKernelVersion(ctx, c); err != nil {
+		return info, err
+	}
+
+	hostname, err := safe.StateGetByID[*network.HostnameStatus](ctx, c.COSI, network.HostnameID)
+	if err != nil {
+		if !state.IsNotFoundError(err) {
+			return info, fmt.Errorf("error getting hostname: %w", err)
+		}
+	} else {
+		info.Hostname = hostname.TypedSpec().FQDN()
+	}
+
+	identity, err := safe.StateGetByID[*cluster.Identity](ctx, c.COSI, cluster.LocalIdentity)
+	if err != nil {
+		if !state.IsNotFoundError(err) {
+			return info, fmt.Errorf("error getting node identity: %w", err)
+		}
+	} else {
+		info.MachineID = identity.TypedSpec().NodeID
+	}
+
+	systemInformation, err := safe.StateGetByID[*hardware.SystemInformation](ctx, c.COSI, hardware.SystemInformationID)
+	if err != nil {
+		if !state.IsNotFoundError(err) {
+			return info, fmt.Errorf("error getting system information: %w", err)
+		}
+	} else {
+		info.UUID = systemInformation.TypedSpec().UUID
+	}
+
+	clusterInfo, err := safe.StateGetByID[*cluster.Info](ctx, c.COSI, cluster.InfoID)
+	if err != nil {
+		if !state.IsNotFoundError(err) {
+			return info, fmt.Errorf("error getting cluster info: %w", err)
+		}
+	} else {
+		info.ClusterName = clusterInfo.TypedSpec().ClusterName
+	}
+
+	kubelet, err := safe.StateGetByID[*k8s.KubeletSpec](ctx, c.COSI, k8s.KubeletID)
+	if err != nil {
+		if !state.IsNotFoundError(err) {
+			return info, fmt.Errorf("error getting kubelet spec: %w", err)
+		}
+	} else {
+		info.KubernetesVersion = imageTag(kubelet.TypedSpec().Image)
+	}
+
+	addresses, err := safe.StateGetByID[*network.NodeAddress](ctx, c.COSI, network.NodeAddressCurrentID)
+	if err != nil {
+		if !state.IsNotFoundError(err) {
+			return info, fmt.Errorf("error getting node addresses: %w", err)
+		}
+	} else {
+		info.Addresses = addresses.TypedSpec().IPs()
+	}
+
+	return info, nil
+}
+
+// readKernelVersion parses the kernel release out of /proc/version.
+func readKernelVersion(ctx context.Context, c *talosclient.Client) (string, error) {
+	r, err := c.Read(ctx, "/proc/version")
+	if err != nil {
+		return "", fmt.Errorf("error reading kernel version: %w", err)
+	}
+
+	defer r.Close() //nolint:errcheck
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("error reading kernel version: %w", err)
+	}
+
+	// Linux version 6.12.57-talos (...) #1 SMP ...
+	fields := strings.Fields(string(data))
+	if len(fields) < 3 {
+		return "", fmt.Errorf("unexpected /proc/version contents %q", string(data))
+	}
+
+	return fields[2], nil
+}
+
+// imageTag returns the tag of the container image reference, or an empty string.
+func imageTag(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+
+	idx := strings.LastIndex(image, ":")
+	if idx == -1 || strings.Contains(image[idx:], "/") {
+		return ""
+	}
+
+	return image[idx+1:]
+}
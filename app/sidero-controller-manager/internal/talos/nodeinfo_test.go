@@ -0,0 +1,114 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package talos_test
+
+import (
+	"context"
+	"crypto/x509"
+	"net/netip"
+	"testing"
+
+	"github.com/cosi-project/runtime/pkg/resource"
+	"github.com/cosi-project/runtime/pkg/state"
+	"github.com/cosi-project/runtime/pkg/state/impl/inmem"
+	"github.com/cosi-project/runtime/pkg/state/impl/namespaced"
+	"github.com/siderolabs/talos/pkg/machinery/resources/cluster"
+	"github.com/siderolabs/talos/pkg/machinery/resources/hardware"
+	"github.com/siderolabs/talos/pkg/machinery/resources/k8s"
+	"github.com/siderolabs/talos/pkg/machinery/resources/network"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/internal/talos"
+)
+
+func TestReadNodeInfo(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	ca := newCA(t, 1)
+	client := newLeaf(t, ca, 3, x509.ExtKeyUsageClientAuth)
+
+	resources := state.WrapCore(namespaced.NewState(inmem.Build))
+
+	hostname := network.NewHostnameStatus(network.NamespaceName, network.HostnameID)
+	hostname.TypedSpec().Hostname = "spokane-cp-1"
+	hostname.TypedSpec().Domainname = "example.org"
+
+	identity := cluster.NewIdentity(cluster.NamespaceName, cluster.LocalIdentity)
+	identity.TypedSpec().NodeID = "7x1SuC8Ege5BGXdAfTEff5iQnlWZLfv9h1LGMxA2pYkC"
+
+	systemInformation := hardware.NewSystemInformation(hardware.SystemInformationID)
+	systemInformation.TypedSpec().UUID = "4c4c4544-0039-3010-8048-b7c04f384432"
+
+	info := cluster.NewInfo()
+	info.TypedSpec().ClusterName = "spokane"
+
+	kubelet := k8s.NewKubeletSpec(k8s.NamespaceName, k8s.KubeletID)
+	kubelet.TypedSpec().Image = "ghcr.io/siderolabs/kubelet:v1.34.1"
+
+	addresses := network.NewNodeAddress(network.NamespaceName, network.NodeAddressCurrentID)
+	addresses.TypedSpec().Addresses = []netip.Prefix{
+		netip.MustParsePrefix("10.5.0.2/24"),
+		netip.MustParsePrefix("fd00::2/64"),
+	}
+
+	for _, r := range []resource.Resource{hostname, identity, systemInformation, info, kubelet, addresses} {
+		require.NoError(t, resources.Create(ctx, r))
+	}
+
+	endpoint := startMachineService(t, ca, &fakeMachineService{resources: resources})
+
+	c, err := talos.NewClient(ctx, endpoint, credentialsSecret(ca, client))
+	require.NoError(t, err)
+
+	t.Cleanup(func() { c.Close() }) //nolint:errcheck
+
+	nodeInfo, err := talos.ReadNodeInfo(ctx, c)
+	require.NoError(t, err)
+
+	assert.Equal(t, talos.NodeInfo{
+		Hostname:          "spokane-cp-1.example.org",
+		MachineID:         "7x1SuC8Ege5BGXdAfTEff5iQnlWZLfv9h1LGMxA2pYkC",
+		UUID:              "4c4c4544-0039-3010-8048-b7c04f384432",
+		Architecture:      "amd64",
+		KernelVersion:     "6.12.57-talos",
+		TalosVersion:      "v1.11.5",
+		KubernetesVersion: "v1.34.1",
+		ClusterName:       "spokane",
+		Addresses: []netip.Addr{
+			netip.MustParseAddr("10.5.0.2"),
+			netip.MustParseAddr("fd00::2"),
+		},
+	}, nodeInfo)
+}
+
+func TestReadNodeInfoMissingResources(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	ca := newCA(t, 1)
+	client := newLeaf(t, ca, 3, x509.ExtKeyUsageClientAuth)
+
+	endpoint := startMachineService(t, ca, &fakeMachineService{
+		resources: state.WrapCore(namespaced.NewState(inmem.Build)),
+	})
+
+	c, err := talos.NewClient(ctx, endpoint, credentialsSecret(ca, client))
+	require.NoError(t, err)
+
+	t.Cleanup(func() { c.Close() }) //nolint:errcheck
+
+	nodeInfo, err := talos.ReadNodeInfo(ctx, c)
+	require.NoError(t, err)
+
+	assert.Equal(t, talos.NodeInfo{
+		Architecture:  "amd64",
+		KernelVersion: "6.12.57-talos",
+		TalosVersion:  "v1.11.5",
+	}, nodeInfo)
+}
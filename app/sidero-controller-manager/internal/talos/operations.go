@@ -0,0 +1,82 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package talos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/siderolabs/talos/pkg/machinery/api/machine"
+	talosclient "github.com/siderolabs/talos/pkg/machinery/client"
+
+	metalv1 "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
+)
+
+// RunOperation runs the TalosOperation action on the node.
+//
+// The Talos API only accepts the request, the action itself runs on the node asynchronously.
+func RunOperation(ctx context.Context, c *talosclient.Client, spec *metalv1.TalosOperationSpec) error {
+	switch spec.Action {
+	case metalv1.TalosOperationReboot:
+		if err := c.Reboot(ctx); err != nil {
+			return fmt.Errorf("error rebooting node: %w", err)
+		}
+	case metalv1.TalosOperationShutdown:
+		if err := c.Shutdown(ctx, talosclient.WithShutdownForce(spec.Force)); err != nil {
+			return fmt.Errorf("error shutting down node: %w", err)
+		}
+	case metalv1.TalosOperationReset:
+		req, err := resetRequest(spec.Reset)
+		if err != nil {
+			return err
+		}
+
+		if err = c.ResetGeneric(ctx, req); err != nil {
+			return fmt.Errorf("error resetting node: %w", err)
+		}
+	case metalv1.TalosOperationRestartService:
+		if spec.Service == "" {
+			return fmt.Errorf("service is not set")
+		}
+
+		if _, err := c.ServiceRestart(ctx, spec.Service); err != nil {
+			return fmt.Errorf("error restarting service %q: %w", spec.Service, err)
+		}
+	default:
+		return fmt.Errorf("unsupported action %q", spec.Action)
+	}
+
+	return nil
+}
+
+func resetRequest(opts *metalv1.ResetOptions) (*machine.ResetRequest, error) {
+	if opts == nil {
+		opts = &metalv1.ResetOptions{}
+	}
+
+	req := &machine.ResetRequest{
+		// as in talosctl, the reset is graceful unless explicitly disabled
+		Graceful: opts.Graceful == nil || *opts.Graceful,
+		Reboot:   opts.Reboot,
+	}
+
+	switch opts.WipeMode {
+	case "", metalv1.ResetWipeModeAll:
+		req.Mode = machine.ResetRequest_ALL
+	case metalv1.ResetWipeModeSystemDisk:
+		req.Mode = machine.ResetRequest_SYSTEM_DISK
+	case metalv1.ResetWipeModeUserDisks:
+		if len(opts.UserDisksToWipe) == 0 {
+			return nil, fmt.Errorf("userDisksToWipe is required in the %q wipe mode", opts.WipeMode)
+		}
+
+		req.Mode = machine.ResetRequest_USER_DISKS
+		req.UserDisksToWipe = opts.UserDisksToWipe
+	default:
+		return nil, fmt.Errorf("unsupported wipe mode %q", opts.WipeMode)
+	}
+
+	return req, nil
+}
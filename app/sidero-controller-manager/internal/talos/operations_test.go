@@ -0,0 +1,154 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package talos_test
+
+import (
+	"context"
+	"crypto/x509"
+	"testing"
+
+	"github.com/siderolabs/go-pointer"
+	"github.com/siderolabs/talos/pkg/machinery/api/machine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	metalv1 "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/internal/talos"
+)
+
+func (s *fakeMachineService) record(req proto.Message) {
+	s.requestsMu.Lock()
+	defer s.requestsMu.Unlock()
+
+	s.requests = append(s.requests, req)
+}
+
+func (s *fakeMachineService) Reboot(_ context.Context, req *machine.RebootRequest) (*machine.RebootResponse, error) {
+	s.record(req)
+
+	return &machine.RebootResponse{}, nil
+}
+
+func (s *fakeMachineService) Shutdown(_ context.Context, req *machine.ShutdownRequest) (*machine.ShutdownResponse, error) {
+	s.record(req)
+
+	return &machine.ShutdownResponse{}, nil
+}
+
+func (s *fakeMachineService) Reset(_ context.Context, req *machine.ResetRequest) (*machine.ResetResponse, error) {
+	s.record(req)
+
+	return &machine.ResetResponse{}, nil
+}
+
+func (s *fakeMachineService) ServiceRestart(_ context.Context, req *machine.ServiceRestartRequest) (*machine.ServiceRestartResponse, error) {
+	s.record(req)
+
+	return &machine.ServiceRestartResponse{}, nil
+}
+
+func TestRunOperation(t *testing.T) {
+	t.Parallel()
+
+	ca := newCA(t, 1)
+	client := newLeaf(t, ca, 3, x509.ExtKeyUsageClientAuth)
+
+	for _, tt := range []struct {
+		name     string
+		spec     metalv1.TalosOperationSpec
+		expected proto.Message
+		err      string
+	}{
+		{
+			name:     "reboot",
+			spec:     metalv1.TalosOperationSpec{Action: metalv1.TalosOperationReboot},
+			expected: &machine.RebootRequest{},
+		},
+		{
+			name:     "shutdown",
+			spec:     metalv1.TalosOperationSpec{Action: metalv1.TalosOperationShutdown, Force: true},
+			expected: &machine.ShutdownRequest{Force: true},
+		},
+		{
+			name:     "reset",
+			spec:     metalv1.TalosOperationSpec{Action: metalv1.TalosOperationReset},
+			expected: &machine.ResetRequest{Graceful: true, Mode: machine.ResetRequest_ALL},
+		},
+		{
+			name: "reset not graceful",
+			spec: metalv1.TalosOperationSpec{
+				Action: metalv1.TalosOperationReset,
+				Reset:  &metalv1.ResetOptions{Graceful: pointer.To(false), WipeMode: metalv1.ResetWipeModeSystemDisk},
+			},
+			expected: &machine.ResetRequest{Mode: machine.ResetRequest_SYSTEM_DISK},
+		},
+		{
+			name: "reset user disks",
+			spec: metalv1.TalosOperationSpec{
+				Action: metalv1.TalosOperationReset,
+				Reset: &metalv1.ResetOptions{
+					Reboot:          true,
+					WipeMode:        metalv1.ResetWipeModeUserDisks,
+					UserDisksToWipe: []string{"/dev/sdb"},
+				},
+			},
+			expected: &machine.ResetRequest{
+				Graceful:        true,
+				Reboot:          true,
+				Mode:            machine.ResetRequest_USER_DISKS,
+				UserDisksToWipe: []string{"/dev/sdb"},
+			},
+		},
+		{
+			name: "reset user disks without disks",
+			spec: metalv1.TalosOperationSpec{
+				Action: metalv1.TalosOperationReset,
+				Reset:  &metalv1.ResetOptions{WipeMode: metalv1.ResetWipeModeUserDisks},
+			},
+			err: `userDisksToWipe is required in the "user-disks" wipe mode`,
+		},
+		{
+			name:     "restart service",
+			spec:     metalv1.TalosOperationSpec{Action: metalv1.TalosOperationRestartService, Service: "kubelet"},
+			expected: &machine.ServiceRestartRequest{Id: "kubelet"},
+		},
+		{
+			name: "unsupported",
+			spec: metalv1.TalosOperationSpec{Action: "upgrade"},
+			err:  `unsupported action "upgrade"`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+
+			svc := &fakeMachineService{}
+			endpoint := startMachineService(t, ca, svc)
+
+			c, err := talos.NewClient(ctx, endpoint, credentialsSecret(ca, client))
+			require.NoError(t, err)
+
+			t.Cleanup(func() { c.Close() }) //nolint:errcheck
+
+			err = talos.RunOperation(ctx, c, &tt.spec)
+
+			svc.requestsMu.Lock()
+			defer svc.requestsMu.Unlock()
+
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				assert.Empty(t, svc.requests)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, svc.requests, 1)
+			assert.True(t, proto.Equal(tt.expected, svc.requests[0]), "unexpected request %v", svc.requests[0])
+		})
+	}
+}
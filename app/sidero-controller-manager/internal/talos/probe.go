@@ -0,0 +1,87 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package talos
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	talosclient "github.com/siderolabs/talos/pkg/machinery/client"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	metalv1 "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
+)
+
+// ProbeResult describes a successful Talos API probe.
+type ProbeResult struct {
+	// Version is the Talos version reported by the node.
+	Version string
+	// Latency is the round trip time of the Version call.
+	Latency time.Duration
+}
+
+// Probe calls the machine Version API to verify that the node is reachable and accepts the client credentials.
+func Probe(ctx context.Context, c *talosclient.Client, timeout time.Duration) (ProbeResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+
+	resp, err := c.Version(ctx)
+
+	result := ProbeResult{
+		Latency: time.Since(start),
+	}
+
+	if err != nil {
+		return result, err
+	}
+
+	if len(resp.Messages) == 0 || resp.Messages[0].Version == nil {
+		return result, errors.New("empty version response")
+	}
+
+	result.Version = resp.Messages[0].Version.Tag
+
+	return result, nil
+}
+
+// FailureReason maps a Talos API error to the Connected condition reason.
+func FailureReason(err error) string {
+	switch status.Code(err) { //nolint:exhaustive
+	case codes.DeadlineExceeded:
+		return metalv1.TalosTimeoutReason
+	case codes.Unauthenticated, codes.PermissionDenied:
+		return metalv1.TalosAuthenticationFailedReason
+	case codes.Unavailable:
+		// gRPC reports TLS handshake failures as unavailable transport errors
+		if isTLSError(err) {
+			return metalv1.TalosAuthenticationFailedReason
+		}
+
+		return metalv1.TalosUnreachableReason
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return metalv1.TalosTimeoutReason
+	}
+
+	return metalv1.TalosConnectivityCheckFailedReason
+}
+
+func isTLSError(err error) bool {
+	msg := err.Error()
+
+	for _, s := range []string{"tls:", "x509:", "authentication handshake failed"} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,119 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package talos
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/siderolabs/talos/pkg/machinery/api/machine"
+	talosclient "github.com/siderolabs/talos/pkg/machinery/client"
+	"github.com/siderolabs/talos/pkg/machinery/config/config"
+	"github.com/siderolabs/talos/pkg/machinery/config/types/meta"
+	"github.com/siderolabs/talos/pkg/machinery/config/types/runtime"
+	"github.com/siderolabs/talos/pkg/machinery/config/types/siderolink"
+)
+
+// SideroLinkLogName is the name of the kernel log delivery document managed by Sidero.
+const SideroLinkLogName = "siderolink"
+
+// SideroLinkSettings describes SideroLink documents pushed to the node.
+type SideroLinkSettings struct {
+	// APIURL is the SideroLink provisioning API, e.g. grpc://172.20.0.2:8081.
+	APIURL string
+	// EventSinkEndpoint is the events-manager endpoint over SideroLink, e.g. [fdae:41e4:649b:9303::1]:4002.
+	EventSinkEndpoint string
+	// KmsgLogURL is the log-receiver endpoint over SideroLink, e.g. tcp://[fdae:41e4:649b:9303::1]:4001.
+	KmsgLogURL string
+}
+
+// ConfigureSideroLink updates SideroLink, event sink and kernel log documents in the node machine configuration.
+//
+// Existing SideroLink and event sink documents are replaced, as the node supports only one of each.
+// If settings is nil, the documents are removed. The configuration is applied without a reboot,
+// and only if the documents on the node differ from the expected ones.
+// The returned value indicates whether the configuration was changed.
+func ConfigureSideroLink(ctx context.Context, c *talosclient.Client, settings *SideroLinkSettings) (bool, error) {
+	docs, err := ReadConfigDocuments(ctx, c)
+	if err != nil {
+		return false, err
+	}
+
+	var (
+		current SideroLinkSettings
+		updated []config.Document
+	)
+
+	for _, doc := range docs {
+		switch d := doc.(type) {
+		case *siderolink.ConfigV1Alpha1:
+			if d.APIUrlConfig.URL != nil {
+				current.APIURL = d.APIUrlConfig.String()
+			}
+		case *runtime.EventSinkV1Alpha1:
+			current.EventSinkEndpoint = d.Endpoint
+		case *runtime.KmsgLogV1Alpha1:
+			if d.MetaName != SideroLinkLogName {
+				updated = append(updated, doc)
+
+				continue
+			}
+
+			if d.KmsgLogURL.URL != nil {
+				current.KmsgLogURL = d.KmsgLogURL.String()
+			}
+		default:
+			updated = append(updated, doc)
+		}
+	}
+
+	var expected SideroLinkSettings
+
+	if settings != nil {
+		expected = *settings
+
+		managed, err := settings.documents()
+		if err != nil {
+			return false, err
+		}
+
+		updated = append(updated, managed...)
+	}
+
+	if current == expected {
+		return false, nil
+	}
+
+	if err = ApplyDocuments(ctx, c, updated, machine.ApplyConfigurationRequest_NO_REBOOT); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (settings *SideroLinkSettings) documents() ([]config.Document, error) {
+	apiURL, err := url.Parse(settings.APIURL)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing SideroLink API URL: %w", err)
+	}
+
+	logURL, err := url.Parse(settings.KmsgLogURL)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing log receiver URL: %w", err)
+	}
+
+	sideroLinkConfig := siderolink.NewConfigV1Alpha1()
+	sideroLinkConfig.APIUrlConfig = meta.URL{URL: apiURL}
+
+	eventSinkConfig := runtime.NewEventSinkV1Alpha1()
+	eventSinkConfig.Endpoint = settings.EventSinkEndpoint
+
+	kmsgLogConfig := runtime.NewKmsgLogV1Alpha1()
+	kmsgLogConfig.MetaName = SideroLinkLogName
+	kmsgLogConfig.KmsgLogURL = meta.URL{URL: logURL}
+
+	return []config.Document{sideroLinkConfig, eventSinkConfig, kmsgLogConfig}, nil
+}
@@ -0,0 +1,144 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package talos_test
+
+import (
+	"context"
+	"crypto/x509"
+	"net/url"
+	"testing"
+
+	"github.com/cosi-project/runtime/pkg/state"
+	"github.com/cosi-project/runtime/pkg/state/impl/inmem"
+	"github.com/cosi-project/runtime/pkg/state/impl/namespaced"
+	"github.com/siderolabs/talos/pkg/machinery/api/machine"
+	"github.com/siderolabs/talos/pkg/machinery/config/configloader"
+	"github.com/siderolabs/talos/pkg/machinery/config/container"
+	"github.com/siderolabs/talos/pkg/machinery/config/types/runtime"
+	"github.com/siderolabs/talos/pkg/machinery/config/types/siderolink"
+	"github.com/siderolabs/talos/pkg/machinery/config/types/v1alpha1"
+	talosconfig "github.com/siderolabs/talos/pkg/machinery/resources/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/internal/talos"
+)
+
+func (s *fakeMachineService) ApplyConfiguration(ctx context.Context, req *machine.ApplyConfigurationRequest) (*machine.ApplyConfigurationResponse, error) {
+	cfg, err := configloader.NewFromBytes(req.Data)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if req.DryRun {
+		return &machine.ApplyConfigurationResponse{
+			Messages: []*machine.ApplyConfiguration{{Mode: req.Mode, ModeDetails: "Dry run summary"}},
+		}, nil
+	}
+
+	current, err := s.resources.Get(ctx, talosconfig.NewMachineConfig(nil).Metadata())
+	if err != nil {
+		return nil, err
+	}
+
+	mc := talosconfig.NewMachineConfig(cfg)
+	mc.Metadata().SetVersion(current.Metadata().Version())
+
+	if err = s.resources.Update(ctx, mc); err != nil {
+		return nil, err
+	}
+
+	s.appliedMu.Lock()
+	s.applied = append(s.applied, req.Mode)
+	s.appliedMu.Unlock()
+
+	return &machine.ApplyConfigurationResponse{
+		Messages: []*machine.ApplyConfiguration{{Mode: req.Mode}},
+	}, nil
+}
+
+func (s *fakeMachineService) appliedModes() []machine.ApplyConfigurationRequest_Mode {
+	s.appliedMu.Lock()
+	defer s.appliedMu.Unlock()
+
+	return append([]machine.ApplyConfigurationRequest_Mode(nil), s.applied...)
+}
+
+func TestConfigureSideroLink(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	ca := newCA(t, 1)
+	client := newLeaf(t, ca, 3, x509.ExtKeyUsageClientAuth)
+
+	// kernel log documents not managed by Sidero should be kept
+	otherLog := runtime.NewKmsgLogV1Alpha1()
+	otherLog.MetaName = "remote"
+	otherLogURL, err := url.Parse("udp://10.5.0.1:6000")
+	require.NoError(t, err)
+
+	otherLog.KmsgLogURL.URL = otherLogURL
+
+	cfg, err := container.New(&v1alpha1.Config{
+		ConfigVersion: "v1alpha1",
+		MachineConfig: &v1alpha1.MachineConfig{MachineType: "worker"},
+	}, otherLog)
+	require.NoError(t, err)
+
+	resources := state.WrapCore(namespaced.NewState(inmem.Build))
+	require.NoError(t, resources.Create(ctx, talosconfig.NewMachineConfig(cfg)))
+
+	svc := &fakeMachineService{resources: resources}
+	endpoint := startMachineService(t, ca, svc)
+
+	c, err := talos.NewClient(ctx, endpoint, credentialsSecret(ca, client))
+	require.NoError(t, err)
+
+	t.Cleanup(func() { c.Close() }) //nolint:errcheck
+
+	settings := &talos.SideroLinkSettings{
+		APIURL:            "grpc://172.20.0.2:8081",
+		EventSinkEndpoint: "[fdae:41e4:649b:9303::1]:4002",
+		KmsgLogURL:        "tcp://[fdae:41e4:649b:9303::1]:4001",
+	}
+
+	changed, err := talos.ConfigureSideroLink(ctx, c, settings)
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	docs, err := talos.ReadConfigDocuments(ctx, c)
+	require.NoError(t, err)
+	require.Len(t, docs, 5)
+
+	assert.Equal(t, "remote", docs[1].(*runtime.KmsgLogV1Alpha1).MetaName)
+	assert.Equal(t, settings.APIURL, docs[2].(*siderolink.ConfigV1Alpha1).APIUrlConfig.String())
+	assert.Equal(t, settings.EventSinkEndpoint, docs[3].(*runtime.EventSinkV1Alpha1).Endpoint)
+	assert.Equal(t, talos.SideroLinkLogName, docs[4].(*runtime.KmsgLogV1Alpha1).MetaName)
+	assert.Equal(t, settings.KmsgLogURL, docs[4].(*runtime.KmsgLogV1Alpha1).KmsgLogURL.String())
+
+	// same settings, nothing to apply
+	changed, err = talos.ConfigureSideroLink(ctx, c, settings)
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	changed, err = talos.ConfigureSideroLink(ctx, c, nil)
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	docs, err = talos.ReadConfigDocuments(ctx, c)
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+
+	assert.Equal(t, "remote", docs[1].(*runtime.KmsgLogV1Alpha1).MetaName)
+
+	assert.Equal(t, []machine.ApplyConfigurationRequest_Mode{
+		machine.ApplyConfigurationRequest_NO_REBOOT,
+		machine.ApplyConfigurationRequest_NO_REBOOT,
+	}, svc.appliedModes())
+
+}
@@ -0,0 +1,82 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package talos provides access to the Talos API of adopted nodes.
+package talos
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+
+	talosclient "github.com/siderolabs/talos/pkg/machinery/client"
+	clientconfig "github.com/siderolabs/talos/pkg/machinery/client/config"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Secret keys holding Talos API client credentials.
+//
+// Secret should contain either TalosconfigKey or all of CAKey, CertKey and KeyKey.
+const (
+	TalosconfigKey = "talosconfig"
+	CAKey          = "ca.crt"
+	CertKey        = corev1.TLSCertKey
+	KeyKey         = corev1.TLSPrivateKeyKey
+)
+
+// NewClient creates a Talos API client for the endpoint using credentials from the secret.
+func NewClient(ctx context.Context, endpoint string, secret *corev1.Secret) (*talosclient.Client, error) {
+	opts, err := ClientOptions(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return talosclient.New(ctx, append(opts, talosclient.WithEndpoints(endpoint))...)
+}
+
+// ClientOptions builds Talos client options from the credentials stored in the secret.
+func ClientOptions(secret *corev1.Secret) ([]talosclient.OptionFunc, error) {
+	if data, ok := secret.Data[TalosconfigKey]; ok {
+		cfg, err := clientconfig.FromBytes(data)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing talosconfig from secret %s/%s: %w", secret.Namespace, secret.Name, err)
+		}
+
+		return []talosclient.OptionFunc{talosclient.WithConfig(cfg)}, nil
+	}
+
+	tlsConfig, err := tlsConfigFrom(secret)
+	if err != nil {
+		return nil, fmt.Errorf("error loading credentials from secret %s/%s: %w", secret.Namespace, secret.Name, err)
+	}
+
+	return []talosclient.OptionFunc{talosclient.WithTLSConfig(tlsConfig)}, nil
+}
+
+func tlsConfigFrom(secret *corev1.Secret) (*tls.Config, error) {
+	for _, key := range []string{CAKey, CertKey, KeyKey} {
+		if _, ok := secret.Data[key]; !ok {
+			return nil, fmt.Errorf("missing %q key", key)
+		}
+	}
+
+	pool := x509.NewCertPool()
+
+	if !pool.AppendCertsFromPEM(secret.Data[CAKey]) {
+		return nil, errors.New("failed to parse CA certificate")
+	}
+
+	crt, err := tls.X509KeyPair(secret.Data[CertKey], secret.Data[KeyKey])
+	if err != nil {
+		return nil, fmt.Errorf("error loading client key pair: %w", err)
+	}
+
+	return &tls.Config{
+		RootCAs:      pool,
+		Certificates: []tls.Certificate{crt},
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
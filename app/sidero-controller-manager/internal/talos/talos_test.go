@@ -0,0 +1,388 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package talos_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cosi-project/runtime/api/v1alpha1"
+	"github.com/cosi-project/runtime/pkg/state"
+	"github.com/cosi-project/runtime/pkg/state/protobuf/server"
+	"github.com/siderolabs/talos/pkg/machinery/api/common"
+	"github.com/siderolabs/talos/pkg/machinery/api/machine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	metalv1 "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/internal/talos"
+)
+
+type fakeMachineService struct {
+	machine.UnimplementedMachineServiceServer
+
+	// resources are served over the COSI API if set
+	resources state.CoreState
+	delay     time.Duration
+
+	services    []*machine.ServiceInfo
+	etcdMembers []*machine.EtcdMember
+	etcdAlarms  []*machine.EtcdMemberAlarm
+	mounts      []*machine.MountStat
+	meminfo     *machine.MemInfo
+
+	appliedMu sync.Mutex
+	applied   []machine.ApplyConfigurationRequest_Mode
+
+	upgradesMu sync.Mutex
+	upgrades   []*machine.UpgradeRequest
+
+	requestsMu sync.Mutex
+	requests   []proto.Message
+
+	// etcdSnapshot is streamed by EtcdSnapshot, and EtcdRecover stores the uploaded snapshot in recovered
+	etcdSnapshot []byte
+	recovered    []byte
+}
+
+func (s *fakeMachineService) Version(ctx context.Context, _ *emptypb.Empty) (*machine.VersionResponse, error) {
+	select {
+	case <-time.After(s.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	return &machine.VersionResponse{
+		Messages: []*machine.Version{
+			{
+				Version: &machine.VersionInfo{
+					Tag:  "v1.11.5",
+					Arch: "amd64",
+				},
+			},
+		},
+	}, nil
+}
+
+func (s *fakeMachineService) Read(req *machine.ReadRequest, srv machine.MachineService_ReadServer) error {
+	if req.Path != "/proc/version" {
+		return status.Errorf(codes.NotFound, "file %q not found", req.Path)
+	}
+
+	return srv.Send(&common.Data{
+		Bytes: []byte("Linux version 6.12.57-talos (@buildkitsandbox) (gcc (GCC) 14.2.0) #1 SMP\n"),
+	})
+}
+
+type keyPair struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+func newKeyPair(t *testing.T, template *x509.Certificate, parent *keyPair) *keyPair {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	parentCert, parentKey := template, key
+
+	if parent != nil {
+		parentCert, parentKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parentCert, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return &keyPair{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func newCA(t *testing.T, serial int64) *keyPair {
+	t.Helper()
+
+	return newKeyPair(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{Organization: []string{"talos"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}, nil)
+}
+
+func newLeaf(t *testing.T, ca *keyPair, serial int64, usage x509.ExtKeyUsage) *keyPair {
+	t.Helper()
+
+	return newKeyPair(t, &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{Organization: []string{"os:admin"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}, ca)
+}
+
+// startMachineService runs a fake apid requiring client certificates signed by the CA.
+func startMachineService(t *testing.T, ca *keyPair, svc *fakeMachineService) string {
+	t.Helper()
+
+	serverCert := newLeaf(t, ca, 2, x509.ExtKeyUsageServerAuth)
+
+	crt, err := tls.X509KeyPair(serverCert.certPEM, serverCert.keyPEM)
+	require.NoError(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{crt},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	})))
+
+	machine.RegisterMachineServiceServer(srv, svc)
+
+	if svc.resources != nil {
+		v1alpha1.RegisterStateServer(srv, server.NewState(svc.resources))
+	}
+
+	go srv.Serve(lis) //nolint:errcheck
+
+	t.Cleanup(srv.Stop)
+
+	return lis.Addr().String()
+}
+
+func credentialsSecret(ca, client *keyPair) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "talos-credentials",
+			Namespace: corev1.NamespaceDefault,
+		},
+		Data: map[string][]byte{
+			talos.CAKey:   ca.certPEM,
+			talos.CertKey: client.certPEM,
+			talos.KeyKey:  client.keyPEM,
+		},
+	}
+}
+
+func TestProbe(t *testing.T) {
+	t.Parallel()
+
+	ca := newCA(t, 1)
+	client := newLeaf(t, ca, 3, x509.ExtKeyUsageClientAuth)
+
+	otherCA := newCA(t, 4)
+	otherClient := newLeaf(t, otherCA, 5, x509.ExtKeyUsageClientAuth)
+
+	endpoint := startMachineService(t, ca, &fakeMachineService{})
+	slowEndpoint := startMachineService(t, ca, &fakeMachineService{delay: time.Second})
+
+	// grab a free port and release it, so that nothing listens on it
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	closedEndpoint := lis.Addr().String()
+	require.NoError(t, lis.Close())
+
+	for _, test := range []struct {
+		name     string
+		endpoint string
+		secret   *corev1.Secret
+		timeout  time.Duration
+
+		expectedVersion string
+		expectedReason  string
+	}{
+		{
+			name:     "reachable",
+			endpoint: endpoint,
+			secret:   credentialsSecret(ca, client),
+			timeout:  5 * time.Second,
+
+			expectedVersion: "v1.11.5",
+		},
+		{
+			name:     "untrusted client certificate",
+			endpoint: endpoint,
+			secret: &corev1.Secret{
+				Data: map[string][]byte{
+					talos.CAKey:   ca.certPEM,
+					talos.CertKey: otherClient.certPEM,
+					talos.KeyKey:  otherClient.keyPEM,
+				},
+			},
+			timeout: 5 * time.Second,
+
+			expectedReason: metalv1.TalosAuthenticationFailedReason,
+		},
+		{
+			name:     "untrusted server certificate",
+			endpoint: endpoint,
+			secret:   credentialsSecret(otherCA, client),
+			timeout:  5 * time.Second,
+
+			expectedReason: metalv1.TalosAuthenticationFailedReason,
+		},
+		{
+			name:     "timeout",
+			endpoint: slowEndpoint,
+			secret:   credentialsSecret(ca, client),
+			timeout:  100 * time.Millisecond,
+
+			expectedReason: metalv1.TalosTimeoutReason,
+		},
+		{
+			name:     "unreachable",
+			endpoint: closedEndpoint,
+			secret:   credentialsSecret(ca, client),
+			timeout:  5 * time.Second,
+
+			expectedReason: metalv1.TalosUnreachableReason,
+		},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+
+			c, err := talos.NewClient(ctx, test.endpoint, test.secret)
+			require.NoError(t, err)
+
+			t.Cleanup(func() { c.Close() }) //nolint:errcheck
+
+			result, err := talos.Probe(ctx, c, test.timeout)
+
+			if test.expectedReason != "" {
+				require.Error(t, err)
+				assert.Equal(t, test.expectedReason, talos.FailureReason(err), "error: %s", err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedVersion, result.Version)
+			assert.Positive(t, result.Latency)
+		})
+	}
+}
+
+func TestClientOptions(t *testing.T) {
+	t.Parallel()
+
+	ca := newCA(t, 1)
+	client := newLeaf(t, ca, 2, x509.ExtKeyUsageClientAuth)
+
+	for _, test := range []struct {
+		name string
+		data map[string][]byte
+
+		expectedError string
+	}{
+		{
+			name: "certificates",
+			data: credentialsSecret(ca, client).Data,
+		},
+		{
+			name: "talosconfig",
+			data: map[string][]byte{
+				talos.TalosconfigKey: []byte("context: default\ncontexts:\n  default:\n    endpoints:\n      - 127.0.0.1\n"),
+			},
+		},
+		{
+			name: "invalid talosconfig",
+			data: map[string][]byte{
+				talos.TalosconfigKey: []byte("contexts: [}"),
+			},
+
+			expectedError: "error parsing talosconfig from secret default/creds",
+		},
+		{
+			name: "missing key",
+			data: map[string][]byte{
+				talos.CAKey:   ca.certPEM,
+				talos.CertKey: client.certPEM,
+			},
+
+			expectedError: "error loading credentials from secret default/creds: missing \"tls.key\" key",
+		},
+		{
+			name: "invalid CA",
+			data: map[string][]byte{
+				talos.CAKey:   []byte("not a certificate"),
+				talos.CertKey: client.certPEM,
+				talos.KeyKey:  client.keyPEM,
+			},
+
+			expectedError: "error loading credentials from secret default/creds: failed to parse CA certificate",
+		},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts, err := talos.ClientOptions(&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "creds",
+					Namespace: corev1.NamespaceDefault,
+				},
+				Data: test.data,
+			})
+
+			if test.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.expectedError)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.NotEmpty(t, opts)
+		})
+	}
+}
@@ -0,0 +1,41 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package talos
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	talosclient "github.com/siderolabs/talos/pkg/machinery/client"
+)
+
+// DefaultInstallerImage is the repository of the installer image used for upgrades when no image is specified.
+const DefaultInstallerImage = "ghcr.io/siderolabs/installer"
+
+// InstallerImage returns the default installer image for the Talos version.
+func InstallerImage(version string) string {
+	return DefaultInstallerImage + ":v" + strings.TrimPrefix(version, "v")
+}
+
+// VersionsEqual compares Talos or Kubernetes versions, ignoring the optional "v" prefix.
+func VersionsEqual(a, b string) bool {
+	return strings.TrimPrefix(a, "v") == strings.TrimPrefix(b, "v")
+}
+
+// Upgrade starts the upgrade of the node with the installer image.
+//
+// The ephemeral partition is preserved, so the node keeps etcd data and the workloads' local state.
+// The node reboots into the new version once the installer finishes.
+func Upgrade(ctx context.Context, c *talosclient.Client, image string) error {
+	if _, err := c.UpgradeWithOptions(ctx,
+		talosclient.WithUpgradeImage(image),
+		talosclient.WithUpgradePreserve(true),
+	); err != nil {
+		return fmt.Errorf("error upgrading node: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package talos_test
+
+import (
+	"context"
+	"crypto/x509"
+	"testing"
+
+	"github.com/siderolabs/talos/pkg/machinery/api/machine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/internal/talos"
+)
+
+func (s *fakeMachineService) Upgrade(_ context.Context, req *machine.UpgradeRequest) (*machine.UpgradeResponse, error) {
+	s.upgradesMu.Lock()
+	s.upgrades = append(s.upgrades, req)
+	s.upgradesMu.Unlock()
+
+	return &machine.UpgradeResponse{
+		Messages: []*machine.Upgrade{{Ack: "Upgrade request received"}},
+	}, nil
+}
+
+func TestUpgrade(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	ca := newCA(t, 1)
+	client := newLeaf(t, ca, 3, x509.ExtKeyUsageClientAuth)
+
+	svc := &fakeMachineService{}
+	endpoint := startMachineService(t, ca, svc)
+
+	c, err := talos.NewClient(ctx, endpoint, credentialsSecret(ca, client))
+	require.NoError(t, err)
+
+	t.Cleanup(func() { c.Close() }) //nolint:errcheck
+
+	require.NoError(t, talos.Upgrade(ctx, c, talos.InstallerImage("1.12.0")))
+
+	svc.upgradesMu.Lock()
+	defer svc.upgradesMu.Unlock()
+
+	require.Len(t, svc.upgrades, 1)
+	assert.Equal(t, "ghcr.io/siderolabs/installer:v1.12.0", svc.upgrades[0].Image)
+	assert.True(t, svc.upgrades[0].Preserve)
+	assert.False(t, svc.upgrades[0].Stage)
+}
+
+func TestVersionsEqual(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, talos.VersionsEqual("v1.11.5", "v1.11.5"))
+	assert.True(t, talos.VersionsEqual("1.11.5", "v1.11.5"))
+	assert.True(t, talos.VersionsEqual("v1.34.1", "1.34.1"))
+	assert.False(t, talos.VersionsEqual("v1.11.5", "v1.12.0"))
+	assert.False(t, talos.VersionsEqual("v1.11.5", ""))
+}
@@ -19,6 +19,7 @@ import (
 	"golang.org/x/net/http2/h2c"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
@@ -32,6 +33,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
@@ -42,11 +44,13 @@ import (
 	"github.com/siderolabs/sidero/app/sidero-controller-manager/internal/dhcp"
 	"github.com/siderolabs/sidero/app/sidero-controller-manager/internal/ipxe"
 	"github.com/siderolabs/sidero/app/sidero-controller-manager/internal/metadata"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/internal/notifications"
 	"github.com/siderolabs/sidero/app/sidero-controller-manager/internal/power/api"
 	"github.com/siderolabs/sidero/app/sidero-controller-manager/internal/server"
 	"github.com/siderolabs/sidero/app/sidero-controller-manager/internal/siderolink"
 	"github.com/siderolabs/sidero/app/sidero-controller-manager/internal/tftp"
 	"github.com/siderolabs/sidero/app/sidero-controller-manager/pkg/constants"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/pkg/managementapi"
 	siderotypes "github.com/siderolabs/sidero/app/sidero-controller-manager/pkg/types"
 	// +kubebuilder:scaffold:imports
 )
@@ -91,6 +95,15 @@ var (
 	disableDHCPProxy     bool
 	webhookPort          int
 	webhookCertDir       string
+	etcdBackupDir        string
+	reverseSync          bool
+	webhookSecret        string
+	serverSyncEndpoint   string
+	serverSyncSecret     string
+	syncLoopInterval     time.Duration
+	syncLoopConcurrency  int
+	assetMirror          string
+	assetAllowedHosts    []string
 
 	testPowerSimulatedExplicitFailureProb float64
 	testPowerSimulatedSilentFailureProb   float64
@@ -119,6 +132,15 @@ func InitFlags(fs *pflag.FlagSet) {
 	fs.DurationVar(&serverRebootTimeout, "server-reboot-timeout", constants.DefaultServerRebootTimeout, "Timeout to wait for the server to restart and start wipe.")
 	fs.StringVar(&ipmiPXEMethod, "ipmi-pxe-method", string(siderotypes.PXEModeUEFI), fmt.Sprintf("Default method to use to set server to boot from PXE via IPMI: %s.", []string{siderotypes.PXEModeUEFI, siderotypes.PXEModeBIOS}))
 	fs.BoolVar(&disableDHCPProxy, "disable-dhcp-proxy", false, "Disable DHCP Proxy service.")
+	fs.BoolVar(&reverseSync, "management-api-reverse-sync", false, "Create and update AdoptedServers from the servers listed by the Management API for each ManagementAPIInventory.")
+	fs.StringVar(&webhookSecret, "management-api-webhook-secret", "", "Secret (namespace/name) holding the webhook-secret HMAC key of the Management API notifications, notifications are disabled if empty.")
+	fs.DurationVar(&syncLoopInterval, "management-api-sync-interval", 0, "Interval of the batched Management API sync of the AdoptedServers grouped by cluster, each AdoptedServer is synced on its own reconcile if zero.")
+	fs.IntVar(&syncLoopConcurrency, "management-api-sync-concurrency", 4, "Number of Management API endpoints and clusters synced at the same time by the batched sync.")
+	fs.StringVar(&serverSyncEndpoint, "management-api-server-sync-endpoint", "", "Management API endpoint all the Servers are synced to, unless overridden by their ServerClass, the global Server sync is disabled if empty.")
+	fs.StringVar(&serverSyncSecret, "management-api-server-sync-secret", "", "Secret (namespace/name) holding the Management API credentials of the global Server sync.")
+	fs.StringVar(&assetMirror, "asset-mirror", "", "Asset mirror (e.g. https://artifacts.local/talos) the Talos release and Image Factory assets of all the Environments are downloaded from, Environments are air-gapped only if they enable it when empty.")
+	fs.StringSliceVar(&assetAllowedHosts, "asset-allowed-hosts", nil, "Hosts (besides the asset mirror) the air-gapped Environments can download assets from.")
+	fs.StringVar(&etcdBackupDir, "etcd-backup-dir", "", "Root directory of the local etcd backup sinks (usually a mounted persistent volume), local sinks are disabled if empty.")
 	fs.Float64Var(&testPowerSimulatedExplicitFailureProb, "test-power-simulated-explicit-failure-prob", 0, "Test failure simulation setting.")
 	fs.Float64Var(&testPowerSimulatedSilentFailureProb, "test-power-simulated-silent-failure-prob", 0, "Test failure simulation setting.")
 
@@ -229,6 +251,15 @@ func main() {
 		mgr.GetScheme(),
 		corev1.EventSource{Component: "sidero-controller-manager"})
 
+	managementAPIClients := managementapi.NewPool()
+	defer managementAPIClients.Close()
+
+	var notificationEvents chan event.GenericEvent
+
+	if webhookSecret != "" {
+		notificationEvents = make(chan event.GenericEvent, 1024)
+	}
+
 	ctx := ctrl.SetupSignalHandler()
 
 	if err = (&controllers.EnvironmentReconciler{
@@ -238,6 +269,9 @@ func main() {
 		TalosRelease: TalosRelease,
 		APIEndpoint:  apiEndpoint,
 		APIPort:      uint16(apiPort),
+
+		AssetMirror:       assetMirror,
+		AllowedAssetHosts: assetAllowedHosts,
 	}).SetupWithManager(ctx, mgr, controller.Options{MaxConcurrentReconciles: defaultMaxConcurrentReconciles}); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Environment")
 		os.Exit(1)
@@ -266,13 +300,113 @@ func main() {
 	}
 
 	if err = (&controllers.AdoptedServerReconciler{
+		Client:      mgr.GetClient(),
+		Log:         ctrl.Log.WithName("controllers").WithName("AdoptedServer"),
+		Scheme:      mgr.GetScheme(),
+		APIReader:   mgr.GetAPIReader(),
+		Recorder:    recorder,
+		APIEndpoint: apiEndpoint,
+		APIPort:     uint16(apiPort),
+
+		ManagementAPIClients:     managementAPIClients,
+		Notifications:            notificationEvents,
+		BatchedManagementAPISync: syncLoopInterval > 0,
+	}).SetupWithManager(ctx, mgr, controller.Options{MaxConcurrentReconciles: defaultMaxConcurrentReconciles}); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "AdoptedServer")
+		os.Exit(1)
+	}
+
+	if syncLoopInterval > 0 {
+		if err = (&controllers.ManagementAPISyncLoop{
+			Client:   mgr.GetClient(),
+			Log:      ctrl.Log.WithName("controllers").WithName("ManagementAPISyncLoop"),
+			Scheme:   mgr.GetScheme(),
+			Recorder: recorder,
+
+			ManagementAPIClients: managementAPIClients,
+			Interval:             syncLoopInterval,
+			Concurrency:          syncLoopConcurrency,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "ManagementAPISyncLoop")
+			os.Exit(1)
+		}
+	}
+
+	if err = (&controllers.AdoptionScanReconciler{
 		Client:    mgr.GetClient(),
-		Log:       ctrl.Log.WithName("controllers").WithName("AdoptedServer"),
+		Log:       ctrl.Log.WithName("controllers").WithName("AdoptionScan"),
 		Scheme:    mgr.GetScheme(),
 		APIReader: mgr.GetAPIReader(),
 		Recorder:  recorder,
 	}).SetupWithManager(mgr, controller.Options{MaxConcurrentReconciles: defaultMaxConcurrentReconciles}); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "AdoptedServer")
+		setupLog.Error(err, "unable to create controller", "controller", "AdoptionScan")
+		os.Exit(1)
+	}
+
+	var serverSyncDefault *metalv1alpha2.ManagementAPIConfig
+
+	if serverSyncEndpoint != "" {
+		serverSyncDefault = &metalv1alpha2.ManagementAPIConfig{
+			Enabled:  true,
+			Endpoint: serverSyncEndpoint,
+		}
+
+		if serverSyncSecret != "" {
+			secret := namespacedNameFlag(serverSyncSecret)
+
+			serverSyncDefault.SecretRef = &corev1.SecretReference{Namespace: secret.Namespace, Name: secret.Name}
+		}
+	}
+
+	if err = (&controllers.ServerManagementAPIReconciler{
+		Client:    mgr.GetClient(),
+		Log:       ctrl.Log.WithName("controllers").WithName("ServerManagementAPI"),
+		Scheme:    mgr.GetScheme(),
+		APIReader: mgr.GetAPIReader(),
+		Recorder:  recorder,
+
+		ManagementAPIClients: managementAPIClients,
+		Default:              serverSyncDefault,
+	}).SetupWithManager(mgr, controller.Options{MaxConcurrentReconciles: defaultMaxConcurrentReconciles}); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ServerManagementAPI")
+		os.Exit(1)
+	}
+
+	if reverseSync {
+		if err = (&controllers.ManagementAPIInventoryReconciler{
+			Client:    mgr.GetClient(),
+			Log:       ctrl.Log.WithName("controllers").WithName("ManagementAPIInventory"),
+			Scheme:    mgr.GetScheme(),
+			APIReader: mgr.GetAPIReader(),
+			Recorder:  recorder,
+
+			ManagementAPIClients: managementAPIClients,
+		}).SetupWithManager(mgr, controller.Options{MaxConcurrentReconciles: defaultMaxConcurrentReconciles}); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "ManagementAPIInventory")
+			os.Exit(1)
+		}
+	}
+
+	if err = (&controllers.TalosOperationReconciler{
+		Client:    mgr.GetClient(),
+		Log:       ctrl.Log.WithName("controllers").WithName("TalosOperation"),
+		Scheme:    mgr.GetScheme(),
+		APIReader: mgr.GetAPIReader(),
+		Recorder:  recorder,
+	}).SetupWithManager(mgr, controller.Options{MaxConcurrentReconciles: defaultMaxConcurrentReconciles}); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "TalosOperation")
+		os.Exit(1)
+	}
+
+	if err = (&controllers.EtcdBackupPolicyReconciler{
+		Client:    mgr.GetClient(),
+		Log:       ctrl.Log.WithName("controllers").WithName("EtcdBackupPolicy"),
+		Scheme:    mgr.GetScheme(),
+		APIReader: mgr.GetAPIReader(),
+		Recorder:  recorder,
+		BackupDir: etcdBackupDir,
+	}).SetupWithManager(mgr, controller.Options{MaxConcurrentReconciles: defaultMaxConcurrentReconciles}); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "EtcdBackupPolicy")
 		os.Exit(1)
 	}
 
@@ -319,6 +453,15 @@ func main() {
 		os.Exit(1)
 	}
 
+	if webhookSecret != "" {
+		setupLog.Info("starting Management API notifications receiver")
+
+		if err := notifications.RegisterReceiver(httpMux, mgr.GetClient(), namespacedNameFlag(webhookSecret), notificationEvents); err != nil {
+			setupLog.Error(err, "unable to start Management API notifications receiver")
+			os.Exit(1)
+		}
+	}
+
 	setupLog.Info("starting internal API server")
 
 	apiRecorder := eventBroadcaster.NewRecorder(
@@ -421,6 +564,11 @@ func setupWebhooks(mgr ctrl.Manager) {
 		setupLog.Error(err, "unable to create webhook", "webhook", "Server")
 		os.Exit(1)
 	}
+
+	if err := (&metalv1alpha2.AdoptedServer{}).SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "AdoptedServer")
+		os.Exit(1)
+	}
 }
 
 func setupChecks(mgr ctrl.Manager, httpPort int) {
@@ -436,3 +584,13 @@ func setupChecks(mgr ctrl.Manager, httpPort int) {
 		os.Exit(1)
 	}
 }
+
+// namespacedNameFlag parses a "namespace/name" flag value, the namespace defaults to "default".
+func namespacedNameFlag(value string) types.NamespacedName {
+	namespace, name, ok := strings.Cut(value, "/")
+	if !ok {
+		return types.NamespacedName{Namespace: corev1.NamespaceDefault, Name: value}
+	}
+
+	return types.NamespacedName{Namespace: namespace, Name: name}
+}
@@ -14,6 +14,9 @@ const (
 	KernelAsset = "vmlinuz"
 	InitrdAsset = "initramfs.xz"
 	BootAsset   = "boot.raw.xz"
+	UKIAsset    = "uki.efi"
+
+	ImageCacheAsset = "image-cache.oci"
 
 	DefaultRequeueAfter = time.Second * 20
 	PowerCheckPeriod    = 5 * time.Minute
@@ -7,27 +7,39 @@ package managementapi
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/pkg/errors"
 )
 
 const (
-	defaultTimeout     = 30 * time.Second
-	defaultAPIVersion  = "v1"
-	contentTypeJSON    = "application/json"
+	defaultTimeout    = 30 * time.Second
+	defaultAPIVersion = "v1"
+	contentTypeJSON   = "application/json"
+
+	// IdempotencyKeyHeader carries the key which lets the Management API deduplicate retried POST requests.
+	IdempotencyKeyHeader = "Idempotency-Key"
 )
 
 // Client represents a Management API client.
+//
+// Client is safe for concurrent use, and should be reused (see Pool) to keep the connections and the circuit breaker state.
 type Client struct {
-	baseURL    string
-	httpClient *http.Client
-	apiKey     string // Optional API key for authentication
+	baseURL     string
+	httpClient  *http.Client
+	apiKey      string // Optional API key for authentication
+	bearerToken string // Optional bearer token for authentication
+	retry       RetryPolicy
+	breaker     *CircuitBreaker
 }
 
 // ClientOption is a functional option for configuring the Client.
@@ -47,6 +59,49 @@ func WithAPIKey(apiKey string) ClientOption {
 	}
 }
 
+// WithBearerToken sets the bearer token for authentication.
+func WithBearerToken(token string) ClientOption {
+	return func(c *Client) {
+		c.bearerToken = token
+	}
+}
+
+// WithTLSConfig sets the TLS configuration, e.g. the client certificate for mTLS and the CA bundle.
+func WithTLSConfig(tlsConfig *tls.Config) ClientOption {
+	return func(c *Client) {
+		transport := http.DefaultTransport.(*http.Transport).Clone() //nolint:forcetypeassert
+		transport.TLSClientConfig = tlsConfig
+
+		c.httpClient.Transport = transport
+	}
+}
+
+// WithCredentials sets all the credentials loaded from a secret.
+func WithCredentials(creds *Credentials) ClientOption {
+	return func(c *Client) {
+		c.apiKey = creds.APIKey
+		c.bearerToken = creds.BearerToken
+
+		if creds.TLSConfig != nil {
+			WithTLSConfig(creds.TLSConfig)(c)
+		}
+	}
+}
+
+// WithRetryPolicy overrides DefaultRetryPolicy.
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// WithCircuitBreaker sets the circuit breaker, it can be shared by the clients of the same endpoint.
+func WithCircuitBreaker(breaker *CircuitBreaker) ClientOption {
+	return func(c *Client) {
+		c.breaker = breaker
+	}
+}
+
 // NewClient creates a new Management API client.
 func NewClient(baseURL string, opts ...ClientOption) (*Client, error) {
 	// Validate and normalize base URL
@@ -64,6 +119,7 @@ func NewClient(baseURL string, opts ...ClientOption) (*Client, error) {
 		httpClient: &http.Client{
 			Timeout: defaultTimeout,
 		},
+		retry: DefaultRetryPolicy,
 	}
 
 	// Apply options
@@ -71,6 +127,10 @@ func NewClient(baseURL string, opts ...ClientOption) (*Client, error) {
 		opt(client)
 	}
 
+	if client.breaker == nil {
+		client.breaker = NewCircuitBreaker(DefaultFailureThreshold, DefaultOpenTimeout)
+	}
+
 	return client, nil
 }
 
@@ -80,6 +140,7 @@ func (c *Client) HealthCheck(ctx context.Context) (*HealthCheckResponse, error)
 	if err := c.doRequest(ctx, http.MethodGet, "/health", nil, &response); err != nil {
 		return nil, err
 	}
+
 	return &response, nil
 }
 
@@ -89,6 +150,7 @@ func (c *Client) RegisterCluster(ctx context.Context, req *ClusterRegisterReques
 	if err := c.doRequest(ctx, http.MethodPost, fmt.Sprintf("/api/%s/clusters", defaultAPIVersion), req, &response); err != nil {
 		return nil, err
 	}
+
 	return &response, nil
 }
 
@@ -98,6 +160,7 @@ func (c *Client) GetCluster(ctx context.Context, clusterID string) (*ClusterInfo
 	if err := c.doRequest(ctx, http.MethodGet, fmt.Sprintf("/api/%s/clusters/%s", defaultAPIVersion, clusterID), nil, &response); err != nil {
 		return nil, err
 	}
+
 	return &response, nil
 }
 
@@ -107,6 +170,7 @@ func (c *Client) UpdateClusterStatus(ctx context.Context, clusterID string, req
 	if err := c.doRequest(ctx, http.MethodPatch, fmt.Sprintf("/api/%s/clusters/%s/status", defaultAPIVersion, clusterID), req, &response); err != nil {
 		return nil, err
 	}
+
 	return &response, nil
 }
 
@@ -116,6 +180,7 @@ func (c *Client) RegisterNode(ctx context.Context, req *NodeRegisterRequest) (*N
 	if err := c.doRequest(ctx, http.MethodPost, fmt.Sprintf("/api/%s/nodes", defaultAPIVersion), req, &response); err != nil {
 		return nil, err
 	}
+
 	return &response, nil
 }
 
@@ -125,6 +190,7 @@ func (c *Client) GetNode(ctx context.Context, nodeID string) (*NodeInfoResponse,
 	if err := c.doRequest(ctx, http.MethodGet, fmt.Sprintf("/api/%s/nodes/%s", defaultAPIVersion, nodeID), nil, &response); err != nil {
 		return nil, err
 	}
+
 	return &response, nil
 }
 
@@ -134,26 +200,124 @@ func (c *Client) UpdateNodeStatus(ctx context.Context, nodeID string, req *Statu
 	if err := c.doRequest(ctx, http.MethodPatch, fmt.Sprintf("/api/%s/nodes/%s/status", defaultAPIVersion, nodeID), req, &response); err != nil {
 		return nil, err
 	}
+
+	return &response, nil
+}
+
+// UpdateNodeStatuses updates the status of several nodes of the cluster in a single request.
+//
+// The request fails as a whole only if the batch is rejected, the result of each node update is in the response.
+func (c *Client) UpdateNodeStatuses(ctx context.Context, clusterID string, req *NodeStatusBatchRequest) (*NodeStatusBatchResponse, error) {
+	var response NodeStatusBatchResponse
+	if err := c.doRequest(ctx, http.MethodPatch, fmt.Sprintf("/api/%s/clusters/%s/nodes/status", defaultAPIVersion, clusterID), req, &response); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// ListServers lists the servers of the cluster registered in the Management API.
+func (c *Client) ListServers(ctx context.Context, clusterName string) (*ServerListResponse, error) {
+	var response ServerListResponse
+	if err := c.doRequest(ctx, http.MethodGet, fmt.Sprintf("/api/%s/sidero/list-servers?%s", defaultAPIVersion, url.Values{"cluster_name": {clusterName}}.Encode()), nil, &response); err != nil {
+		return nil, err
+	}
+
 	return &response, nil
 }
 
+// DeleteCluster removes a cluster from the Management API.
+func (c *Client) DeleteCluster(ctx context.Context, clusterID string) error {
+	return c.doRequest(ctx, http.MethodDelete, fmt.Sprintf("/api/%s/clusters/%s", defaultAPIVersion, clusterID), nil, nil)
+}
+
+// DeleteNode removes a node from the Management API.
+func (c *Client) DeleteNode(ctx context.Context, nodeID string) error {
+	return c.doRequest(ctx, http.MethodDelete, fmt.Sprintf("/api/%s/nodes/%s", defaultAPIVersion, nodeID), nil, nil)
+}
+
 // doRequest performs an HTTP request to the Management API.
+//
+// Idempotent requests are retried on network errors and temporary API errors (429 and 5xx) according to the retry policy.
+// POST requests get an idempotency key, which is kept for all the attempts, so that they can be retried as well.
 func (c *Client) doRequest(ctx context.Context, method, path string, body interface{}, response interface{}) error {
-	// Build full URL
-	fullURL := c.baseURL + path
+	var bodyBytes []byte
 
-	// Prepare request body if provided
-	var bodyReader io.Reader
 	if body != nil {
-		bodyBytes, err := json.Marshal(body)
+		var err error
+
+		bodyBytes, err = json.Marshal(body)
 		if err != nil {
 			return errors.Wrap(err, "failed to marshal request body")
 		}
-		bodyReader = bytes.NewReader(bodyBytes)
 	}
 
-	// Create HTTP request
-	req, err := http.NewRequestWithContext(ctx, method, fullURL, bodyReader)
+	var idempotencyKey string
+
+	if method == http.MethodPost {
+		idempotencyKey = newIdempotencyKey()
+	}
+
+	attempts := 1
+	if idempotencyKey != "" || isIdempotent(method) {
+		attempts = max(c.retry.MaxAttempts, 1)
+	}
+
+	var lastErr error
+
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			var retryAfter time.Duration
+
+			var apiErr *APIError
+			if errors.As(lastErr, &apiErr) {
+				retryAfter = apiErr.retryAfter
+			}
+
+			select {
+			case <-ctx.Done():
+				return lastErr
+			case <-time.After(c.retry.backoff(attempt-1, retryAfter)):
+			}
+		}
+
+		if !c.breaker.allow() {
+			if lastErr != nil {
+				return errors.Wrapf(ErrCircuitOpen, "%s: %s", c.baseURL, lastErr)
+			}
+
+			return errors.Wrap(ErrCircuitOpen, c.baseURL)
+		}
+
+		lastErr = c.attempt(ctx, method, path, bodyBytes, idempotencyKey, response)
+
+		// canceled requests say nothing about the endpoint health
+		if ctx.Err() != nil {
+			c.breaker.abort()
+
+			return lastErr
+		}
+
+		retryable := isTemporary(lastErr)
+
+		c.breaker.record(!retryable)
+
+		if !retryable {
+			return lastErr
+		}
+	}
+
+	return lastErr
+}
+
+// attempt sends the request once.
+func (c *Client) attempt(ctx context.Context, method, path string, body []byte, idempotencyKey string, response interface{}) error {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bodyReader)
 	if err != nil {
 		return errors.Wrap(err, "failed to create HTTP request")
 	}
@@ -163,32 +327,48 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body interf
 	req.Header.Set("Accept", contentTypeJSON)
 	req.Header.Set("User-Agent", "Sidero-ManagementAPI-Client/1.0")
 
+	if idempotencyKey != "" {
+		req.Header.Set(IdempotencyKeyHeader, idempotencyKey)
+	}
+
 	// Add API key if configured
 	if c.apiKey != "" {
 		req.Header.Set("X-API-Key", c.apiKey)
 	}
 
-	// Execute request
+	// Add bearer token if configured
+	if c.bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.bearerToken)
+	}
+
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return errors.Wrap(err, "failed to execute HTTP request")
+		return &networkError{errors.Wrap(err, "failed to execute HTTP request")}
 	}
-	defer resp.Body.Close()
 
-	// Read response body
+	defer resp.Body.Close() //nolint:errcheck
+
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return errors.Wrap(err, "failed to read response body")
+		return &networkError{errors.Wrap(err, "failed to read response body")}
 	}
 
-	// Check for non-2xx status codes
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		apiErr := &APIError{
+			StatusCode: resp.StatusCode,
+			Detail:     string(respBody),
+		}
+
 		var errResp ErrorResponse
-		if err := json.Unmarshal(respBody, &errResp); err != nil {
-			// Failed to parse error response, return generic error
-			return fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(respBody))
+		if err := json.Unmarshal(respBody, &errResp); err == nil {
+			apiErr.Detail = errResp.Detail
+		}
+
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			apiErr.retryAfter = time.Duration(seconds) * time.Second
 		}
-		return fmt.Errorf("API error (status %d): %s", resp.StatusCode, errResp.Detail)
+
+		return apiErr
 	}
 
 	// Parse response if a response object was provided
@@ -201,8 +381,57 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body interf
 	return nil
 }
 
-// Close closes the HTTP client (placeholder for any cleanup).
+// Close closes the idle connections of the client.
 func (c *Client) Close() error {
-	// Currently nothing to close, but keeping for future extensibility
+	c.httpClient.CloseIdleConnections()
+
 	return nil
 }
+
+// networkError is returned when the request failed before receiving the response.
+type networkError struct {
+	err error
+}
+
+func (e *networkError) Error() string {
+	return e.err.Error()
+}
+
+func (e *networkError) Unwrap() error {
+	return e.err
+}
+
+// isTemporary returns true if the request failed but may succeed when retried.
+func isTemporary(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var netErr *networkError
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var apiErr *APIError
+
+	return errors.As(err, &apiErr) && apiErr.temporary()
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+func newIdempotencyKey() string {
+	var buf [16]byte
+
+	if _, err := rand.Read(buf[:]); err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(buf[:])
+}
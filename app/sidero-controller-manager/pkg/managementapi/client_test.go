@@ -0,0 +1,308 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package managementapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/pkg/managementapi"
+)
+
+var fastRetries = managementapi.WithRetryPolicy(managementapi.RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   time.Millisecond,
+	MaxDelay:    5 * time.Millisecond,
+})
+
+// flakyServer responds with the statuses in order, and 200 once they run out.
+type flakyServer struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+}
+
+func (s *flakyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, r)
+
+	if len(s.statuses) > 0 {
+		status := s.statuses[0]
+		s.statuses = s.statuses[1:]
+
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(managementapi.ErrorResponse{Detail: http.StatusText(status)}) //nolint:errcheck
+
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok", "cluster_id": "c1", "node_id": "n1"}) //nolint:errcheck
+}
+
+func (s *flakyServer) attempts() []*http.Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]*http.Request(nil), s.requests...)
+}
+
+func TestClientRetries(t *testing.T) {
+	t.Parallel()
+
+	getNode := func(ctx context.Context, c *managementapi.Client) error {
+		_, err := c.GetNode(ctx, "n1")
+
+		return err
+	}
+
+	registerNode := func(ctx context.Context, c *managementapi.Client) error {
+		_, err := c.RegisterNode(ctx, &managementapi.NodeRegisterRequest{ClusterID: "c1", Hostname: "node"})
+
+		return err
+	}
+
+	updateNode := func(ctx context.Context, c *managementapi.Client) error {
+		_, err := c.UpdateNodeStatus(ctx, "n1", &managementapi.StatusUpdateRequest{Status: "active"})
+
+		return err
+	}
+
+	for _, tt := range []struct {
+		name     string
+		call     func(context.Context, *managementapi.Client) error
+		statuses []int
+		attempts int
+		target   error
+		err      string
+	}{
+		{
+			name:     "get retried",
+			call:     getNode,
+			statuses: []int{http.StatusServiceUnavailable, http.StatusBadGateway},
+			attempts: 3,
+		},
+		{
+			name:     "get gives up",
+			call:     getNode,
+			statuses: []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+			attempts: 3,
+			err:      "API error (status 503): Service Unavailable",
+		},
+		{
+			name:     "not found",
+			call:     getNode,
+			statuses: []int{http.StatusNotFound},
+			attempts: 1,
+			target:   managementapi.ErrNotFound,
+			err:      "API error (status 404): Not Found",
+		},
+		{
+			name:     "post retried with idempotency key",
+			call:     registerNode,
+			statuses: []int{http.StatusTooManyRequests},
+			attempts: 2,
+		},
+		{
+			name:     "conflict",
+			call:     registerNode,
+			statuses: []int{http.StatusConflict},
+			attempts: 1,
+			target:   managementapi.ErrConflict,
+			err:      "API error (status 409): Conflict",
+		},
+		{
+			name:     "forbidden",
+			call:     updateNode,
+			statuses: []int{http.StatusForbidden},
+			attempts: 1,
+			target:   managementapi.ErrUnauthorized,
+			err:      "API error (status 403): Forbidden",
+		},
+		{
+			name:     "patch not retried",
+			call:     updateNode,
+			statuses: []int{http.StatusServiceUnavailable},
+			attempts: 1,
+			err:      "API error (status 503): Service Unavailable",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := &flakyServer{statuses: tt.statuses}
+
+			httpSrv := httptest.NewServer(srv)
+			t.Cleanup(httpSrv.Close)
+
+			client, err := managementapi.NewClient(httpSrv.URL, fastRetries)
+			require.NoError(t, err)
+
+			err = tt.call(context.Background(), client)
+
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			if tt.target != nil {
+				assert.ErrorIs(t, err, tt.target)
+			}
+
+			var apiErr *managementapi.APIError
+			if tt.err != "" {
+				require.ErrorAs(t, err, &apiErr)
+			}
+
+			attempts := srv.attempts()
+			require.Len(t, attempts, tt.attempts)
+
+			key := attempts[0].Header.Get(managementapi.IdempotencyKeyHeader)
+
+			for _, r := range attempts {
+				assert.Equal(t, key, r.Header.Get(managementapi.IdempotencyKeyHeader), "idempotency key must be kept on retries")
+			}
+
+			assert.Equal(t, attempts[0].Method == http.MethodPost, key != "")
+		})
+	}
+}
+
+func TestClientCircuitBreaker(t *testing.T) {
+	t.Parallel()
+
+	srv := &flakyServer{statuses: []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError}}
+
+	httpSrv := httptest.NewServer(srv)
+	t.Cleanup(httpSrv.Close)
+
+	breaker := managementapi.NewCircuitBreaker(2, 50*time.Millisecond)
+
+	client, err := managementapi.NewClient(httpSrv.URL,
+		managementapi.WithRetryPolicy(managementapi.RetryPolicy{MaxAttempts: 1}),
+		managementapi.WithCircuitBreaker(breaker),
+	)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	for range 2 {
+		_, err = client.HealthCheck(ctx)
+		require.EqualError(t, err, "API error (status 500): Internal Server Error")
+	}
+
+	assert.True(t, breaker.Open())
+
+	// requests are rejected without reaching the server
+	_, err = client.HealthCheck(ctx)
+	require.ErrorIs(t, err, managementapi.ErrCircuitOpen)
+	assert.Len(t, srv.attempts(), 2)
+
+	// the probe fails, and the breaker opens again
+	time.Sleep(60 * time.Millisecond)
+
+	_, err = client.HealthCheck(ctx)
+	require.EqualError(t, err, "API error (status 500): Internal Server Error")
+
+	_, err = client.HealthCheck(ctx)
+	require.ErrorIs(t, err, managementapi.ErrCircuitOpen)
+
+	// the probe succeeds, and the breaker closes
+	time.Sleep(60 * time.Millisecond)
+
+	_, err = client.HealthCheck(ctx)
+	require.NoError(t, err)
+	assert.False(t, breaker.Open())
+
+	// client errors don't open the breaker
+	srv.mu.Lock()
+	srv.statuses = []int{http.StatusNotFound, http.StatusNotFound, http.StatusNotFound}
+	srv.mu.Unlock()
+
+	for range 3 {
+		_, err = client.GetNode(ctx, "missing")
+		require.ErrorIs(t, err, managementapi.ErrNotFound)
+	}
+
+	assert.False(t, breaker.Open())
+}
+
+func TestPool(t *testing.T) {
+	t.Parallel()
+
+	srv := &flakyServer{}
+
+	httpSrv := httptest.NewServer(srv)
+	t.Cleanup(httpSrv.Close)
+
+	pool := managementapi.NewPool(managementapi.WithRetryPolicy(managementapi.RetryPolicy{MaxAttempts: 1}))
+	t.Cleanup(pool.Close)
+
+	creds := &managementapi.Credentials{APIKey: "key"}
+
+	client1, err := pool.Client(httpSrv.URL, creds)
+	require.NoError(t, err)
+
+	client2, err := pool.Client(httpSrv.URL, &managementapi.Credentials{APIKey: "key"})
+	require.NoError(t, err)
+
+	assert.Same(t, client1, client2)
+
+	// open the shared circuit breaker
+	srv.mu.Lock()
+	for range managementapi.DefaultFailureThreshold {
+		srv.statuses = append(srv.statuses, http.StatusBadGateway)
+	}
+	srv.mu.Unlock()
+
+	for range managementapi.DefaultFailureThreshold {
+		_, err = client1.HealthCheck(context.Background())
+		require.Error(t, err)
+	}
+
+	// other credentials get another client, which shares the endpoint state
+	rotated, err := pool.Client(httpSrv.URL, &managementapi.Credentials{APIKey: "rotated"})
+	require.NoError(t, err)
+
+	assert.NotSame(t, client1, rotated)
+
+	_, err = rotated.HealthCheck(context.Background())
+	require.ErrorIs(t, err, managementapi.ErrCircuitOpen)
+
+	// objects using different credentials for the same endpoint keep their clients
+	client3, err := pool.Client(httpSrv.URL, creds)
+	require.NoError(t, err)
+
+	assert.Same(t, client1, client3)
+
+	rotated2, err := pool.Client(httpSrv.URL, &managementapi.Credentials{APIKey: "rotated"})
+	require.NoError(t, err)
+
+	assert.Same(t, rotated, rotated2)
+
+	// the endpoint state is removed with the clients
+	pool.Close()
+
+	client4, err := pool.Client(httpSrv.URL, creds)
+	require.NoError(t, err)
+
+	assert.NotSame(t, client1, client4)
+
+	_, err = client4.HealthCheck(context.Background())
+	require.NoError(t, err)
+
+	_, err = pool.Client("ftp://example.com", nil)
+	require.EqualError(t, err, "invalid URL scheme: ftp (must be http or https)")
+}
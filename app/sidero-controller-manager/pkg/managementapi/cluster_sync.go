@@ -0,0 +1,303 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package managementapi
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	metalv1 "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
+)
+
+// ClusterStatus is the status of a Management API cluster aggregated from all of its AdoptedServers.
+type ClusterStatus struct {
+	Nodes             int
+	ControlPlaneNodes int
+	WorkerNodes       int
+	ConnectedNodes    int
+
+	// Health is the worst health of the nodes.
+	Health string
+
+	// TalosVersions and KubernetesVersions are the sorted versions running in the cluster.
+	TalosVersions      []string
+	KubernetesVersions []string
+
+	// LastContact is the most recent contact with any of the nodes.
+	LastContact time.Time
+}
+
+// healthSeverity orders the health statuses from the best to the worst.
+var healthSeverity = []string{
+	metalv1.HealthStatusHealthy,
+	metalv1.HealthStatusUnknown,
+	metalv1.HealthStatusDegraded,
+	metalv1.HealthStatusUnhealthy,
+}
+
+// AggregateClusterStatus computes the cluster status from the AdoptedServers of the cluster.
+func AggregateClusterStatus(servers []*metalv1.AdoptedServer) ClusterStatus {
+	status := ClusterStatus{
+		Nodes:  len(servers),
+		Health: metalv1.HealthStatusHealthy,
+	}
+
+	if len(servers) == 0 {
+		status.Health = metalv1.HealthStatusUnknown
+	}
+
+	for _, as := range servers {
+		switch as.Spec.Talos.NodeType {
+		case "controlplane":
+			status.ControlPlaneNodes++
+		case "worker":
+			status.WorkerNodes++
+		}
+
+		if as.Status.Connected {
+			status.ConnectedNodes++
+		}
+
+		health := metalv1.HealthStatusUnknown
+		if as.Status.Health != nil && slices.Contains(healthSeverity, as.Status.Health.Status) {
+			health = as.Status.Health.Status
+		}
+
+		if slices.Index(healthSeverity, health) > slices.Index(healthSeverity, status.Health) {
+			status.Health = health
+		}
+
+		if v := as.Spec.Talos.TalosVersion; v != "" && !slices.Contains(status.TalosVersions, v) {
+			status.TalosVersions = append(status.TalosVersions, v)
+		}
+
+		if v := as.Spec.Talos.KubernetesVersion; v != "" && !slices.Contains(status.KubernetesVersions, v) {
+			status.KubernetesVersions = append(status.KubernetesVersions, v)
+		}
+
+		if as.Status.LastContactTime != nil && as.Status.LastContactTime.After(status.LastContact) {
+			status.LastContact = as.Status.LastContactTime.Time
+		}
+	}
+
+	slices.Sort(status.TalosVersions)
+	slices.Sort(status.KubernetesVersions)
+
+	return status
+}
+
+// StatusUpdateRequest builds the cluster status update.
+func (status ClusterStatus) StatusUpdateRequest() *StatusUpdateRequest {
+	state := "unknown"
+	if status.ConnectedNodes > 0 {
+		state = "active"
+	}
+
+	return &StatusUpdateRequest{
+		Status:      state,
+		Health:      status.Health,
+		LastContact: status.LastContact,
+		Metadata: map[string]string{
+			"node_count":          strconv.Itoa(status.Nodes),
+			"controlplane_count":  strconv.Itoa(status.ControlPlaneNodes),
+			"worker_count":        strconv.Itoa(status.WorkerNodes),
+			"connected_count":     strconv.Itoa(status.ConnectedNodes),
+			"talos_versions":      strings.Join(status.TalosVersions, ","),
+			"kubernetes_versions": strings.Join(status.KubernetesVersions, ","),
+		},
+	}
+}
+
+// SyncCluster synchronizes all the AdoptedServers of a Management API cluster at once.
+//
+// The servers must share the endpoint and the cluster. The aggregated cluster status is pushed with a single update,
+// and the status of the registered nodes with a single batch update, the nodes which are not registered yet
+// (or were removed from the Management API) are registered. The cluster and node IDs are recorded in the status
+// of each AdoptedServer.
+//
+// The returned error prevented syncing the whole cluster, otherwise the errors of the nodes which failed to sync
+// are returned by AdoptedServer name.
+func (s *SyncService) SyncCluster(ctx context.Context, servers []*metalv1.AdoptedServer) (map[string]error, error) {
+	if len(servers) == 0 {
+		return nil, nil
+	}
+
+	for _, as := range servers {
+		if as.Spec.ManagementAPI == nil || !as.Spec.ManagementAPI.Enabled {
+			return nil, errors.Errorf("Management API integration not enabled for AdoptedServer %s", as.Name)
+		}
+	}
+
+	clusterID, err := s.syncClusterStatus(ctx, servers)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, as := range servers {
+		if as.Status.ManagementAPIStatus == nil {
+			as.Status.ManagementAPIStatus = &metalv1.ManagementAPIStatus{}
+		}
+
+		if status := as.Status.ManagementAPIStatus; status.ClusterID != clusterID {
+			// Nodes belong to the cluster, the previous registration is gone
+			status.ClusterID = clusterID
+			status.NodeID = ""
+		}
+	}
+
+	nodeErrors := s.updateNodeStatuses(ctx, clusterID, servers)
+
+	// registered nodes by ID, an existing node is adopted only if no other AdoptedServer owns it
+	owners := map[string]string{}
+
+	for _, as := range servers {
+		if nodeID := as.Status.ManagementAPIStatus.NodeID; nodeID != "" {
+			owners[nodeID] = as.Name
+		}
+	}
+
+	for _, as := range servers {
+		if _, failed := nodeErrors[as.Name]; failed || as.Status.ManagementAPIStatus.NodeID != "" {
+			continue
+		}
+
+		if err := s.registerOrUpdateNode(ctx, as, clusterID); err != nil {
+			nodeErrors[as.Name] = errors.Wrap(err, "failed to register node")
+
+			continue
+		}
+
+		nodeID := as.Status.ManagementAPIStatus.NodeID
+
+		if owner, owned := owners[nodeID]; owned {
+			as.Status.ManagementAPIStatus.NodeID = ""
+			nodeErrors[as.Name] = errors.Wrapf(ErrConflict, "node %s is already registered for AdoptedServer %s", nodeID, owner)
+
+			continue
+		}
+
+		owners[nodeID] = as.Name
+	}
+
+	return nodeErrors, nil
+}
+
+// syncClusterStatus pushes the aggregated status of the cluster, registering it if needed, and returns its ID.
+func (s *SyncService) syncClusterStatus(ctx context.Context, servers []*metalv1.AdoptedServer) (string, error) {
+	var clusterID string
+
+	for _, as := range servers {
+		if clusterID = ClusterID(as); clusterID != "" {
+			break
+		}
+	}
+
+	config := servers[0].Spec.ManagementAPI
+	status := AggregateClusterStatus(servers).StatusUpdateRequest()
+
+	if clusterID != "" {
+		_, err := s.client.UpdateClusterStatus(ctx, clusterID, status)
+
+		switch {
+		case err == nil:
+			return clusterID, nil
+		case errors.Is(err, ErrNotFound) && config.ClusterID == "":
+			// Cluster was removed from the Management API, register it again
+			s.logger.Info("Cluster not found in Management API", "cluster_id", clusterID)
+		default:
+			return "", errors.Wrap(err, "failed to update cluster status")
+		}
+	}
+
+	s.logger.Info("Registering cluster with Management API", "cluster", config.ClusterName)
+
+	resp, err := s.registerCluster(ctx, servers[0])
+	if err != nil {
+		if errors.Is(err, ErrConflict) {
+			return "", errors.Wrapf(err, "cluster %q is already registered, set spec.managementAPI.clusterID to its ID", config.ClusterName)
+		}
+
+		return "", errors.Wrap(err, "failed to register cluster")
+	}
+
+	s.logger.Info("Cluster registered successfully", "cluster_id", resp.ClusterID)
+
+	if _, err = s.client.UpdateClusterStatus(ctx, resp.ClusterID, status); err != nil {
+		return "", errors.Wrap(err, "failed to update cluster status")
+	}
+
+	return resp.ClusterID, nil
+}
+
+// updateNodeStatuses pushes the status of the registered nodes with a single batch update.
+//
+// The node IDs of the nodes removed from the Management API are cleared, so that they are registered again.
+func (s *SyncService) updateNodeStatuses(ctx context.Context, clusterID string, servers []*metalv1.AdoptedServer) map[string]error {
+	nodeErrors := map[string]error{}
+	registered := map[string]*metalv1.AdoptedServer{}
+
+	req := &NodeStatusBatchRequest{}
+
+	for _, as := range servers {
+		if nodeID := as.Status.ManagementAPIStatus.NodeID; nodeID != "" {
+			registered[nodeID] = as
+
+			req.Updates = append(req.Updates, NodeStatusUpdate{
+				NodeID:              nodeID,
+				StatusUpdateRequest: *statusUpdateRequest(as),
+			})
+		}
+	}
+
+	if len(req.Updates) == 0 {
+		return nodeErrors
+	}
+
+	resp, err := s.client.UpdateNodeStatuses(ctx, clusterID, req)
+	if err != nil {
+		for _, as := range registered {
+			nodeErrors[as.Name] = errors.Wrap(err, "failed to update node status")
+		}
+
+		return nodeErrors
+	}
+
+	for _, result := range resp.Results {
+		as, ok := registered[result.NodeID]
+		if !ok {
+			continue
+		}
+
+		delete(registered, result.NodeID)
+
+		if result.Success {
+			continue
+		}
+
+		err := &APIError{StatusCode: result.Code, Detail: result.Error}
+
+		if errors.Is(err, ErrNotFound) {
+			// Node was removed from the Management API, register it again
+			s.logger.Info("Node not found in Management API", "node_id", result.NodeID)
+
+			as.Status.ManagementAPIStatus.NodeID = ""
+
+			continue
+		}
+
+		nodeErrors[as.Name] = errors.Wrap(err, "failed to update node status")
+	}
+
+	for nodeID, as := range registered {
+		nodeErrors[as.Name] = errors.Errorf("no status update result for node %s", nodeID)
+	}
+
+	return nodeErrors
+}
@@ -0,0 +1,220 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package managementapi_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	metalv1 "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/internal/power/api"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/pkg/managementapi"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/pkg/managementapi/fake"
+)
+
+func adoptedServer(name, nodeType, endpoint, health string, connected bool) *metalv1.AdoptedServer {
+	as := &metalv1.AdoptedServer{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: metalv1.AdoptedServerSpec{
+			Talos: metalv1.TalosConfig{
+				Endpoint:          endpoint,
+				NodeType:          nodeType,
+				TalosVersion:      "v1.9.0",
+				KubernetesVersion: "v1.32.0",
+			},
+			ManagementAPI: &metalv1.ManagementAPIConfig{
+				Enabled:     true,
+				ClusterName: "cluster",
+			},
+		},
+		Status: metalv1.AdoptedServerStatus{Connected: connected},
+	}
+
+	if health != "" {
+		as.Status.Health = &metalv1.HealthStatus{Status: health}
+	}
+
+	return as
+}
+
+func TestAggregateClusterStatus(t *testing.T) {
+	t.Parallel()
+
+	lastContact := metav1.NewTime(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	cp1 := adoptedServer("cp-1", "controlplane", "10.5.0.2", metalv1.HealthStatusHealthy, true)
+	cp1.Status.LastContactTime = &lastContact
+
+	cp2 := adoptedServer("cp-2", "controlplane", "10.5.0.3", metalv1.HealthStatusDegraded, true)
+	cp2.Spec.Talos.TalosVersion = "v1.8.3"
+
+	worker := adoptedServer("worker-1", "worker", "10.5.0.4", "", false)
+
+	status := managementapi.AggregateClusterStatus([]*metalv1.AdoptedServer{cp1, cp2, worker})
+
+	assert.Equal(t, managementapi.ClusterStatus{
+		Nodes:              3,
+		ControlPlaneNodes:  2,
+		WorkerNodes:        1,
+		ConnectedNodes:     2,
+		Health:             metalv1.HealthStatusDegraded,
+		TalosVersions:      []string{"v1.8.3", "v1.9.0"},
+		KubernetesVersions: []string{"v1.32.0"},
+		LastContact:        lastContact.Time,
+	}, status)
+
+	assert.Equal(t, &managementapi.StatusUpdateRequest{
+		Status:      "active",
+		Health:      metalv1.HealthStatusDegraded,
+		LastContact: lastContact.Time,
+		Metadata: map[string]string{
+			"node_count":          "3",
+			"controlplane_count":  "2",
+			"worker_count":        "1",
+			"connected_count":     "2",
+			"talos_versions":      "v1.8.3,v1.9.0",
+			"kubernetes_versions": "v1.32.0",
+		},
+	}, status.StatusUpdateRequest())
+
+	for _, test := range []struct {
+		name     string
+		health   []string
+		expected string
+	}{
+		{
+			name:     "healthy",
+			health:   []string{metalv1.HealthStatusHealthy, metalv1.HealthStatusHealthy},
+			expected: metalv1.HealthStatusHealthy,
+		},
+		{
+			name:     "unknown",
+			health:   []string{metalv1.HealthStatusHealthy, ""},
+			expected: metalv1.HealthStatusUnknown,
+		},
+		{
+			name:     "unhealthy",
+			health:   []string{metalv1.HealthStatusUnhealthy, metalv1.HealthStatusDegraded, ""},
+			expected: metalv1.HealthStatusUnhealthy,
+		},
+		{
+			name:     "empty",
+			expected: metalv1.HealthStatusUnknown,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			servers := make([]*metalv1.AdoptedServer, 0, len(test.health))
+
+			for _, health := range test.health {
+				servers = append(servers, adoptedServer("node", "worker", "10.5.0.2", health, true))
+			}
+
+			assert.Equal(t, test.expected, managementapi.AggregateClusterStatus(servers).Health)
+		})
+	}
+}
+
+func TestSyncCluster(t *testing.T) {
+	t.Parallel()
+
+	srv := fake.NewServer()
+	t.Cleanup(srv.Close)
+
+	client, err := managementapi.NewClient(srv.URL)
+	require.NoError(t, err)
+
+	syncService := managementapi.NewSyncService(client, logr.Discard())
+	ctx := context.Background()
+
+	servers := []*metalv1.AdoptedServer{
+		adoptedServer("cp-1", "controlplane", "10.5.0.2:50000", metalv1.HealthStatusHealthy, true),
+		adoptedServer("worker-1", "worker", "10.5.0.3:50000", metalv1.HealthStatusUnhealthy, true),
+	}
+
+	// first sync registers the cluster and the nodes
+	nodeErrors, err := syncService.SyncCluster(ctx, servers)
+	require.NoError(t, err)
+	assert.Empty(t, nodeErrors)
+	assert.Equal(t, []string{
+		"POST /api/v1/clusters",
+		"PATCH /api/v1/clusters/cluster-1/status",
+		"POST /api/v1/nodes",
+		"POST /api/v1/nodes",
+	}, srv.DrainRequests())
+
+	cluster, ok := srv.Cluster("cluster-1")
+	require.True(t, ok)
+	assert.Equal(t, metalv1.HealthStatusUnhealthy, cluster.Status.Health)
+	assert.Equal(t, "2", cluster.Status.Metadata["node_count"])
+
+	for _, as := range servers {
+		assert.Equal(t, "cluster-1", as.Status.ManagementAPIStatus.ClusterID)
+		assert.NotEmpty(t, as.Status.ManagementAPIStatus.NodeID)
+	}
+
+	// next syncs update the cluster and all the nodes with two requests
+	nodeErrors, err = syncService.SyncCluster(ctx, servers)
+	require.NoError(t, err)
+	assert.Empty(t, nodeErrors)
+	assert.Equal(t, []string{
+		"PATCH /api/v1/clusters/cluster-1/status",
+		"PATCH /api/v1/clusters/cluster-1/nodes/status",
+	}, srv.DrainRequests())
+
+	node, ok := srv.Node(servers[1].Status.ManagementAPIStatus.NodeID)
+	require.True(t, ok)
+	assert.Equal(t, metalv1.HealthStatusUnhealthy, node.Status.Health)
+
+	// node removed upstream is registered again, the other one is still updated
+	require.True(t, srv.RemoveNode(servers[0].Status.ManagementAPIStatus.NodeID))
+
+	nodeErrors, err = syncService.SyncCluster(ctx, servers)
+	require.NoError(t, err)
+	assert.Empty(t, nodeErrors)
+	assert.Equal(t, []string{
+		"PATCH /api/v1/clusters/cluster-1/status",
+		"PATCH /api/v1/clusters/cluster-1/nodes/status",
+		"POST /api/v1/nodes",
+	}, srv.DrainRequests())
+	assert.Len(t, srv.Nodes(), 2)
+
+	// registration failures are reported for the node only
+	servers = append(servers, adoptedServer("worker-2", "worker", "10.5.0.4:50000", metalv1.HealthStatusHealthy, true))
+	servers[2].Spec.Talos.Hostname = "worker-1"
+
+	nodeErrors, err = syncService.SyncCluster(ctx, servers)
+	require.NoError(t, err)
+	require.Len(t, nodeErrors, 1)
+	require.ErrorIs(t, nodeErrors["worker-2"], managementapi.ErrConflict)
+
+	// failure to update the cluster fails the whole sync
+	srv.FailNext(api.ExplicitFailure)
+
+	_, err = syncService.SyncCluster(ctx, servers)
+	require.Error(t, err)
+
+	// cluster removed upstream is registered again with all the nodes
+	require.True(t, srv.RemoveCluster("cluster-1"))
+	srv.DrainRequests()
+
+	nodeErrors, err = syncService.SyncCluster(ctx, servers[:2])
+	require.NoError(t, err)
+	assert.Empty(t, nodeErrors)
+	assert.Equal(t, []string{
+		"PATCH /api/v1/clusters/cluster-1/status",
+		"POST /api/v1/clusters",
+		"PATCH /api/v1/clusters/cluster-5/status",
+		"POST /api/v1/nodes",
+		"POST /api/v1/nodes",
+	}, srv.DrainRequests())
+}
@@ -0,0 +1,125 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package managementapi
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Secret keys holding Management API credentials.
+//
+// The secret should contain at least one of them: the API key and the bearer token authenticate the requests,
+// the client certificate (both CertKey and KeyKey) is used for mTLS, and the CA bundle verifies the server certificate.
+const (
+	APIKeyKey = "api-key"
+	TokenKey  = "token"
+	CAKey     = "ca.crt"
+	CertKey   = corev1.TLSCertKey
+	KeyKey    = corev1.TLSPrivateKeyKey
+)
+
+// Credentials authenticate the Management API client.
+type Credentials struct {
+	// APIKey is sent in the X-API-Key header.
+	APIKey string
+	// BearerToken is sent in the Authorization header.
+	BearerToken string
+	// TLSConfig holds the client certificate and the CA bundle, nil if neither is set.
+	TLSConfig *tls.Config
+
+	// fingerprint identifies the secret contents, so that the pool creates a new client when they change.
+	fingerprint string
+}
+
+// CredentialsFromSecret loads the credentials stored in the secret.
+func CredentialsFromSecret(secret *corev1.Secret) (*Credentials, error) {
+	creds := &Credentials{
+		APIKey:      string(secret.Data[APIKeyKey]),
+		BearerToken: string(secret.Data[TokenKey]),
+	}
+
+	tlsConfig, err := tlsConfigFrom(secret)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error loading credentials from secret %s/%s", secret.Namespace, secret.Name)
+	}
+
+	creds.TLSConfig = tlsConfig
+
+	hash := sha256.New()
+
+	for _, key := range []string{APIKeyKey, TokenKey, CAKey, CertKey, KeyKey} {
+		fmt.Fprintf(hash, "%s=%d:%s\n", key, len(secret.Data[key]), secret.Data[key])
+	}
+
+	creds.fingerprint = hex.EncodeToString(hash.Sum(nil))
+
+	if creds.APIKey == "" && creds.BearerToken == "" && creds.TLSConfig == nil {
+		return nil, errors.Errorf("secret %s/%s contains none of the %q, %q, %q or %q keys", secret.Namespace, secret.Name, APIKeyKey, TokenKey, CertKey, CAKey)
+	}
+
+	return creds, nil
+}
+
+func tlsConfigFrom(secret *corev1.Secret) (*tls.Config, error) {
+	ca, hasCA := secret.Data[CAKey]
+	cert, hasCert := secret.Data[CertKey]
+	key, hasKey := secret.Data[KeyKey]
+
+	if !hasCA && !hasCert && !hasKey {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if hasCA {
+		pool := x509.NewCertPool()
+
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, errors.New("failed to parse CA certificate")
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	if hasCert != hasKey {
+		return nil, errors.Errorf("both %q and %q keys are required for the client certificate", CertKey, KeyKey)
+	}
+
+	if hasCert {
+		crt, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, errors.Wrap(err, "error loading client key pair")
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{crt}
+	}
+
+	return tlsConfig, nil
+}
+
+// Fingerprint returns a hash of the credentials which changes when any of them changes.
+func (creds *Credentials) Fingerprint() string {
+	if creds == nil {
+		return ""
+	}
+
+	if creds.fingerprint != "" {
+		return creds.fingerprint
+	}
+
+	// credentials built in code, the TLS configuration is compared by identity
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s\n%s\n%p", creds.APIKey, creds.BearerToken, creds.TLSConfig)))
+
+	return hex.EncodeToString(hash[:])
+}
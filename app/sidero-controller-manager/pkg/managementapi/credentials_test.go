@@ -0,0 +1,237 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package managementapi_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/pkg/managementapi"
+)
+
+type keyPair struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+func newKeyPair(t *testing.T, template *x509.Certificate, parent *keyPair) *keyPair {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	parentCert, parentKey := template, key
+	if parent != nil {
+		parentCert, parentKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parentCert, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return &keyPair{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func newCA(t *testing.T) *keyPair {
+	t.Helper()
+
+	return newKeyPair(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "management-api-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}, nil)
+}
+
+func newLeaf(t *testing.T, ca *keyPair, serial int64, usage x509.ExtKeyUsage) *keyPair {
+	t.Helper()
+
+	return newKeyPair(t, &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "sidero"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}, ca)
+}
+
+// healthHandler accepts the requests authorized by the check function.
+func healthHandler(check func(r *http.Request) bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !check(r) {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(managementapi.ErrorResponse{Detail: "invalid credentials"}) //nolint:errcheck
+
+			return
+		}
+
+		json.NewEncoder(w).Encode(managementapi.HealthCheckResponse{Status: "healthy"}) //nolint:errcheck
+	})
+}
+
+func TestCredentials(t *testing.T) {
+	t.Parallel()
+
+	ca := newCA(t)
+	serverCert := newLeaf(t, ca, 2, x509.ExtKeyUsageServerAuth)
+	clientCert := newLeaf(t, ca, 3, x509.ExtKeyUsageClientAuth)
+
+	for _, tt := range []struct {
+		name  string
+		data  map[string][]byte
+		check func(r *http.Request) bool
+		mTLS  bool
+		err   string
+	}{
+		{
+			name:  "api key",
+			data:  map[string][]byte{managementapi.APIKeyKey: []byte("key")},
+			check: func(r *http.Request) bool { return r.Header.Get("X-API-Key") == "key" },
+		},
+		{
+			name:  "bearer token",
+			data:  map[string][]byte{managementapi.TokenKey: []byte("token")},
+			check: func(r *http.Request) bool { return r.Header.Get("Authorization") == "Bearer token" },
+		},
+		{
+			name: "rejected token",
+			data: map[string][]byte{managementapi.TokenKey: []byte("expired")},
+			check: func(r *http.Request) bool {
+				return r.Header.Get("Authorization") == "Bearer token"
+			},
+			err: "API error (status 401): invalid credentials",
+		},
+		{
+			name: "mTLS",
+			data: map[string][]byte{
+				managementapi.CAKey:   ca.certPEM,
+				managementapi.CertKey: clientCert.certPEM,
+				managementapi.KeyKey:  clientCert.keyPEM,
+			},
+			check: func(r *http.Request) bool {
+				return r.TLS != nil && len(r.TLS.PeerCertificates) > 0 && r.TLS.PeerCertificates[0].Subject.CommonName == "sidero"
+			},
+			mTLS: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := httptest.NewUnstartedServer(healthHandler(tt.check))
+
+			if tt.mTLS {
+				pool := x509.NewCertPool()
+				pool.AddCert(ca.cert)
+
+				crt, err := tls.X509KeyPair(serverCert.certPEM, serverCert.keyPEM)
+				require.NoError(t, err)
+
+				srv.TLS = &tls.Config{
+					Certificates: []tls.Certificate{crt},
+					ClientCAs:    pool,
+					ClientAuth:   tls.RequireAndVerifyClientCert,
+				}
+
+				srv.StartTLS()
+			} else {
+				srv.Start()
+			}
+
+			t.Cleanup(srv.Close)
+
+			creds, err := managementapi.CredentialsFromSecret(&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "credentials"},
+				Data:       tt.data,
+			})
+			require.NoError(t, err)
+
+			client, err := managementapi.NewClient(srv.URL, managementapi.WithCredentials(creds))
+			require.NoError(t, err)
+
+			_, err = client.HealthCheck(context.Background())
+
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				assert.ErrorIs(t, err, managementapi.ErrUnauthorized)
+
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestCredentialsFromSecretErrors(t *testing.T) {
+	t.Parallel()
+
+	ca := newCA(t)
+
+	for _, tt := range []struct {
+		name string
+		data map[string][]byte
+		err  string
+	}{
+		{
+			name: "empty",
+			err:  `secret default/credentials contains none of the "api-key", "token", "tls.crt" or "ca.crt" keys`,
+		},
+		{
+			name: "certificate without key",
+			data: map[string][]byte{managementapi.CertKey: ca.certPEM},
+			err:  `error loading credentials from secret default/credentials: both "tls.crt" and "tls.key" keys are required for the client certificate`,
+		},
+		{
+			name: "invalid CA",
+			data: map[string][]byte{managementapi.CAKey: []byte("invalid")},
+			err:  "error loading credentials from secret default/credentials: failed to parse CA certificate",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := managementapi.CredentialsFromSecret(&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "credentials"},
+				Data:       tt.data,
+			})
+			require.EqualError(t, err, tt.err)
+		})
+	}
+}
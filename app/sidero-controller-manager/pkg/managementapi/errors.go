@@ -0,0 +1,62 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package managementapi
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Errors returned by the Client, API errors wrap them based on the response status.
+var (
+	// ErrUnauthorized is returned when the Management API rejects the credentials of the client.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrNotFound is returned when the requested object doesn't exist.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is returned when the object conflicts with an existing one, e.g. it's already registered.
+	ErrConflict = errors.New("conflict")
+	// ErrCircuitOpen is returned without sending the request while the endpoint is failing.
+	ErrCircuitOpen = errors.New("circuit breaker is open")
+)
+
+// APIError is returned when the Management API responds with a non-2xx status.
+type APIError struct {
+	StatusCode int
+	Detail     string
+
+	retryAfter time.Duration
+}
+
+// Error implements error.
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error (status %d): %s", e.StatusCode, e.Detail)
+}
+
+// Unwrap returns the typed error matching the status code, so that errors.Is can be used.
+func (e *APIError) Unwrap() error {
+	switch e.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrUnauthorized
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusConflict:
+		return ErrConflict
+	default:
+		return nil
+	}
+}
+
+// temporary returns true if the request can succeed when retried.
+func (e *APIError) temporary() bool {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
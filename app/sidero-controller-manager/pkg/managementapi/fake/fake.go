@@ -0,0 +1,613 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package fake implements an in-memory Management API for tests.
+//
+// The server implements the REST contract used by managementapi.Client: health, clusters, nodes, (batch) status updates,
+// deletion and the Sidero server list. Failures can be injected, either randomly with the power management
+// api.FailureDice, or deterministically with FailNext:
+//
+//   - api.ExplicitFailure rejects the request with 503 Service Unavailable, nothing is changed.
+//   - api.SilentFailure handles the request, but drops the connection instead of sending the response.
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/internal/power/api"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/pkg/managementapi"
+)
+
+// Cluster is a cluster registered in the fake Management API.
+type Cluster struct {
+	ID      string
+	Request managementapi.ClusterRegisterRequest
+	// Status is the last status update, if any.
+	Status *managementapi.StatusUpdateRequest
+}
+
+// Node is a node registered in the fake Management API.
+type Node struct {
+	ID      string
+	Request managementapi.NodeRegisterRequest
+	// Status is the last status update, if any.
+	Status *managementapi.StatusUpdateRequest
+	// Labels are listed with the node by the Sidero server list.
+	Labels map[string]string
+}
+
+// Server is the fake Management API server.
+type Server struct {
+	*httptest.Server
+
+	mux *http.ServeMux
+
+	mu        sync.Mutex
+	clusters  map[string]*Cluster
+	nodes     map[string]*Node
+	responses map[string]*httptest.ResponseRecorder
+	requests  []string
+	failures  []api.SimulatedFailure
+	nextID    int
+
+	dice   *api.FailureDice
+	apiKey string
+	token  string
+}
+
+// Option configures the fake server.
+type Option func(*Server)
+
+// WithFailureDice injects random failures.
+func WithFailureDice(dice *api.FailureDice) Option {
+	return func(s *Server) {
+		s.dice = dice
+	}
+}
+
+// WithAPIKey requires the X-API-Key header.
+func WithAPIKey(apiKey string) Option {
+	return func(s *Server) {
+		s.apiKey = apiKey
+	}
+}
+
+// WithToken requires the bearer token.
+func WithToken(token string) Option {
+	return func(s *Server) {
+		s.token = token
+	}
+}
+
+// NewServer starts the fake server, it should be closed with Close.
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		mux:       http.NewServeMux(),
+		clusters:  map[string]*Cluster{},
+		nodes:     map[string]*Node{},
+		responses: map[string]*httptest.ResponseRecorder{},
+		dice:      api.NewFailureDice(0, 0),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.mux.HandleFunc("GET /health", s.health)
+	s.mux.HandleFunc("POST /api/v1/clusters", s.registerCluster)
+	s.mux.HandleFunc("GET /api/v1/clusters/{id}", s.getCluster)
+	s.mux.HandleFunc("PATCH /api/v1/clusters/{id}/status", s.updateClusterStatus)
+	s.mux.HandleFunc("DELETE /api/v1/clusters/{id}", s.deleteCluster)
+	s.mux.HandleFunc("PATCH /api/v1/clusters/{id}/nodes/status", s.updateNodeStatuses)
+	s.mux.HandleFunc("POST /api/v1/nodes", s.registerNode)
+	s.mux.HandleFunc("GET /api/v1/nodes/{id}", s.getNode)
+	s.mux.HandleFunc("PATCH /api/v1/nodes/{id}/status", s.updateNodeStatus)
+	s.mux.HandleFunc("DELETE /api/v1/nodes/{id}", s.deleteNode)
+	s.mux.HandleFunc("GET /api/v1/sidero/list-servers", s.listServers)
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+
+	return s
+}
+
+// FailNext makes the next requests fail with the given modes, in order, before the dice is rolled.
+func (s *Server) FailNext(failures ...api.SimulatedFailure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = append(s.failures, failures...)
+}
+
+// DrainRequests returns the requests received so far as "METHOD /path", and forgets them.
+func (s *Server) DrainRequests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	requests := s.requests
+	s.requests = nil
+
+	return requests
+}
+
+// Clusters returns the registered clusters sorted by ID.
+func (s *Server) Clusters() []Cluster {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	clusters := make([]Cluster, 0, len(s.clusters))
+
+	for _, id := range slices.Sorted(maps.Keys(s.clusters)) {
+		clusters = append(clusters, *s.clusters[id])
+	}
+
+	return clusters
+}
+
+// Nodes returns the registered nodes sorted by ID.
+func (s *Server) Nodes() []Node {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	nodes := make([]Node, 0, len(s.nodes))
+
+	for _, id := range slices.Sorted(maps.Keys(s.nodes)) {
+		nodes = append(nodes, *s.nodes[id])
+	}
+
+	return nodes
+}
+
+// Cluster returns the registered cluster.
+func (s *Server) Cluster(id string) (Cluster, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cluster, ok := s.clusters[id]
+	if !ok {
+		return Cluster{}, false
+	}
+
+	return *cluster, true
+}
+
+// Node returns the registered node.
+func (s *Server) Node(id string) (Node, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	node, ok := s.nodes[id]
+	if !ok {
+		return Node{}, false
+	}
+
+	return *node, true
+}
+
+// AddCluster registers a cluster directly, as if it was registered by another client.
+func (s *Server) AddCluster(req managementapi.ClusterRegisterRequest) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := s.newID("cluster")
+	s.clusters[id] = &Cluster{ID: id, Request: req}
+
+	return id
+}
+
+// AddNode registers a node directly, as if it was registered by another client.
+func (s *Server) AddNode(req managementapi.NodeRegisterRequest, labels map[string]string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.clusters[req.ClusterID]; !ok {
+		return "", fmt.Errorf("cluster %q not found", req.ClusterID)
+	}
+
+	id := s.newID("node")
+	s.nodes[id] = &Node{ID: id, Request: req, Labels: labels}
+
+	return id, nil
+}
+
+// SetNodeLabels replaces the labels of the node.
+func (s *Server) SetNodeLabels(id string, labels map[string]string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	node, ok := s.nodes[id]
+	if ok {
+		node.Labels = labels
+	}
+
+	return ok
+}
+
+// RemoveCluster removes a cluster and its nodes, as if they were removed by another client.
+func (s *Server) RemoveCluster(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.removeCluster(id)
+}
+
+// RemoveNode removes a node, as if it was removed by another client.
+func (s *Server) RemoveNode(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.nodes[id]
+	delete(s.nodes, id)
+
+	return ok
+}
+
+// serve checks the credentials, injects the failures and replays the responses of the retried POST requests.
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+
+	if s.apiKey != "" && r.Header.Get("X-API-Key") != s.apiKey {
+		writeError(w, http.StatusUnauthorized, "invalid API key")
+
+		return
+	}
+
+	if s.token != "" && r.Header.Get("Authorization") != "Bearer "+s.token {
+		writeError(w, http.StatusUnauthorized, "invalid token")
+
+		return
+	}
+
+	failure := s.dice.Roll()
+
+	if len(s.failures) > 0 {
+		failure, s.failures = s.failures[0], s.failures[1:]
+	}
+
+	if failure == api.ExplicitFailure {
+		writeError(w, http.StatusServiceUnavailable, "simulated failure")
+
+		return
+	}
+
+	key := r.Header.Get(managementapi.IdempotencyKeyHeader)
+
+	recorder, replayed := s.responses[key]
+
+	if !replayed {
+		recorder = httptest.NewRecorder()
+
+		s.mux.ServeHTTP(recorder, r)
+
+		if r.Method == http.MethodPost && key != "" {
+			s.responses[key] = recorder
+		}
+	}
+
+	if failure == api.SilentFailure {
+		if hijacker, ok := w.(http.Hijacker); ok {
+			if conn, _, err := hijacker.Hijack(); err == nil {
+				conn.Close() //nolint:errcheck
+
+				return
+			}
+		}
+	}
+
+	maps.Copy(w.Header(), recorder.Header())
+	w.WriteHeader(recorder.Code)
+	w.Write(recorder.Body.Bytes()) //nolint:errcheck
+}
+
+func (s *Server) health(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, managementapi.HealthCheckResponse{
+		Status:    "healthy",
+		AppName:   "fake-management-api",
+		Version:   "test",
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+func (s *Server) registerCluster(w http.ResponseWriter, r *http.Request) {
+	var req managementapi.ClusterRegisterRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name == "" {
+		writeError(w, http.StatusUnprocessableEntity, "invalid cluster registration")
+
+		return
+	}
+
+	for _, cluster := range s.clusters {
+		if cluster.Request.Name == req.Name {
+			writeError(w, http.StatusConflict, fmt.Sprintf("cluster %q already exists", req.Name))
+
+			return
+		}
+	}
+
+	id := s.newID("cluster")
+	s.clusters[id] = &Cluster{ID: id, Request: req}
+
+	writeJSON(w, http.StatusCreated, managementapi.ClusterRegisterResponse{
+		ClusterID: id,
+		Name:      req.Name,
+		Status:    "registered",
+	})
+}
+
+func (s *Server) getCluster(w http.ResponseWriter, r *http.Request) {
+	cluster, ok := s.clusters[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "cluster not found")
+
+		return
+	}
+
+	info := managementapi.ClusterInfoResponse{
+		ClusterID:         cluster.ID,
+		Name:              cluster.Request.Name,
+		Location:          cluster.Request.Location,
+		Status:            "registered",
+		EndpointIP:        cluster.Request.EndpointIP,
+		EndpointPort:      cluster.Request.EndpointPort,
+		TalosVersion:      cluster.Request.TalosVersion,
+		KubernetesVersion: cluster.Request.KubernetesVersion,
+		Labels:            cluster.Request.Labels,
+	}
+
+	if cluster.Status != nil {
+		info.Status = cluster.Status.Status
+	}
+
+	writeJSON(w, http.StatusOK, info)
+}
+
+func (s *Server) updateClusterStatus(w http.ResponseWriter, r *http.Request) {
+	cluster, ok := s.clusters[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "cluster not found")
+
+		return
+	}
+
+	if status := decodeStatus(w, r); status != nil {
+		cluster.Status = status
+
+		writeJSON(w, http.StatusOK, managementapi.StatusUpdateResponse{Success: true})
+	}
+}
+
+func (s *Server) deleteCluster(w http.ResponseWriter, r *http.Request) {
+	if !s.removeCluster(r.PathValue("id")) {
+		writeError(w, http.StatusNotFound, "cluster not found")
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) registerNode(w http.ResponseWriter, r *http.Request) {
+	var req managementapi.NodeRegisterRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Hostname == "" {
+		writeError(w, http.StatusUnprocessableEntity, "invalid node registration")
+
+		return
+	}
+
+	if _, ok := s.clusters[req.ClusterID]; !ok {
+		writeError(w, http.StatusNotFound, "cluster not found")
+
+		return
+	}
+
+	for _, node := range s.nodes {
+		if node.Request.ClusterID == req.ClusterID && node.Request.Hostname == req.Hostname {
+			writeError(w, http.StatusConflict, fmt.Sprintf("node %q already exists", req.Hostname))
+
+			return
+		}
+	}
+
+	id := s.newID("node")
+	s.nodes[id] = &Node{ID: id, Request: req}
+
+	writeJSON(w, http.StatusCreated, managementapi.NodeRegisterResponse{
+		NodeID:    id,
+		ClusterID: req.ClusterID,
+		Hostname:  req.Hostname,
+		Status:    "registered",
+	})
+}
+
+func (s *Server) getNode(w http.ResponseWriter, r *http.Request) {
+	node, ok := s.nodes[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "node not found")
+
+		return
+	}
+
+	info := managementapi.NodeInfoResponse{
+		NodeID:            node.ID,
+		ClusterID:         node.Request.ClusterID,
+		Hostname:          node.Request.Hostname,
+		IPAddress:         node.Request.IPAddress,
+		NodeType:          node.Request.NodeType,
+		Status:            "registered",
+		TalosVersion:      node.Request.TalosVersion,
+		KubernetesVersion: node.Request.KubernetesVersion,
+	}
+
+	if node.Status != nil {
+		info.Status = node.Status.Status
+	}
+
+	writeJSON(w, http.StatusOK, info)
+}
+
+func (s *Server) updateNodeStatus(w http.ResponseWriter, r *http.Request) {
+	node, ok := s.nodes[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "node not found")
+
+		return
+	}
+
+	if status := decodeStatus(w, r); status != nil {
+		node.Status = status
+
+		writeJSON(w, http.StatusOK, managementapi.StatusUpdateResponse{Success: true})
+	}
+}
+
+func (s *Server) updateNodeStatuses(w http.ResponseWriter, r *http.Request) {
+	clusterID := r.PathValue("id")
+
+	if _, ok := s.clusters[clusterID]; !ok {
+		writeError(w, http.StatusNotFound, "cluster not found")
+
+		return
+	}
+
+	var req managementapi.NodeStatusBatchRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "invalid status update")
+
+		return
+	}
+
+	response := managementapi.NodeStatusBatchResponse{
+		Results: make([]managementapi.NodeStatusResult, 0, len(req.Updates)),
+	}
+
+	for _, update := range req.Updates {
+		result := managementapi.NodeStatusResult{NodeID: update.NodeID}
+
+		node, ok := s.nodes[update.NodeID]
+
+		switch {
+		case !ok || node.Request.ClusterID != clusterID:
+			result.Code, result.Error = http.StatusNotFound, "node not found"
+		case strings.TrimSpace(update.Status) == "":
+			result.Code, result.Error = http.StatusUnprocessableEntity, "invalid status update"
+		default:
+			status := update.StatusUpdateRequest
+			node.Status = &status
+			result.Success = true
+		}
+
+		response.Results = append(response.Results, result)
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) deleteNode(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	if _, ok := s.nodes[id]; !ok {
+		writeError(w, http.StatusNotFound, "node not found")
+
+		return
+	}
+
+	delete(s.nodes, id)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listServers(w http.ResponseWriter, r *http.Request) {
+	clusterName := r.URL.Query().Get("cluster_name")
+
+	response := managementapi.ServerListResponse{
+		Servers: []managementapi.ServerInfo{},
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(s.nodes)) {
+		node := s.nodes[id]
+		cluster := s.clusters[node.Request.ClusterID]
+
+		if clusterName != "" && cluster.Request.Name != clusterName {
+			continue
+		}
+
+		var endpoint string
+
+		if node.Request.IPAddress != "" {
+			endpoint = net.JoinHostPort(node.Request.IPAddress, "50000")
+		}
+
+		response.Servers = append(response.Servers, managementapi.ServerInfo{
+			Name:              node.Request.Hostname,
+			NodeID:            node.ID,
+			ClusterID:         cluster.ID,
+			ClusterName:       cluster.Request.Name,
+			TalosEndpoint:     endpoint,
+			NodeType:          node.Request.NodeType,
+			TalosVersion:      node.Request.TalosVersion,
+			KubernetesVersion: node.Request.KubernetesVersion,
+			Hostname:          node.Request.Hostname,
+			Labels:            node.Labels,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) removeCluster(id string) bool {
+	if _, ok := s.clusters[id]; !ok {
+		return false
+	}
+
+	delete(s.clusters, id)
+
+	maps.DeleteFunc(s.nodes, func(_ string, node *Node) bool {
+		return node.Request.ClusterID == id
+	})
+
+	return true
+}
+
+func (s *Server) newID(kind string) string {
+	s.nextID++
+
+	return fmt.Sprintf("%s-%d", kind, s.nextID)
+}
+
+func decodeStatus(w http.ResponseWriter, r *http.Request) *managementapi.StatusUpdateRequest {
+	var req managementapi.StatusUpdateRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.Status) == "" {
+		writeError(w, http.StatusUnprocessableEntity, "invalid status update")
+
+		return nil
+	}
+
+	return &req
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	json.NewEncoder(w).Encode(v) //nolint:errcheck
+}
+
+func writeError(w http.ResponseWriter, statusCode int, detail string) {
+	writeJSON(w, statusCode, managementapi.ErrorResponse{Detail: detail})
+}
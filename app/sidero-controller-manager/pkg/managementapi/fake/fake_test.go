@@ -0,0 +1,164 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package fake_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	metalv1 "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/internal/power/api"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/pkg/managementapi"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/pkg/managementapi/fake"
+)
+
+var fastRetries = managementapi.WithRetryPolicy(managementapi.RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   time.Millisecond,
+	MaxDelay:    10 * time.Millisecond,
+})
+
+func newClient(t *testing.T, srv *fake.Server, opts ...managementapi.ClientOption) *managementapi.Client {
+	t.Helper()
+
+	client, err := managementapi.NewClient(srv.URL, append([]managementapi.ClientOption{fastRetries}, opts...)...)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { client.Close() }) //nolint:errcheck
+
+	return client
+}
+
+func TestSyncAdoptedServer(t *testing.T) {
+	t.Parallel()
+
+	srv := fake.NewServer()
+	t.Cleanup(srv.Close)
+
+	syncService := managementapi.NewSyncService(newClient(t, srv), logr.Discard())
+	ctx := context.Background()
+
+	as := &metalv1.AdoptedServer{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Spec: metalv1.AdoptedServerSpec{
+			Talos: metalv1.TalosConfig{
+				Endpoint: "10.5.0.2:50000",
+				NodeType: "controlplane",
+			},
+			ManagementAPI: &metalv1.ManagementAPIConfig{
+				Enabled:     true,
+				Endpoint:    srv.URL,
+				ClusterName: "cluster",
+			},
+		},
+		Status: metalv1.AdoptedServerStatus{Connected: true},
+	}
+
+	require.NoError(t, syncService.SyncAdoptedServer(ctx, as))
+	require.NoError(t, syncService.SyncAdoptedServer(ctx, as))
+
+	clusters := srv.Clusters()
+	require.Len(t, clusters, 1)
+	assert.Equal(t, "cluster", clusters[0].Request.Name)
+	assert.Equal(t, "active", clusters[0].Status.Status)
+
+	node, ok := srv.Node(as.Status.ManagementAPIStatus.NodeID)
+	require.True(t, ok)
+	assert.Equal(t, clusters[0].ID, node.Request.ClusterID)
+	assert.Equal(t, "10.5.0.2", node.Request.IPAddress)
+	assert.Equal(t, "active", node.Status.Status)
+
+	list, err := newClient(t, srv).ListServers(ctx, "cluster")
+	require.NoError(t, err)
+	assert.Equal(t, []managementapi.ServerInfo{
+		{
+			Name:          "node-1",
+			NodeID:        node.ID,
+			ClusterID:     clusters[0].ID,
+			ClusterName:   "cluster",
+			TalosEndpoint: "10.5.0.2:50000",
+			NodeType:      "controlplane",
+			Hostname:      "node-1",
+		},
+	}, list.Servers)
+
+	require.NoError(t, syncService.UnregisterAdoptedServer(ctx, as, true))
+	assert.Empty(t, srv.Clusters())
+	assert.Empty(t, srv.Nodes())
+}
+
+func TestRetries(t *testing.T) {
+	t.Parallel()
+
+	srv := fake.NewServer()
+	t.Cleanup(srv.Close)
+
+	client := newClient(t, srv)
+	ctx := context.Background()
+
+	// temporary failures are retried
+	srv.FailNext(api.ExplicitFailure, api.ExplicitFailure)
+
+	_, err := client.HealthCheck(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"GET /health", "GET /health", "GET /health"}, srv.DrainRequests())
+
+	// lost responses of POST requests are retried without registering twice
+	srv.FailNext(api.SilentFailure)
+
+	resp, err := client.RegisterCluster(ctx, &managementapi.ClusterRegisterRequest{Name: "cluster"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"POST /api/v1/clusters", "POST /api/v1/clusters"}, srv.DrainRequests())
+	assert.Len(t, srv.Clusters(), 1)
+
+	// status updates are not retried
+	srv.FailNext(api.ExplicitFailure)
+
+	_, err = client.UpdateClusterStatus(ctx, resp.ClusterID, &managementapi.StatusUpdateRequest{Status: "active"})
+	require.Error(t, err)
+	assert.Equal(t, []string{"PATCH /api/v1/clusters/" + resp.ClusterID + "/status"}, srv.DrainRequests())
+
+	// upstream removal is reported
+	require.True(t, srv.RemoveCluster(resp.ClusterID))
+
+	_, err = client.UpdateClusterStatus(ctx, resp.ClusterID, &managementapi.StatusUpdateRequest{Status: "active"})
+	require.ErrorIs(t, err, managementapi.ErrNotFound)
+}
+
+func TestFailureDice(t *testing.T) {
+	t.Parallel()
+
+	srv := fake.NewServer(fake.WithFailureDice(api.NewFailureDice(1, 0)))
+	t.Cleanup(srv.Close)
+
+	client := newClient(t, srv, managementapi.WithCircuitBreaker(managementapi.NewCircuitBreaker(3, time.Minute)))
+
+	_, err := client.HealthCheck(context.Background())
+	require.Error(t, err)
+
+	_, err = client.HealthCheck(context.Background())
+	require.ErrorIs(t, err, managementapi.ErrCircuitOpen)
+
+	assert.Len(t, srv.DrainRequests(), 3)
+}
+
+func TestAuthentication(t *testing.T) {
+	t.Parallel()
+
+	srv := fake.NewServer(fake.WithAPIKey("key"))
+	t.Cleanup(srv.Close)
+
+	_, err := newClient(t, srv).HealthCheck(context.Background())
+	require.ErrorIs(t, err, managementapi.ErrUnauthorized)
+
+	_, err = newClient(t, srv, managementapi.WithAPIKey("key")).HealthCheck(context.Background())
+	require.NoError(t, err)
+}
@@ -0,0 +1,101 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package managementapi
+
+import (
+	metalv1 "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
+)
+
+// Fields of the AdoptedServer synced from the Management API inventory, as reported in the conflicts.
+const (
+	FieldEndpoint = "endpoint"
+	FieldNodeType = "nodeType"
+	FieldHostname = "hostname"
+	FieldVersions = "versions"
+	FieldLabels   = "labels"
+)
+
+// ApplyServerInfo updates the AdoptedServer spec from the server listed by the Management API.
+//
+// A field is overwritten only if the Management API is its source of truth, otherwise the differing field is kept and
+// returned in the conflicts. Values which are not listed by the Management API are never cleared.
+func ApplyServerInfo(as *metalv1.AdoptedServer, server *ServerInfo, policy metalv1.InventorySourceOfTruth) (conflicts []string) {
+	sync := func(field string, source metalv1.SourceOfTruth, differs bool, apply func()) {
+		if !differs {
+			return
+		}
+
+		if sourceOf(policy, source) == metalv1.SourceOfTruthSidero {
+			conflicts = append(conflicts, field)
+
+			return
+		}
+
+		apply()
+	}
+
+	talos := &as.Spec.Talos
+
+	sync(FieldEndpoint, policy.Endpoint, server.TalosEndpoint != "" && server.TalosEndpoint != talos.Endpoint, func() {
+		talos.Endpoint = server.TalosEndpoint
+	})
+
+	nodeType := server.NodeType
+	if nodeType != "controlplane" && nodeType != "worker" {
+		nodeType = ""
+	}
+
+	sync(FieldNodeType, policy.NodeType, nodeType != "" && nodeType != talos.NodeType, func() {
+		talos.NodeType = nodeType
+	})
+
+	sync(FieldHostname, policy.Hostname, server.Hostname != "" && server.Hostname != talos.Hostname, func() {
+		talos.Hostname = server.Hostname
+	})
+
+	versionsDiffer := (server.TalosVersion != "" && server.TalosVersion != talos.TalosVersion) ||
+		(server.KubernetesVersion != "" && server.KubernetesVersion != talos.KubernetesVersion)
+
+	sync(FieldVersions, policy.Versions, versionsDiffer, func() {
+		if server.TalosVersion != "" {
+			talos.TalosVersion = server.TalosVersion
+		}
+
+		if server.KubernetesVersion != "" {
+			talos.KubernetesVersion = server.KubernetesVersion
+		}
+	})
+
+	labelsDiffer := false
+
+	for key, value := range server.Labels {
+		if current, ok := as.Spec.Labels[key]; !ok || current != value {
+			labelsDiffer = true
+		}
+	}
+
+	sync(FieldLabels, policy.Labels, labelsDiffer, func() {
+		if as.Spec.Labels == nil {
+			as.Spec.Labels = make(map[string]string, len(server.Labels))
+		}
+
+		for key, value := range server.Labels {
+			as.Spec.Labels[key] = value
+		}
+	})
+
+	return conflicts
+}
+
+func sourceOf(policy metalv1.InventorySourceOfTruth, field metalv1.SourceOfTruth) metalv1.SourceOfTruth {
+	switch {
+	case field != "":
+		return field
+	case policy.Default != "":
+		return policy.Default
+	default:
+		return metalv1.SourceOfTruthManagementAPI
+	}
+}
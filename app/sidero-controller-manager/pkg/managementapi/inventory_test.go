@@ -0,0 +1,126 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package managementapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metalv1 "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/pkg/managementapi"
+)
+
+func TestApplyServerInfo(t *testing.T) {
+	t.Parallel()
+
+	server := &managementapi.ServerInfo{
+		Name:              "node-1",
+		TalosEndpoint:     "10.5.0.3:50000",
+		NodeType:          "worker",
+		TalosVersion:      "v1.9.0",
+		KubernetesVersion: "v1.32.0",
+		Hostname:          "node-1.example.com",
+		Labels:            map[string]string{"rack": "r2"},
+	}
+
+	current := metalv1.AdoptedServerSpec{
+		Talos: metalv1.TalosConfig{
+			Endpoint:          "10.5.0.2:50000",
+			NodeType:          "worker",
+			TalosVersion:      "v1.8.3",
+			KubernetesVersion: "v1.32.0",
+			Hostname:          "node-1",
+		},
+		Labels: map[string]string{"rack": "r1", "location": "spokane"},
+	}
+
+	for _, tt := range []struct {
+		name      string
+		policy    metalv1.InventorySourceOfTruth
+		expected  metalv1.AdoptedServerSpec
+		conflicts []string
+	}{
+		{
+			name: "management API by default",
+			expected: metalv1.AdoptedServerSpec{
+				Talos: metalv1.TalosConfig{
+					Endpoint:          "10.5.0.3:50000",
+					NodeType:          "worker",
+					TalosVersion:      "v1.9.0",
+					KubernetesVersion: "v1.32.0",
+					Hostname:          "node-1.example.com",
+				},
+				Labels: map[string]string{"rack": "r2", "location": "spokane"},
+			},
+		},
+		{
+			name:      "sidero by default",
+			policy:    metalv1.InventorySourceOfTruth{Default: metalv1.SourceOfTruthSidero},
+			expected:  current,
+			conflicts: []string{managementapi.FieldEndpoint, managementapi.FieldHostname, managementapi.FieldVersions, managementapi.FieldLabels},
+		},
+		{
+			name: "per field",
+			policy: metalv1.InventorySourceOfTruth{
+				Default:  metalv1.SourceOfTruthSidero,
+				Endpoint: metalv1.SourceOfTruthManagementAPI,
+				Versions: metalv1.SourceOfTruthManagementAPI,
+			},
+			expected: metalv1.AdoptedServerSpec{
+				Talos: metalv1.TalosConfig{
+					Endpoint:          "10.5.0.3:50000",
+					NodeType:          "worker",
+					TalosVersion:      "v1.9.0",
+					KubernetesVersion: "v1.32.0",
+					Hostname:          "node-1",
+				},
+				Labels: map[string]string{"rack": "r1", "location": "spokane"},
+			},
+			conflicts: []string{managementapi.FieldHostname, managementapi.FieldLabels},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			as := &metalv1.AdoptedServer{Spec: *current.DeepCopy()}
+
+			conflicts := managementapi.ApplyServerInfo(as, server, tt.policy)
+
+			assert.Equal(t, tt.conflicts, conflicts)
+			assert.Equal(t, tt.expected, as.Spec)
+		})
+	}
+}
+
+func TestListServers(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/sidero/list-servers" || r.URL.Query().Get("cluster_name") != "prod cluster" {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		json.NewEncoder(w).Encode(managementapi.ServerListResponse{ //nolint:errcheck
+			Servers: []managementapi.ServerInfo{{Name: "node-1", NodeID: "n1", ClusterName: "prod cluster"}},
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := managementapi.NewClient(srv.URL)
+	require.NoError(t, err)
+
+	resp, err := client.ListServers(context.Background(), "prod cluster")
+	require.NoError(t, err)
+
+	assert.Equal(t, []managementapi.ServerInfo{{Name: "node-1", NodeID: "n1", ClusterName: "prod cluster"}}, resp.Servers)
+}
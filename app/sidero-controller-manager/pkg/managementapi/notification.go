@@ -0,0 +1,89 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package managementapi
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Notification events sent by the Management API.
+const (
+	// EventAdopt is sent when a server was adopted through the Management API.
+	EventAdopt = "adopt"
+	// EventLabels is sent when the labels of a server were changed.
+	EventLabels = "labels"
+	// EventRemove is sent when a server was removed from the Management API.
+	EventRemove = "remove"
+	// EventSync requests an immediate sync of a server.
+	EventSync = "sync"
+)
+
+// Notification headers.
+//
+// The signature is the hex encoded HMAC-SHA256 of "<timestamp>.<body>" prefixed with "sha256=",
+// and the timestamp is the Unix time when the notification was sent.
+const (
+	SignatureHeader = "X-Sidero-Signature"
+	TimestampHeader = "X-Sidero-Timestamp"
+)
+
+// MaxNotificationAge is the maximum difference between the notification timestamp and the current time.
+const MaxNotificationAge = 5 * time.Minute
+
+// ErrInvalidSignature is returned when the notification signature can't be verified.
+var ErrInvalidSignature = errors.New("invalid notification signature")
+
+// Notification represents a change notification sent by the Management API.
+//
+// The AdoptedServer is identified by Server (its name), or by NodeID.
+type Notification struct {
+	Event       string            `json:"event"`
+	Server      string            `json:"server,omitempty"`
+	NodeID      string            `json:"node_id,omitempty"`
+	ClusterName string            `json:"cluster_name,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+}
+
+// SignNotification returns the signature header value of the notification body.
+func SignNotification(secret []byte, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10))) //nolint:errcheck
+	mac.Write([]byte("."))                                     //nolint:errcheck
+	mac.Write(body)                                            //nolint:errcheck
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyNotification checks the signature and the timestamp headers of the notification body.
+func VerifyNotification(secret []byte, signature, timestamp string, body []byte, now time.Time) error {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.Wrapf(ErrInvalidSignature, "invalid timestamp %q", timestamp)
+	}
+
+	sent := time.Unix(seconds, 0)
+
+	if age := now.Sub(sent); age > MaxNotificationAge || age < -MaxNotificationAge {
+		return errors.Wrapf(ErrInvalidSignature, "timestamp %s is outside of the allowed window", sent.UTC().Format(time.RFC3339))
+	}
+
+	if !strings.HasPrefix(signature, "sha256=") {
+		return errors.Wrap(ErrInvalidSignature, "unsupported signature scheme")
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(SignNotification(secret, sent, body))) {
+		return errors.Wrap(ErrInvalidSignature, "signature mismatch")
+	}
+
+	return nil
+}
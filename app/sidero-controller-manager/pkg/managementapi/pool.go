@@ -0,0 +1,134 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package managementapi
+
+import (
+	"sync"
+	"time"
+)
+
+// clientIdleTimeout is the time after which the clients which weren't requested are closed, e.g. after credential rotation.
+const clientIdleTimeout = time.Hour
+
+// Pool shares the Management API clients between the reconciles.
+//
+// There is a client for each endpoint and credentials, so that the connections are reused, and the objects using
+// different credentials for the same endpoint don't replace each other's client. All the clients of an endpoint share
+// the same circuit breaker.
+type Pool struct {
+	mu       sync.Mutex
+	clients  map[poolKey]*pooledClient
+	breakers map[string]*CircuitBreaker
+	opts     []ClientOption
+	now      func() time.Time
+}
+
+type poolKey struct {
+	endpoint    string
+	fingerprint string
+}
+
+type pooledClient struct {
+	client   *Client
+	lastUsed time.Time
+}
+
+// NewPool creates an empty pool, the options are applied to all the clients.
+func NewPool(opts ...ClientOption) *Pool {
+	return &Pool{
+		clients:  map[poolKey]*pooledClient{},
+		breakers: map[string]*CircuitBreaker{},
+		opts:     opts,
+		now:      time.Now,
+	}
+}
+
+// Client returns the client for the endpoint and credentials, creating it if needed.
+//
+// The credentials can be nil if the endpoint doesn't require authentication.
+func (p *Pool) Client(endpoint string, creds *Credentials) (*Client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+
+	p.closeIdle(now)
+
+	key := poolKey{
+		endpoint:    endpoint,
+		fingerprint: creds.Fingerprint(),
+	}
+
+	if entry, ok := p.clients[key]; ok {
+		entry.lastUsed = now
+
+		return entry.client, nil
+	}
+
+	breaker, ok := p.breakers[endpoint]
+	if !ok {
+		breaker = NewCircuitBreaker(DefaultFailureThreshold, DefaultOpenTimeout)
+	}
+
+	opts := append([]ClientOption{}, p.opts...)
+	opts = append(opts, WithCircuitBreaker(breaker))
+
+	if creds != nil {
+		opts = append(opts, WithCredentials(creds))
+	}
+
+	client, err := NewClient(endpoint, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	p.breakers[endpoint] = breaker
+	p.clients[key] = &pooledClient{
+		client:   client,
+		lastUsed: now,
+	}
+
+	return client, nil
+}
+
+// closeIdle closes the clients which weren't requested for clientIdleTimeout,
+// and removes the circuit breakers of the endpoints which have no clients left.
+//
+// Requests in flight keep using the closed clients until they finish.
+func (p *Pool) closeIdle(now time.Time) {
+	for key, entry := range p.clients {
+		if now.Sub(entry.lastUsed) > clientIdleTimeout {
+			entry.client.Close() //nolint:errcheck
+
+			delete(p.clients, key)
+		}
+	}
+
+	endpoints := make(map[string]struct{}, len(p.clients))
+
+	for key := range p.clients {
+		endpoints[key.endpoint] = struct{}{}
+	}
+
+	for endpoint := range p.breakers {
+		if _, ok := endpoints[endpoint]; !ok {
+			delete(p.breakers, endpoint)
+		}
+	}
+}
+
+// Close closes all the clients, and removes the circuit breakers.
+func (p *Pool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for key, entry := range p.clients {
+		entry.client.Close() //nolint:errcheck
+
+		delete(p.clients, key)
+	}
+
+	clear(p.breakers)
+}
@@ -0,0 +1,133 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package managementapi
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// RetryPolicy configures the retries of failed requests.
+//
+// Only the idempotent requests are retried: GET, HEAD, PUT and DELETE, and the POST requests carrying an idempotency key.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, 1 disables the retries.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry, it's doubled on each attempt.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between the attempts, including the one requested with Retry-After.
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy is used unless overridden with WithRetryPolicy.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   250 * time.Millisecond,
+	MaxDelay:    5 * time.Second,
+}
+
+// backoff returns the delay before the next attempt: exponential with the random jitter of up to a half of it.
+func (p RetryPolicy) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	delay := p.BaseDelay << attempt
+	if delay <= 0 || delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	if half := int64(delay / 2); half > 0 {
+		delay = time.Duration(half + rand.Int64N(half+1)) //nolint:gosec
+	}
+
+	if retryAfter > delay {
+		delay = min(retryAfter, p.MaxDelay)
+	}
+
+	return delay
+}
+
+// Circuit breaker defaults.
+const (
+	DefaultFailureThreshold = 5
+	DefaultOpenTimeout      = 30 * time.Second
+)
+
+// CircuitBreaker stops sending requests to an endpoint after consecutive failures.
+//
+// Once FailureThreshold requests in a row failed, the breaker opens and the requests fail with ErrCircuitOpen
+// for OpenTimeout. Then a single probe request is let through: the breaker closes if it succeeds, and opens again otherwise.
+type CircuitBreaker struct {
+	now func() time.Time
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+
+	threshold   int
+	openTimeout time.Duration
+}
+
+// NewCircuitBreaker creates a closed circuit breaker.
+func NewCircuitBreaker(failureThreshold int, openTimeout time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		now:         time.Now,
+		threshold:   failureThreshold,
+		openTimeout: openTimeout,
+	}
+}
+
+// Open returns true if the requests are currently rejected.
+func (b *CircuitBreaker) Open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.failures >= b.threshold && (b.probing || b.now().Sub(b.openedAt) < b.openTimeout)
+}
+
+// allow reports whether a request can be sent.
+func (b *CircuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return true
+	}
+
+	if b.probing || b.now().Sub(b.openedAt) < b.openTimeout {
+		return false
+	}
+
+	b.probing = true
+
+	return true
+}
+
+// record updates the breaker with the request outcome.
+func (b *CircuitBreaker) record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+
+	if success {
+		b.failures = 0
+
+		return
+	}
+
+	b.failures++
+
+	if b.failures >= b.threshold {
+		b.openedAt = b.now()
+	}
+}
+
+// abort releases the probe slot without recording the outcome.
+func (b *CircuitBreaker) abort() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
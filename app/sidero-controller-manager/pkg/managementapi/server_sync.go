@@ -0,0 +1,323 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package managementapi
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+
+	metalv1 "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
+)
+
+// DefaultPoolClusterName is the Management API cluster of the Servers which are not allocated to a CAPI cluster.
+const DefaultPoolClusterName = "sidero-pool"
+
+// talosAPIPort is the port of the Talos API, reported as the endpoint port of the Server clusters.
+const talosAPIPort = 50000
+
+// Server states reported to the Management API.
+const (
+	ServerStatePending   = "pending"
+	ServerStateCordoned  = "cordoned"
+	ServerStateAllocated = "allocated"
+	ServerStateWiping    = "wiping"
+	ServerStateAvailable = "available"
+)
+
+// ServerCluster describes the Management API cluster a Server belongs to.
+type ServerCluster struct {
+	// Name of the cluster: the CAPI cluster of the ServerBinding, or the pool cluster of the unallocated Servers.
+	Name string
+	// ID of an existing cluster, the cluster is registered on the first sync otherwise.
+	ID string
+	// EndpointIP is the control plane endpoint of the cluster, if known.
+	EndpointIP string
+	// NodeType of the Server in the cluster: "controlplane" or "worker", empty for the unallocated Servers.
+	NodeType string
+	// Managed is true for the CAPI clusters.
+	Managed bool
+}
+
+// SyncServer synchronizes a bare-metal Server with the Management API.
+//
+// The Server is registered as a node of the cluster, and its status, allocation and hardware are updated afterwards.
+// The cluster and node IDs are recorded in the Server status (persisted by the controller).
+// When the Server moves to another cluster, its node is deleted from the previous one and registered again.
+func (s *SyncService) SyncServer(ctx context.Context, server *metalv1.Server, cluster *ServerCluster) error {
+	if server.Status.ManagementAPIStatus == nil {
+		server.Status.ManagementAPIStatus = &metalv1.ManagementAPIStatus{}
+	}
+
+	status := server.Status.ManagementAPIStatus
+
+	if status.ClusterName != cluster.Name {
+		if status.NodeID != "" {
+			s.logger.Info("Server moved to another cluster", "from", status.ClusterName, "to", cluster.Name)
+
+			if err := s.client.DeleteNode(ctx, status.NodeID); err != nil && !errors.Is(err, ErrNotFound) {
+				return errors.Wrapf(err, "failed to delete node %s", status.NodeID)
+			}
+		}
+
+		status.ClusterName = cluster.Name
+		status.ClusterID = ""
+		status.NodeID = ""
+	}
+
+	if cluster.ID != "" {
+		status.ClusterID = cluster.ID
+	}
+
+	if status.ClusterID == "" {
+		clusterID, err := s.registerServerCluster(ctx, server, cluster)
+		if err != nil {
+			return err
+		}
+
+		status.ClusterID = clusterID
+	}
+
+	if status.NodeID != "" {
+		_, err := s.client.UpdateNodeStatus(ctx, status.NodeID, serverStatusUpdateRequest(server))
+		if !errors.Is(err, ErrNotFound) {
+			return errors.Wrap(err, "failed to update node status")
+		}
+
+		// Node was removed from the Management API, register it again
+		s.logger.Info("Node not found in Management API", "node_id", status.NodeID)
+
+		status.NodeID = ""
+	}
+
+	req := serverNodeRegisterRequest(server, cluster)
+	req.ClusterID = status.ClusterID
+
+	resp, err := s.client.RegisterNode(ctx, req)
+	if errors.Is(err, ErrNotFound) && cluster.ID == "" {
+		// Cluster was removed from the Management API, register it again
+		s.logger.Info("Cluster not found in Management API", "cluster_id", status.ClusterID)
+
+		status.ClusterID = ""
+
+		if req.ClusterID, err = s.registerServerCluster(ctx, server, cluster); err != nil {
+			return err
+		}
+
+		status.ClusterID = req.ClusterID
+
+		resp, err = s.client.RegisterNode(ctx, req)
+	}
+
+	switch {
+	case errors.Is(err, ErrConflict):
+		// The node ID was lost (e.g. status reset), adopt the existing node
+		if status.NodeID, err = s.findNode(ctx, cluster.Name, req.Hostname); err != nil {
+			return err
+		}
+	case err != nil:
+		return errors.Wrap(err, "failed to register node")
+	default:
+		s.logger.Info("Node registered successfully", "node_id", resp.NodeID)
+
+		status.NodeID = resp.NodeID
+	}
+
+	// The registration carries no status, push it right away
+	if _, err = s.client.UpdateNodeStatus(ctx, status.NodeID, serverStatusUpdateRequest(server)); err != nil {
+		return errors.Wrap(err, "failed to update node status")
+	}
+
+	return nil
+}
+
+// registerServerCluster registers the cluster of the Server with the Management API.
+func (s *SyncService) registerServerCluster(ctx context.Context, server *metalv1.Server, cluster *ServerCluster) (string, error) {
+	s.logger.Info("Registering cluster with Management API", "cluster", cluster.Name)
+
+	location := server.Labels["location"]
+	if location == "" {
+		location = "unknown"
+	}
+
+	resp, err := s.client.RegisterCluster(ctx, &ClusterRegisterRequest{
+		Name:         cluster.Name,
+		Location:     location,
+		EndpointIP:   cluster.EndpointIP,
+		EndpointPort: talosAPIPort,
+		Managed:      cluster.Managed,
+	})
+	if err != nil {
+		if errors.Is(err, ErrConflict) {
+			return "", errors.Wrapf(err, "cluster %q is already registered, set spec.managementAPI.clusterID of the ServerClass to its ID", cluster.Name)
+		}
+
+		return "", errors.Wrap(err, "failed to register cluster")
+	}
+
+	s.logger.Info("Cluster registered successfully", "cluster_id", resp.ClusterID)
+
+	return resp.ClusterID, nil
+}
+
+// UnregisterServer removes a Server from the Management API.
+//
+// The node is deleted if it was registered, and its cluster is deleted as well if deleteCluster is set,
+// i.e. the Server is the last one of the cluster. Objects already removed from the Management API are ignored.
+func (s *SyncService) UnregisterServer(ctx context.Context, server *metalv1.Server, deleteCluster bool) error {
+	status := server.Status.ManagementAPIStatus
+	if status == nil {
+		return nil
+	}
+
+	s.logger.Info("Unregistering Server from Management API", "name", server.Name)
+
+	if status.NodeID != "" {
+		if err := s.client.DeleteNode(ctx, status.NodeID); err != nil && !errors.Is(err, ErrNotFound) {
+			return errors.Wrapf(err, "failed to delete node %s", status.NodeID)
+		}
+
+		s.logger.Info("Node deleted from Management API", "node_id", status.NodeID)
+
+		status.NodeID = ""
+	}
+
+	if deleteCluster && status.ClusterID != "" {
+		if err := s.client.DeleteCluster(ctx, status.ClusterID); err != nil && !errors.Is(err, ErrNotFound) {
+			return errors.Wrapf(err, "failed to delete cluster %s", status.ClusterID)
+		}
+
+		s.logger.Info("Cluster deleted from Management API", "cluster_id", status.ClusterID)
+	}
+
+	status.ClusterID = ""
+	status.ClusterName = ""
+
+	return nil
+}
+
+// ServerState returns the allocation state of the Server reported to the Management API.
+func ServerState(server *metalv1.Server) string {
+	switch {
+	case !server.Spec.Accepted:
+		return ServerStatePending
+	case server.Status.InUse:
+		return ServerStateAllocated
+	case server.Spec.Cordoned:
+		return ServerStateCordoned
+	case !server.Status.IsClean:
+		return ServerStateWiping
+	default:
+		return ServerStateAvailable
+	}
+}
+
+// serverNodeRegisterRequest builds the node registration of the Server.
+func serverNodeRegisterRequest(server *metalv1.Server, cluster *ServerCluster) *NodeRegisterRequest {
+	hostname := server.Spec.Hostname
+	if hostname == "" {
+		hostname = server.Name
+	}
+
+	return &NodeRegisterRequest{
+		Hostname:  hostname,
+		IPAddress: serverIPAddress(server.Status.Addresses),
+		NodeType:  cluster.NodeType,
+		Hardware:  serverHardware(server.Spec.Hardware),
+	}
+}
+
+// serverStatusUpdateRequest builds the node status update from the Server status.
+func serverStatusUpdateRequest(server *metalv1.Server) *StatusUpdateRequest {
+	power := server.Status.Power
+	if power == "" {
+		power = "unknown"
+	}
+
+	return &StatusUpdateRequest{
+		Status:    ServerState(server),
+		Addresses: addressesToStrings(server.Status.Addresses),
+		Metadata: map[string]string{
+			"power":    power,
+			"in_use":   strconv.FormatBool(server.Status.InUse),
+			"is_clean": strconv.FormatBool(server.Status.IsClean),
+			"cordoned": strconv.FormatBool(server.Spec.Cordoned),
+		},
+		Hardware: serverHardware(server.Spec.Hardware),
+	}
+}
+
+// serverIPAddress returns the internal IP of the Server, or its first address.
+func serverIPAddress(addresses []corev1.NodeAddress) string {
+	for _, addr := range addresses {
+		if addr.Type == corev1.NodeInternalIP {
+			return addr.Address
+		}
+	}
+
+	if len(addresses) > 0 {
+		return addresses[0].Address
+	}
+
+	return ""
+}
+
+// serverHardware summarizes the hardware inventory of the Server.
+func serverHardware(hw *metalv1.HardwareInformation) *NodeHardware {
+	if hw == nil {
+		return nil
+	}
+
+	result := &NodeHardware{}
+
+	if hw.System != nil {
+		result.Manufacturer = hw.System.Manufacturer
+		result.ProductName = hw.System.ProductName
+		result.SerialNumber = hw.System.SerialNumber
+	}
+
+	if hw.Compute != nil {
+		result.CPUCores = hw.Compute.TotalCoreCount
+		result.CPUThreads = hw.Compute.TotalThreadCount
+
+		if len(hw.Compute.Processors) > 0 && hw.Compute.Processors[0] != nil {
+			result.CPUModel = hw.Compute.Processors[0].ProductName
+		}
+	}
+
+	if hw.Memory != nil {
+		for _, module := range hw.Memory.Modules {
+			if module != nil {
+				result.MemoryMB += uint64(module.Size)
+			}
+		}
+	}
+
+	if hw.Storage != nil {
+		for _, device := range hw.Storage.Devices {
+			if device == nil {
+				continue
+			}
+
+			result.StorageBytes += device.Size
+
+			if device.DeviceName != "" {
+				result.Disks = append(result.Disks, device.DeviceName)
+			}
+		}
+	}
+
+	if hw.Network != nil {
+		for _, iface := range hw.Network.Interfaces {
+			if iface != nil && iface.MAC != "" {
+				result.MACAddresses = append(result.MACAddresses, iface.MAC)
+			}
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,218 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package managementapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	metalv1 "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/pkg/managementapi"
+)
+
+func TestSyncServer(t *testing.T) {
+	t.Parallel()
+
+	reg := &registry{objects: map[string]bool{}}
+
+	srv := httptest.NewServer(reg)
+	t.Cleanup(srv.Close)
+
+	client, err := managementapi.NewClient(srv.URL)
+	require.NoError(t, err)
+
+	syncService := managementapi.NewSyncService(client, logr.Discard())
+	ctx := context.Background()
+
+	server := &metalv1.Server{
+		ObjectMeta: metav1.ObjectMeta{Name: "0000-1111"},
+		Spec:       metalv1.ServerSpec{Accepted: true},
+		Status: metalv1.ServerStatus{
+			IsClean:   true,
+			Addresses: []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "10.5.0.2"}},
+		},
+	}
+
+	pool := &managementapi.ServerCluster{Name: managementapi.DefaultPoolClusterName}
+
+	// first sync registers the pool cluster and the node, and pushes its status
+	require.NoError(t, syncService.SyncServer(ctx, server, pool))
+	assert.Equal(t, []string{"POST /api/v1/clusters", "POST /api/v1/nodes", "PATCH /api/v1/nodes/id-2/status"}, reg.drain())
+	assert.Equal(t, &metalv1.ManagementAPIStatus{ClusterName: "sidero-pool", ClusterID: "id-1", NodeID: "id-2"}, server.Status.ManagementAPIStatus)
+
+	// next syncs only update the status
+	require.NoError(t, syncService.SyncServer(ctx, server, pool))
+	assert.Equal(t, []string{"PATCH /api/v1/nodes/id-2/status"}, reg.drain())
+
+	// allocation moves the node to the CAPI cluster
+	server.Status.InUse = true
+	server.Status.IsClean = false
+
+	workload := &managementapi.ServerCluster{Name: "workload", NodeType: "controlplane", Managed: true}
+
+	require.NoError(t, syncService.SyncServer(ctx, server, workload))
+	assert.Equal(t, []string{
+		"DELETE /api/v1/nodes/id-2",
+		"POST /api/v1/clusters",
+		"POST /api/v1/nodes",
+		"PATCH /api/v1/nodes/id-4/status",
+	}, reg.drain())
+	assert.Equal(t, &metalv1.ManagementAPIStatus{ClusterName: "workload", ClusterID: "id-3", NodeID: "id-4"}, server.Status.ManagementAPIStatus)
+
+	// node removed upstream is registered again
+	reg.mu.Lock()
+	delete(reg.objects, "/api/v1/nodes/id-4")
+	reg.mu.Unlock()
+
+	require.NoError(t, syncService.SyncServer(ctx, server, workload))
+	assert.Equal(t, []string{"PATCH /api/v1/nodes/id-4/status", "POST /api/v1/nodes", "PATCH /api/v1/nodes/id-5/status"}, reg.drain())
+
+	// release goes back to the existing pool cluster
+	server.Status.InUse = false
+
+	require.NoError(t, syncService.UnregisterServer(ctx, server, true))
+	assert.Equal(t, []string{"DELETE /api/v1/nodes/id-5", "DELETE /api/v1/clusters/id-3"}, reg.drain())
+
+	require.NoError(t, syncService.SyncServer(ctx, server, &managementapi.ServerCluster{Name: managementapi.DefaultPoolClusterName, ID: "id-1"}))
+	assert.Equal(t, []string{"POST /api/v1/nodes", "PATCH /api/v1/nodes/id-6/status"}, reg.drain())
+	assert.Equal(t, &metalv1.ManagementAPIStatus{ClusterName: "sidero-pool", ClusterID: "id-1", NodeID: "id-6"}, server.Status.ManagementAPIStatus)
+}
+
+func TestSyncServerRequests(t *testing.T) {
+	t.Parallel()
+
+	var (
+		node   managementapi.NodeRegisterRequest
+		status managementapi.StatusUpdateRequest
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/nodes":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&node))
+		case "/api/v1/nodes/n-1/status":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&status))
+		}
+
+		json.NewEncoder(w).Encode(map[string]any{"node_id": "n-1", "success": true}) //nolint:errcheck
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := managementapi.NewClient(srv.URL)
+	require.NoError(t, err)
+
+	server := &metalv1.Server{
+		ObjectMeta: metav1.ObjectMeta{Name: "0000-1111"},
+		Spec: metalv1.ServerSpec{
+			Accepted: true,
+			Hostname: "metal-1",
+			Hardware: &metalv1.HardwareInformation{
+				System: &metalv1.SystemInformation{Manufacturer: "Sidero", ProductName: "Metal", SerialNumber: "S1"},
+				Compute: &metalv1.ComputeInformation{
+					TotalCoreCount:   8,
+					TotalThreadCount: 16,
+					Processors:       []*metalv1.Processor{{ProductName: "CPU"}},
+				},
+				Memory:  &metalv1.MemoryInformation{Modules: []*metalv1.MemoryModule{{Size: 8192}, {Size: 8192}}},
+				Storage: &metalv1.StorageInformation{Devices: []*metalv1.StorageDevice{{Size: 100, DeviceName: "/dev/sda"}, {Size: 200, DeviceName: "/dev/sdb"}}},
+				Network: &metalv1.NetworkInformation{Interfaces: []*metalv1.NetworkInterface{{MAC: "aa:bb:cc:dd:ee:ff"}, {Name: "lo"}}},
+			},
+		},
+		Status: metalv1.ServerStatus{
+			InUse: true,
+			Power: "on",
+			Addresses: []corev1.NodeAddress{
+				{Type: corev1.NodeHostName, Address: "metal-1"},
+				{Type: corev1.NodeInternalIP, Address: "10.5.0.2"},
+			},
+		},
+	}
+
+	require.NoError(t, managementapi.NewSyncService(client, logr.Discard()).SyncServer(context.Background(), server, &managementapi.ServerCluster{
+		Name:     "workload",
+		ID:       "c-1",
+		NodeType: "worker",
+	}))
+
+	hardware := &managementapi.NodeHardware{
+		Manufacturer: "Sidero",
+		ProductName:  "Metal",
+		SerialNumber: "S1",
+		CPUModel:     "CPU",
+		CPUCores:     8,
+		CPUThreads:   16,
+		MemoryMB:     16384,
+		StorageBytes: 300,
+		Disks:        []string{"/dev/sda", "/dev/sdb"},
+		MACAddresses: []string{"aa:bb:cc:dd:ee:ff"},
+	}
+
+	assert.Equal(t, managementapi.NodeRegisterRequest{
+		ClusterID: "c-1",
+		Hostname:  "metal-1",
+		IPAddress: "10.5.0.2",
+		NodeType:  "worker",
+		Hardware:  hardware,
+	}, node)
+
+	assert.Equal(t, managementapi.ServerStateAllocated, status.Status)
+	assert.Equal(t, []string{"metal-1", "10.5.0.2"}, status.Addresses)
+	assert.Equal(t, map[string]string{"power": "on", "in_use": "true", "is_clean": "false", "cordoned": "false"}, status.Metadata)
+	assert.Equal(t, hardware, status.Hardware)
+}
+
+func TestServerState(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range []struct {
+		name     string
+		spec     metalv1.ServerSpec
+		status   metalv1.ServerStatus
+		expected string
+	}{
+		{
+			name:     "not accepted",
+			expected: managementapi.ServerStatePending,
+		},
+		{
+			name:     "allocated",
+			spec:     metalv1.ServerSpec{Accepted: true, Cordoned: true},
+			status:   metalv1.ServerStatus{InUse: true},
+			expected: managementapi.ServerStateAllocated,
+		},
+		{
+			name:     "cordoned",
+			spec:     metalv1.ServerSpec{Accepted: true, Cordoned: true},
+			status:   metalv1.ServerStatus{IsClean: true},
+			expected: managementapi.ServerStateCordoned,
+		},
+		{
+			name:     "wiping",
+			spec:     metalv1.ServerSpec{Accepted: true},
+			expected: managementapi.ServerStateWiping,
+		},
+		{
+			name:     "available",
+			spec:     metalv1.ServerSpec{Accepted: true},
+			status:   metalv1.ServerStatus{IsClean: true},
+			expected: managementapi.ServerStateAvailable,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, managementapi.ServerState(&metalv1.Server{Spec: test.spec, Status: test.status}))
+		})
+	}
+}
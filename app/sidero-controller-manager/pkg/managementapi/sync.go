@@ -7,7 +7,6 @@ package managementapi
 import (
 	"context"
 	"fmt"
-	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
@@ -32,31 +31,59 @@ func NewSyncService(client *Client, logger logr.Logger) *SyncService {
 
 // SyncAdoptedServer synchronizes an AdoptedServer with the Management API.
 // It handles both cluster and node registration/updates.
+//
+// The cluster and node IDs are recorded in the AdoptedServer status (persisted by the controller),
+// so that the objects are registered once and only their status is updated afterwards.
 func (s *SyncService) SyncAdoptedServer(ctx context.Context, as *metalv1.AdoptedServer) error {
 	if as.Spec.ManagementAPI == nil || !as.Spec.ManagementAPI.Enabled {
 		return fmt.Errorf("Management API integration not enabled for AdoptedServer %s", as.Name)
 	}
 
+	if as.Status.ManagementAPIStatus == nil {
+		as.Status.ManagementAPIStatus = &metalv1.ManagementAPIStatus{}
+	}
+
+	status := as.Status.ManagementAPIStatus
+
 	// Step 1: Register or update cluster
-	clusterID := as.Spec.ManagementAPI.ClusterID
+	clusterID := ClusterID(as)
+	if clusterID != "" {
+		// Cluster already registered, update status
+		s.logger.Info("Updating cluster status in Management API", "cluster_id", clusterID)
+
+		err := s.updateClusterStatus(ctx, as, clusterID)
+
+		switch {
+		case err == nil:
+		case errors.Is(err, ErrNotFound) && as.Spec.ManagementAPI.ClusterID == "":
+			// Cluster was removed from the Management API, register it again
+			s.logger.Info("Cluster not found in Management API", "cluster_id", clusterID)
+
+			clusterID = ""
+		default:
+			return errors.Wrap(err, "failed to update cluster status")
+		}
+	}
+
 	if clusterID == "" {
 		// Cluster not yet registered, register it
 		s.logger.Info("Registering cluster with Management API", "cluster", as.Spec.ManagementAPI.ClusterName)
 		resp, err := s.registerCluster(ctx, as)
 		if err != nil {
+			if errors.Is(err, ErrConflict) {
+				return errors.Wrapf(err, "cluster %q is already registered, set spec.managementAPI.clusterID to its ID", as.Spec.ManagementAPI.ClusterName)
+			}
+
 			return errors.Wrap(err, "failed to register cluster")
 		}
 		clusterID = resp.ClusterID
 		s.logger.Info("Cluster registered successfully", "cluster_id", clusterID)
+	}
 
-		// Update the AdoptedServer spec with the cluster ID (this will be persisted by the controller)
-		as.Spec.ManagementAPI.ClusterID = clusterID
-	} else {
-		// Cluster already registered, update status
-		s.logger.Info("Updating cluster status in Management API", "cluster_id", clusterID)
-		if err := s.updateClusterStatus(ctx, as, clusterID); err != nil {
-			return errors.Wrap(err, "failed to update cluster status")
-		}
+	if status.ClusterID != clusterID {
+		// Nodes belong to the cluster, the previous registration is gone
+		status.ClusterID = clusterID
+		status.NodeID = ""
 	}
 
 	// Step 2: Register or update node
@@ -86,43 +113,27 @@ func (s *SyncService) registerCluster(ctx context.Context, as *metalv1.AdoptedSe
 
 // updateClusterStatus updates the cluster status in the Management API.
 func (s *SyncService) updateClusterStatus(ctx context.Context, as *metalv1.AdoptedServer, clusterID string) error {
-	status := "unknown"
-	health := "unknown"
+	_, err := s.client.UpdateClusterStatus(ctx, clusterID, statusUpdateRequest(as))
 
-	if as.Status.Connected {
-		status = "active"
-	}
+	return err
+}
 
-	if as.Status.Health != nil {
-		health = as.Status.Health.Status
-	}
+// registerOrUpdateNode registers the node in the Management API, or updates its status once registered.
+func (s *SyncService) registerOrUpdateNode(ctx context.Context, as *metalv1.AdoptedServer, clusterID string) error {
+	status := as.Status.ManagementAPIStatus
 
-	addresses := make([]string, 0, len(as.Status.Addresses))
-	for _, addr := range as.Status.Addresses {
-		addresses = append(addresses, addr.Address)
-	}
+	if status.NodeID != "" {
+		_, err := s.client.UpdateNodeStatus(ctx, status.NodeID, statusUpdateRequest(as))
+		if !errors.Is(err, ErrNotFound) {
+			return err
+		}
 
-	req := &StatusUpdateRequest{
-		Status:    status,
-		Health:    health,
-		Addresses: addresses,
-		Metadata: map[string]string{
-			"talos_version":      as.Spec.Talos.TalosVersion,
-			"kubernetes_version": as.Spec.Talos.KubernetesVersion,
-			"node_type":          as.Spec.Talos.NodeType,
-		},
-	}
+		// Node was removed from the Management API, register it again
+		s.logger.Info("Node not found in Management API", "node_id", status.NodeID)
 
-	if as.Status.LastContactTime != nil {
-		req.LastContact = as.Status.LastContactTime.Time
+		status.NodeID = ""
 	}
 
-	_, err := s.client.UpdateClusterStatus(ctx, clusterID, req)
-	return err
-}
-
-// registerOrUpdateNode registers or updates a node in the Management API.
-func (s *SyncService) registerOrUpdateNode(ctx context.Context, as *metalv1.AdoptedServer, clusterID string) error {
 	hostname := as.Spec.Talos.Hostname
 	if hostname == "" {
 		hostname = as.Name
@@ -130,8 +141,6 @@ func (s *SyncService) registerOrUpdateNode(ctx context.Context, as *metalv1.Adop
 
 	ipAddress := extractIPFromEndpoint(as.Spec.Talos.Endpoint)
 
-	// Check if node already exists (we could store node_id in status, but for now we'll try to register)
-	// The Management API should handle idempotent registration
 	req := &NodeRegisterRequest{
 		ClusterID:         clusterID,
 		Hostname:          hostname,
@@ -141,18 +150,120 @@ func (s *SyncService) registerOrUpdateNode(ctx context.Context, as *metalv1.Adop
 		KubernetesVersion: as.Spec.Talos.KubernetesVersion,
 	}
 
-	_, err := s.client.RegisterNode(ctx, req)
-	return err
+	resp, err := s.client.RegisterNode(ctx, req)
+	if errors.Is(err, ErrConflict) {
+		// The node ID was lost (e.g. status reset), adopt the existing node
+		status.NodeID, err = s.findNode(ctx, as.Spec.ManagementAPI.ClusterName, hostname)
+
+		return err
+	}
+
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("Node registered successfully", "node_id", resp.NodeID)
+
+	status.NodeID = resp.NodeID
+
+	return nil
+}
+
+// findNode returns the ID of the node already registered with the hostname in the cluster.
+func (s *SyncService) findNode(ctx context.Context, clusterName, hostname string) (string, error) {
+	resp, err := s.client.ListServers(ctx, clusterName)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to look up node %q in cluster %q", hostname, clusterName)
+	}
+
+	for _, server := range resp.Servers {
+		if server.Hostname == hostname && server.NodeID != "" {
+			s.logger.Info("Node already registered in Management API", "node_id", server.NodeID)
+
+			return server.NodeID, nil
+		}
+	}
+
+	return "", errors.Wrapf(ErrConflict, "node %q is already registered in cluster %q, but it is not listed", hostname, clusterName)
 }
 
 // UnregisterAdoptedServer removes an AdoptedServer from the Management API.
-func (s *SyncService) UnregisterAdoptedServer(ctx context.Context, as *metalv1.AdoptedServer) error {
-	// TODO: Implement node and cluster deletion in the Management API
-	// For now, this is a no-op as the Management API doesn't expose deletion endpoints yet
+//
+// The node is deleted if it was registered, and the cluster is deleted as well if deleteCluster is set,
+// i.e. the AdoptedServer is the last one of the cluster. Objects already removed from the Management API are ignored.
+func (s *SyncService) UnregisterAdoptedServer(ctx context.Context, as *metalv1.AdoptedServer, deleteCluster bool) error {
 	s.logger.Info("Unregistering AdoptedServer from Management API", "name", as.Name)
+
+	if as.Status.ManagementAPIStatus != nil && as.Status.ManagementAPIStatus.NodeID != "" {
+		nodeID := as.Status.ManagementAPIStatus.NodeID
+
+		if err := s.client.DeleteNode(ctx, nodeID); err != nil && !errors.Is(err, ErrNotFound) {
+			return errors.Wrapf(err, "failed to delete node %s", nodeID)
+		}
+
+		s.logger.Info("Node deleted from Management API", "node_id", nodeID)
+
+		as.Status.ManagementAPIStatus.NodeID = ""
+	}
+
+	clusterID := ClusterID(as)
+	if !deleteCluster || clusterID == "" {
+		return nil
+	}
+
+	if err := s.client.DeleteCluster(ctx, clusterID); err != nil && !errors.Is(err, ErrNotFound) {
+		return errors.Wrapf(err, "failed to delete cluster %s", clusterID)
+	}
+
+	s.logger.Info("Cluster deleted from Management API", "cluster_id", clusterID)
+
 	return nil
 }
 
+// ClusterID returns the Management API cluster ID of the AdoptedServer: the one set in the spec, or the registered one.
+func ClusterID(as *metalv1.AdoptedServer) string {
+	if as.Spec.ManagementAPI != nil && as.Spec.ManagementAPI.ClusterID != "" {
+		return as.Spec.ManagementAPI.ClusterID
+	}
+
+	if as.Status.ManagementAPIStatus != nil {
+		return as.Status.ManagementAPIStatus.ClusterID
+	}
+
+	return ""
+}
+
+// statusUpdateRequest builds the cluster and node status update from the AdoptedServer status.
+func statusUpdateRequest(as *metalv1.AdoptedServer) *StatusUpdateRequest {
+	status := "unknown"
+	health := "unknown"
+
+	if as.Status.Connected {
+		status = "active"
+	}
+
+	if as.Status.Health != nil {
+		health = as.Status.Health.Status
+	}
+
+	req := &StatusUpdateRequest{
+		Status:    status,
+		Health:    health,
+		Addresses: addressesToStrings(as.Status.Addresses),
+		Metadata: map[string]string{
+			"talos_version":      as.Spec.Talos.TalosVersion,
+			"kubernetes_version": as.Spec.Talos.KubernetesVersion,
+			"node_type":          as.Spec.Talos.NodeType,
+		},
+	}
+
+	if as.Status.LastContactTime != nil {
+		req.LastContact = as.Status.LastContactTime.Time
+	}
+
+	return req
+}
+
 // Helper functions
 
 // getLocationFromLabels extracts the location from AdoptedServer labels.
@@ -0,0 +1,205 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package managementapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	metalv1 "github.com/siderolabs/sidero/app/sidero-controller-manager/api/v1alpha2"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/pkg/managementapi"
+	"github.com/siderolabs/sidero/app/sidero-controller-manager/pkg/managementapi/fake"
+)
+
+// registry records the requests, and keeps the registered objects.
+type registry struct {
+	mu       sync.Mutex
+	objects  map[string]bool
+	requests []string
+	nextID   int
+}
+
+func (reg *registry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	reg.requests = append(reg.requests, r.Method+" "+r.URL.Path)
+
+	path := strings.TrimSuffix(r.URL.Path, "/status")
+
+	switch r.Method {
+	case http.MethodPost:
+		reg.nextID++
+
+		id := fmt.Sprintf("id-%d", reg.nextID)
+		reg.objects[path+"/"+id] = true
+
+		json.NewEncoder(w).Encode(map[string]string{"cluster_id": id, "node_id": id}) //nolint:errcheck
+	case http.MethodPatch, http.MethodDelete:
+		if !reg.objects[path] {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		if r.Method == http.MethodDelete {
+			delete(reg.objects, path)
+		}
+
+		json.NewEncoder(w).Encode(managementapi.StatusUpdateResponse{Success: true}) //nolint:errcheck
+	}
+}
+
+func (reg *registry) drain() []string {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	requests := reg.requests
+	reg.requests = nil
+
+	return requests
+}
+
+func TestSyncService(t *testing.T) {
+	t.Parallel()
+
+	reg := &registry{objects: map[string]bool{}}
+
+	srv := httptest.NewServer(reg)
+	t.Cleanup(srv.Close)
+
+	client, err := managementapi.NewClient(srv.URL)
+	require.NoError(t, err)
+
+	syncService := managementapi.NewSyncService(client, logr.Discard())
+	ctx := context.Background()
+
+	as := &metalv1.AdoptedServer{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Spec: metalv1.AdoptedServerSpec{
+			Talos: metalv1.TalosConfig{Endpoint: "10.5.0.2:50000"},
+			ManagementAPI: &metalv1.ManagementAPIConfig{
+				Enabled:     true,
+				Endpoint:    srv.URL,
+				ClusterName: "cluster",
+			},
+		},
+	}
+
+	// first sync registers the cluster and the node
+	require.NoError(t, syncService.SyncAdoptedServer(ctx, as))
+	assert.Equal(t, []string{"POST /api/v1/clusters", "POST /api/v1/nodes"}, reg.drain())
+	assert.Equal(t, "id-1", as.Status.ManagementAPIStatus.ClusterID)
+	assert.Equal(t, "id-2", as.Status.ManagementAPIStatus.NodeID)
+	assert.Empty(t, as.Spec.ManagementAPI.ClusterID)
+
+	// next syncs only update the status
+	require.NoError(t, syncService.SyncAdoptedServer(ctx, as))
+	assert.Equal(t, []string{"PATCH /api/v1/clusters/id-1/status", "PATCH /api/v1/nodes/id-2/status"}, reg.drain())
+
+	// node removed upstream is registered again
+	reg.mu.Lock()
+	delete(reg.objects, "/api/v1/nodes/id-2")
+	reg.mu.Unlock()
+
+	require.NoError(t, syncService.SyncAdoptedServer(ctx, as))
+	assert.Equal(t, []string{"PATCH /api/v1/clusters/id-1/status", "PATCH /api/v1/nodes/id-2/status", "POST /api/v1/nodes"}, reg.drain())
+	assert.Equal(t, "id-3", as.Status.ManagementAPIStatus.NodeID)
+
+	// the cluster is kept while other AdoptedServers use it
+	require.NoError(t, syncService.UnregisterAdoptedServer(ctx, as, false))
+	assert.Equal(t, []string{"DELETE /api/v1/nodes/id-3"}, reg.drain())
+	assert.Empty(t, as.Status.ManagementAPIStatus.NodeID)
+
+	require.NoError(t, syncService.UnregisterAdoptedServer(ctx, as, true))
+	assert.Equal(t, []string{"DELETE /api/v1/clusters/id-1"}, reg.drain())
+
+	// already deleted objects are ignored
+	as.Status.ManagementAPIStatus.NodeID = "id-3"
+
+	require.NoError(t, syncService.UnregisterAdoptedServer(ctx, as, true))
+	assert.Equal(t, []string{"DELETE /api/v1/nodes/id-3", "DELETE /api/v1/clusters/id-1"}, reg.drain())
+}
+
+func TestSyncServiceAdoptNode(t *testing.T) {
+	t.Parallel()
+
+	srv := fake.NewServer()
+	t.Cleanup(srv.Close)
+
+	client, err := managementapi.NewClient(srv.URL)
+	require.NoError(t, err)
+
+	syncService := managementapi.NewSyncService(client, logr.Discard())
+	ctx := context.Background()
+
+	as := &metalv1.AdoptedServer{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Spec: metalv1.AdoptedServerSpec{
+			Talos: metalv1.TalosConfig{Endpoint: "10.5.0.2:50000"},
+			ManagementAPI: &metalv1.ManagementAPIConfig{
+				Enabled:     true,
+				Endpoint:    srv.URL,
+				ClusterName: "cluster",
+			},
+		},
+	}
+
+	require.NoError(t, syncService.SyncAdoptedServer(ctx, as))
+
+	nodeID := as.Status.ManagementAPIStatus.NodeID
+	require.NotEmpty(t, nodeID)
+
+	srv.DrainRequests()
+
+	// the node ID is lost, the registered node is adopted
+	as.Status.ManagementAPIStatus.NodeID = ""
+
+	require.NoError(t, syncService.SyncAdoptedServer(ctx, as))
+	assert.Equal(t, []string{
+		"PATCH /api/v1/clusters/" + as.Status.ManagementAPIStatus.ClusterID + "/status",
+		"POST /api/v1/nodes",
+		"GET /api/v1/sidero/list-servers",
+	}, srv.DrainRequests())
+	assert.Equal(t, nodeID, as.Status.ManagementAPIStatus.NodeID)
+	assert.Len(t, srv.Nodes(), 1)
+
+	server := &metalv1.Server{
+		ObjectMeta: metav1.ObjectMeta{Name: "0000-1111"},
+		Spec:       metalv1.ServerSpec{Accepted: true, Hostname: "metal-1"},
+	}
+
+	cluster := &managementapi.ServerCluster{Name: "workload", NodeType: "worker", Managed: true}
+
+	require.NoError(t, syncService.SyncServer(ctx, server, cluster))
+
+	nodeID = server.Status.ManagementAPIStatus.NodeID
+	require.NotEmpty(t, nodeID)
+
+	srv.DrainRequests()
+
+	// same for the Servers, the status of the adopted node is pushed right away
+	server.Status.ManagementAPIStatus.NodeID = ""
+
+	require.NoError(t, syncService.SyncServer(ctx, server, cluster))
+	assert.Equal(t, []string{
+		"POST /api/v1/nodes",
+		"GET /api/v1/sidero/list-servers",
+		"PATCH /api/v1/nodes/" + nodeID + "/status",
+	}, srv.DrainRequests())
+	assert.Equal(t, nodeID, server.Status.ManagementAPIStatus.NodeID)
+	assert.Len(t, srv.Nodes(), 2)
+}
@@ -28,13 +28,28 @@ type ClusterRegisterResponse struct {
 
 // NodeRegisterRequest represents a request to register a node with the Management API.
 type NodeRegisterRequest struct {
-	ClusterID          string `json:"cluster_id"`
-	Hostname           string `json:"hostname"`
-	IPAddress          string `json:"ip_address"`
-	NodeType           string `json:"node_type"` // controlplane, worker
-	TalosVersion       string `json:"talos_version,omitempty"`
-	KubernetesVersion  string `json:"kubernetes_version,omitempty"`
-	MachineConfigPath  string `json:"machine_config_path,omitempty"`
+	ClusterID         string        `json:"cluster_id"`
+	Hostname          string        `json:"hostname"`
+	IPAddress         string        `json:"ip_address"`
+	NodeType          string        `json:"node_type"` // controlplane, worker
+	TalosVersion      string        `json:"talos_version,omitempty"`
+	KubernetesVersion string        `json:"kubernetes_version,omitempty"`
+	MachineConfigPath string        `json:"machine_config_path,omitempty"`
+	Hardware          *NodeHardware `json:"hardware,omitempty"` // bare-metal servers only
+}
+
+// NodeHardware represents the hardware inventory of a bare-metal node.
+type NodeHardware struct {
+	Manufacturer string   `json:"manufacturer,omitempty"`
+	ProductName  string   `json:"product_name,omitempty"`
+	SerialNumber string   `json:"serial_number,omitempty"`
+	CPUModel     string   `json:"cpu_model,omitempty"`
+	CPUCores     uint32   `json:"cpu_cores,omitempty"`
+	CPUThreads   uint32   `json:"cpu_threads,omitempty"`
+	MemoryMB     uint64   `json:"memory_mb,omitempty"`
+	StorageBytes uint64   `json:"storage_bytes,omitempty"`
+	Disks        []string `json:"disks,omitempty"`
+	MACAddresses []string `json:"mac_addresses,omitempty"`
 }
 
 // NodeRegisterResponse represents the response from registering a node.
@@ -53,6 +68,7 @@ type StatusUpdateRequest struct {
 	Addresses   []string          `json:"addresses,omitempty"`
 	LastContact time.Time         `json:"last_contact,omitempty"`
 	Metadata    map[string]string `json:"metadata,omitempty"`
+	Hardware    *NodeHardware     `json:"hardware,omitempty"` // bare-metal nodes only
 }
 
 // StatusUpdateResponse represents the response from a status update.
@@ -61,6 +77,30 @@ type StatusUpdateResponse struct {
 	Message string `json:"message,omitempty"`
 }
 
+// NodeStatusBatchRequest represents a request to update the status of several nodes of a cluster at once.
+type NodeStatusBatchRequest struct {
+	Updates []NodeStatusUpdate `json:"updates"`
+}
+
+// NodeStatusUpdate represents the status update of a node in a batch.
+type NodeStatusUpdate struct {
+	NodeID string `json:"node_id"`
+	StatusUpdateRequest
+}
+
+// NodeStatusBatchResponse represents the response from a batch status update, with a result for each node.
+type NodeStatusBatchResponse struct {
+	Results []NodeStatusResult `json:"results"`
+}
+
+// NodeStatusResult represents the result of the status update of a node in a batch.
+type NodeStatusResult struct {
+	NodeID  string `json:"node_id"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+	Code    int    `json:"code,omitempty"` // HTTP status code of the failed update
+}
+
 // ClusterInfoResponse represents cluster information from the Management API.
 type ClusterInfoResponse struct {
 	ClusterID         string            `json:"cluster_id"`
@@ -104,3 +144,22 @@ type ErrorResponse struct {
 	Error  string `json:"error,omitempty"`
 	Code   int    `json:"code,omitempty"`
 }
+
+// ServerListResponse represents the servers listed by the Sidero integration routes of the Management API.
+type ServerListResponse struct {
+	Servers []ServerInfo `json:"servers"`
+}
+
+// ServerInfo represents a server registered in the Management API.
+type ServerInfo struct {
+	Name              string            `json:"name"`
+	NodeID            string            `json:"node_id,omitempty"`
+	ClusterID         string            `json:"cluster_id,omitempty"`
+	ClusterName       string            `json:"cluster_name"`
+	TalosEndpoint     string            `json:"talos_endpoint"`
+	NodeType          string            `json:"node_type,omitempty"`
+	TalosVersion      string            `json:"talos_version,omitempty"`
+	KubernetesVersion string            `json:"kubernetes_version,omitempty"`
+	Hostname          string            `json:"hostname,omitempty"`
+	Labels            map[string]string `json:"labels,omitempty"`
+}